@@ -0,0 +1,98 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"tidalcore-backend/internal/service"
+	"tidalcore-backend/pkg/response"
+)
+
+type APITokenHandler struct {
+	apiTokenService *service.APITokenService
+}
+
+func NewAPITokenHandler() *APITokenHandler {
+	return &APITokenHandler{
+		apiTokenService: service.NewAPITokenService(),
+	}
+}
+
+// ListTokens 获取个人访问令牌列表
+func (h *APITokenHandler) ListTokens(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	tokens, err := h.apiTokenService.List(userID)
+	if err != nil {
+		response.ServerError(c, "获取访问令牌失败")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"tokens": tokens,
+		"scopes": service.APITokenScopes,
+	})
+}
+
+// CreateToken 创建个人访问令牌，令牌明文仅返回这一次
+func (h *APITokenHandler) CreateToken(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	var req service.CreateAPITokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请填写令牌名称并至少选择一个授权范围")
+		return
+	}
+
+	token, err := h.apiTokenService.Create(userID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidAPITokenScope):
+			response.BadRequest(c, "无效的授权范围")
+		case errors.Is(err, service.ErrAPITokenLimit):
+			response.BadRequest(c, fmt.Sprintf("最多只能创建 %d 个访问令牌", service.MaxAPITokensPerUser))
+		default:
+			response.ServerError(c, "创建访问令牌失败")
+		}
+		return
+	}
+
+	response.SuccessWithMsg(c, "访问令牌已创建，请立即复制保存", token)
+}
+
+// RevokeToken 删除个人访问令牌
+func (h *APITokenHandler) RevokeToken(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	tokenID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的令牌ID")
+		return
+	}
+
+	if err := h.apiTokenService.Revoke(userID, uint(tokenID)); err != nil {
+		if errors.Is(err, service.ErrAPITokenNotFound) {
+			response.NotFound(c, "访问令牌不存在")
+			return
+		}
+		response.ServerError(c, "删除访问令牌失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "访问令牌已删除", nil)
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"tidalcore-backend/internal/service"
+	"tidalcore-backend/pkg/response"
+)
+
+type AuditHandler struct {
+	auditService *service.AuditService
+}
+
+func NewAuditHandler() *AuditHandler {
+	return &AuditHandler{
+		auditService: service.NewAuditService(),
+	}
+}
+
+// auditQuery 解析筛选参数：actor_id、action、target_type、target_id、from、to
+func auditQuery(c *gin.Context) (*service.AuditQuery, bool) {
+	q := &service.AuditQuery{
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+		From:       c.Query("from"),
+		To:         c.Query("to"),
+	}
+	if s := c.Query("actor_id"); s != "" {
+		id, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			response.BadRequest(c, "无效的操作者ID")
+			return nil, false
+		}
+		actorID := uint(id)
+		q.ActorID = &actorID
+	}
+	return q, true
+}
+
+// ListAuditLogs 分页获取审计记录（管理员）
+func (h *AuditHandler) ListAuditLogs(c *gin.Context) {
+	q, ok := auditQuery(c)
+	if !ok {
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	logs, total, err := h.auditService.List(q, page, pageSize)
+	if err != nil {
+		handleAuditError(c, err, "获取审计记录失败")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"logs":      logs,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// ExportAuditLogs 按筛选条件导出审计记录（管理员），format 可选 csv（默认）和 json
+func (h *AuditHandler) ExportAuditLogs(c *gin.Context) {
+	q, ok := auditQuery(c)
+	if !ok {
+		return
+	}
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "json" {
+		response.BadRequest(c, "导出格式只支持 csv 和 json")
+		return
+	}
+
+	logs, truncated, err := h.auditService.Export(q)
+	if err != nil {
+		handleAuditError(c, err, "导出审计记录失败")
+		return
+	}
+	if truncated {
+		// 超出单次导出上限，只包含最新的记录，可缩小时间范围分批导出
+		c.Header("X-Audit-Truncated", "true")
+	}
+
+	filename := "audit_" + time.Now().Format("20060102_150405") + "." + format
+	if format == "json" {
+		c.Header("Content-Disposition", "attachment; filename="+filename)
+		c.JSON(200, logs)
+		return
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("\ufeff") // BOM，便于 Excel 正确识别 UTF-8
+	if err := h.auditService.WriteCSV(&buf, logs); err != nil {
+		response.ServerError(c, "导出审计记录失败")
+		return
+	}
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Data(200, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+func handleAuditError(c *gin.Context, err error, msg string) {
+	if errors.Is(err, service.ErrInvalidAuditDate) {
+		response.BadRequest(c, "日期格式应为 YYYY-MM-DD，且开始日期不能晚于结束日期")
+		return
+	}
+	response.ServerError(c, msg)
+}
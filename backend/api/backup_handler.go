@@ -22,7 +22,7 @@ func NewBackupHandler() *BackupHandler {
 
 // CreateBackup 创建备份
 func (h *BackupHandler) CreateBackup(c *gin.Context) {
-	backup, err := h.backupService.CreateBackup()
+	backup, err := h.backupService.CreateBackup(auditActor(c))
 	if err != nil {
 		response.ServerError(c, err.Error())
 		return
@@ -55,6 +55,10 @@ func (h *BackupHandler) DownloadBackup(c *gin.Context) {
 		response.NotFound(c, err.Error())
 		return
 	}
+	if err := h.backupService.RecordDownload(auditActor(c), filename); err != nil {
+		response.ServerError(c, "写入审计记录失败")
+		return
+	}
 
 	c.Header("Content-Description", "File Transfer")
 	c.Header("Content-Disposition", "attachment; filename="+filename)
@@ -70,7 +74,7 @@ func (h *BackupHandler) RestoreBackup(c *gin.Context) {
 		return
 	}
 
-	if err := h.backupService.RestoreBackup(filename); err != nil {
+	if err := h.backupService.RestoreBackup(auditActor(c), filename); err != nil {
 		response.ServerError(c, err.Error())
 		return
 	}
@@ -108,7 +112,7 @@ func (h *BackupHandler) UploadAndRestore(c *gin.Context) {
 	}
 
 	// 执行恢复
-	if err := h.backupService.RestoreFromSQL(string(content)); err != nil {
+	if err := h.backupService.RestoreFromSQL(auditActor(c), header.Filename, string(content)); err != nil {
 		response.ServerError(c, err.Error())
 		return
 	}
@@ -124,7 +128,7 @@ func (h *BackupHandler) DeleteBackup(c *gin.Context) {
 		return
 	}
 
-	if err := h.backupService.DeleteBackup(filename); err != nil {
+	if err := h.backupService.DeleteBackup(auditActor(c), filename); err != nil {
 		response.ServerError(c, err.Error())
 		return
 	}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"tidalcore-backend/internal/service"
+	"tidalcore-backend/pkg/response"
+)
+
+type ChallengeHandler struct {
+	challengeService *service.ChallengeService
+}
+
+func NewChallengeHandler() *ChallengeHandler {
+	return &ChallengeHandler{
+		challengeService: service.NewChallengeService(),
+	}
+}
+
+// handleChallengeError 统一处理挑战相关错误
+func handleChallengeError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrChallengeNotFound):
+		response.NotFound(c, "挑战不存在")
+	case errors.Is(err, service.ErrInvalidChallengeGoal):
+		response.BadRequest(c, "目标类型无效，可选值: sessions, minutes, streak")
+	case errors.Is(err, service.ErrInvalidChallengeDate):
+		response.BadRequest(c, "日期无效，格式为 YYYY-MM-DD，结束日期不能早于开始日期且跨度不超过一年")
+	case errors.Is(err, service.ErrChallengeEnded):
+		response.BadRequest(c, "挑战已结束")
+	case errors.Is(err, service.ErrChallengeNotEnded):
+		response.BadRequest(c, "挑战尚未结束")
+	case errors.Is(err, service.ErrChallengeStarted):
+		response.BadRequest(c, "挑战已开始，只能修改标题和描述")
+	case errors.Is(err, service.ErrAlreadyJoined):
+		response.BadRequest(c, "已参加该挑战")
+	case errors.Is(err, service.ErrNotJoined):
+		response.BadRequest(c, "未参加该挑战")
+	default:
+		response.ServerError(c, fallback)
+	}
+}
+
+func parseChallengeID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的挑战ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// ListChallenges 获取挑战列表
+func (h *ChallengeHandler) ListChallenges(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	status := c.DefaultQuery("status", "all")
+
+	challenges, err := h.challengeService.ListChallenges(userID, status)
+	if err != nil {
+		response.ServerError(c, "获取挑战列表失败")
+		return
+	}
+
+	response.Success(c, challenges)
+}
+
+// JoinChallenge 参加挑战
+func (h *ChallengeHandler) JoinChallenge(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	challengeID, ok := parseChallengeID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.challengeService.JoinChallenge(userID, challengeID); err != nil {
+		handleChallengeError(c, err, "参加挑战失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "参加成功", nil)
+}
+
+// LeaveChallenge 退出挑战
+func (h *ChallengeHandler) LeaveChallenge(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	challengeID, ok := parseChallengeID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.challengeService.LeaveChallenge(userID, challengeID); err != nil {
+		handleChallengeError(c, err, "退出挑战失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "已退出挑战", nil)
+}
+
+// GetProgress 获取挑战进度
+func (h *ChallengeHandler) GetProgress(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	challengeID, ok := parseChallengeID(c)
+	if !ok {
+		return
+	}
+
+	progress, err := h.challengeService.GetProgress(userID, challengeID)
+	if err != nil {
+		handleChallengeError(c, err, "获取挑战进度失败")
+		return
+	}
+
+	response.Success(c, progress)
+}
+
+// GetRanking 获取挑战完成排名
+func (h *ChallengeHandler) GetRanking(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	challengeID, ok := parseChallengeID(c)
+	if !ok {
+		return
+	}
+
+	ranking, err := h.challengeService.GetRanking(userID, challengeID)
+	if err != nil {
+		handleChallengeError(c, err, "获取挑战排名失败")
+		return
+	}
+
+	response.Success(c, ranking)
+}
+
+// ========== 管理员接口 ==========
+
+// CreateChallenge 创建挑战（管理员）
+func (h *ChallengeHandler) CreateChallenge(c *gin.Context) {
+	var req service.ChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数无效")
+		return
+	}
+
+	challenge, err := h.challengeService.CreateChallenge(auditActor(c), &req)
+	if err != nil {
+		handleChallengeError(c, err, "创建挑战失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "创建成功", challenge)
+}
+
+// UpdateChallenge 更新挑战（管理员）
+func (h *ChallengeHandler) UpdateChallenge(c *gin.Context) {
+	challengeID, ok := parseChallengeID(c)
+	if !ok {
+		return
+	}
+
+	var req service.ChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数无效")
+		return
+	}
+
+	challenge, err := h.challengeService.UpdateChallenge(auditActor(c), challengeID, &req)
+	if err != nil {
+		handleChallengeError(c, err, "更新挑战失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "更新成功", challenge)
+}
+
+// DeleteChallenge 删除挑战（管理员）
+func (h *ChallengeHandler) DeleteChallenge(c *gin.Context) {
+	challengeID, ok := parseChallengeID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.challengeService.DeleteChallenge(auditActor(c), challengeID); err != nil {
+		handleChallengeError(c, err, "删除挑战失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "挑战已删除", nil)
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"tidalcore-backend/internal/service"
+	"tidalcore-backend/pkg/response"
+)
+
+type CheerHandler struct {
+	cheerService *service.CheerService
+}
+
+func NewCheerHandler() *CheerHandler {
+	return &CheerHandler{
+		cheerService: service.NewCheerService(),
+	}
+}
+
+// SendCheer 发送鼓励
+func (h *CheerHandler) SendCheer(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	var req service.SendCheerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数无效")
+		return
+	}
+
+	cheer, err := h.cheerService.SendCheer(userID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCheerKind):
+			response.BadRequest(c, "鼓励类型无效")
+		case errors.Is(err, service.ErrCheerSelf):
+			response.BadRequest(c, "不能给自己发送鼓励")
+		case errors.Is(err, service.ErrCheerUserNotFound):
+			response.NotFound(c, "用户不存在")
+		case errors.Is(err, service.ErrCheerNotCheckedIn):
+			response.BadRequest(c, "对方今日尚未打卡")
+		case errors.Is(err, service.ErrCheerAlreadySent):
+			response.BadRequest(c, "今天已经鼓励过该用户")
+		case errors.Is(err, service.ErrCheerDailyLimit):
+			response.TooManyRequests(c, "今日鼓励次数已达上限")
+		default:
+			response.ServerError(c, "发送鼓励失败")
+		}
+		return
+	}
+
+	response.SuccessWithMsg(c, "鼓励已送达", cheer)
+}
+
+// GetReceived 获取收到的鼓励
+func (h *CheerHandler) GetReceived(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "30"))
+	if err != nil || limit <= 0 {
+		limit = 30
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	cheers, err := h.cheerService.GetReceived(userID, limit)
+	if err != nil {
+		response.ServerError(c, "获取鼓励记录失败")
+		return
+	}
+
+	response.Success(c, cheers)
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"tidalcore-backend/internal/auth"
+	"tidalcore-backend/internal/realtime"
+	"tidalcore-backend/internal/service"
+	"tidalcore-backend/pkg/response"
+)
+
+const (
+	sseHeartbeatInterval = 25 * time.Second
+	sseRetryMillis       = 3000
+)
+
+type EventHandler struct {
+	hub     *realtime.Hub
+	tickets *service.EventTicketService
+}
+
+func NewEventHandler() *EventHandler {
+	return &EventHandler{
+		hub:     realtime.Default(),
+		tickets: service.GetEventTicketService(),
+	}
+}
+
+// IssueTicket 签发事件流票据，用于 EventSource 通过 ticket 查询参数建立连接
+func (h *EventHandler) IssueTicket(c *gin.Context) {
+	value, _ := c.Get("claims")
+	claims, ok := value.(*auth.Claims)
+	if !ok {
+		response.ServerError(c, "签发票据失败")
+		return
+	}
+
+	ticket, expiresAt, err := h.tickets.Issue(claims)
+	if err != nil {
+		response.ServerError(c, "签发票据失败")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"ticket":     ticket,
+		"expires_at": expiresAt,
+	})
+}
+
+// Stream 通过 Server-Sent Events 推送实时事件
+// 匿名连接只接收全站统计事件，登录用户额外接收自己的通知
+// 断线重连时通过 Last-Event-ID 请求头（或 last_event_id 查询参数）补发错过的事件
+// 登录用户的连接在每次心跳时重新校验，令牌或会话被吊销、用户被删除或封禁后断开
+func (h *EventHandler) Stream(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	claims, _ := c.Get("claims")
+
+	lastIDStr := c.GetHeader("Last-Event-ID")
+	if lastIDStr == "" {
+		lastIDStr = c.Query("last_event_id")
+	}
+	lastEventID, _ := strconv.ParseUint(lastIDStr, 10, 64)
+
+	sub, missed := h.hub.Subscribe(userID, lastEventID)
+	defer h.hub.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // 关闭 nginx 缓冲
+
+	w := c.Writer
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetryMillis)
+	for _, event := range missed {
+		if err := writeEvent(c, event); err != nil {
+			return
+		}
+	}
+	w.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-sub.Events:
+			if err := writeEvent(c, event); err != nil {
+				return
+			}
+			w.Flush()
+		case <-heartbeat.C:
+			if userID != 0 && !streamAuthorized(claims.(*auth.Claims)) {
+				return
+			}
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			w.Flush()
+		}
+	}
+}
+
+// streamAuthorized 检查建立连接所用的令牌是否仍然有效
+func streamAuthorized(claims *auth.Claims) bool {
+	if service.GetRevocationService().IsRevoked(claims) {
+		return false
+	}
+	state, err := service.GetUserStateCache().Get(claims.UserID)
+	if err != nil {
+		// 数据库暂时不可用时保持连接，下次心跳再检查
+		return true
+	}
+	return state.Exists && !state.Suspended
+}
+
+// writeEvent 按 SSE 格式写入单个事件
+func writeEvent(c *gin.Context, event *realtime.Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
@@ -0,0 +1,253 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"tidalcore-backend/internal/service"
+	"tidalcore-backend/pkg/response"
+)
+
+type GroupHandler struct {
+	groupService *service.GroupService
+}
+
+func NewGroupHandler() *GroupHandler {
+	return &GroupHandler{
+		groupService: service.NewGroupService(),
+	}
+}
+
+// handleGroupError 统一处理小组相关错误
+func handleGroupError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrGroupNotFound):
+		response.NotFound(c, "小组不存在")
+	case errors.Is(err, service.ErrInvalidInviteCode):
+		response.BadRequest(c, "邀请码无效")
+	case errors.Is(err, service.ErrAlreadyGroupMember):
+		response.BadRequest(c, "你已经是该小组成员")
+	case errors.Is(err, service.ErrNotGroupMember):
+		response.Forbidden(c, "你不是该小组成员")
+	case errors.Is(err, service.ErrNotGroupOwner):
+		response.Forbidden(c, "只有组长可以执行此操作")
+	case errors.Is(err, service.ErrOwnerCannotLeave):
+		response.BadRequest(c, "组长不能退出小组，请解散小组")
+	case errors.Is(err, service.ErrTooManyGroups):
+		response.BadRequest(c, "加入的小组数量已达上限")
+	default:
+		response.ServerError(c, fallback)
+	}
+}
+
+func parseGroupID(c *gin.Context) (uint, bool) {
+	groupID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的小组ID")
+		return 0, false
+	}
+	return uint(groupID), true
+}
+
+// CreateGroup 创建小组
+func (h *GroupHandler) CreateGroup(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	var req service.CreateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数无效")
+		return
+	}
+
+	group, err := h.groupService.CreateGroup(userID, &req)
+	if err != nil {
+		handleGroupError(c, err, "创建小组失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "创建成功", group)
+}
+
+// JoinGroup 通过邀请码加入小组
+func (h *GroupHandler) JoinGroup(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	var req service.JoinGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数无效")
+		return
+	}
+
+	group, err := h.groupService.JoinGroup(userID, &req)
+	if err != nil {
+		handleGroupError(c, err, "加入小组失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "加入成功", group)
+}
+
+// GetMyGroups 获取我加入的小组
+func (h *GroupHandler) GetMyGroups(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	groups, err := h.groupService.GetMyGroups(userID)
+	if err != nil {
+		response.ServerError(c, "获取小组列表失败")
+		return
+	}
+
+	response.Success(c, groups)
+}
+
+// GetGroup 获取小组详情
+func (h *GroupHandler) GetGroup(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	groupID, ok := parseGroupID(c)
+	if !ok {
+		return
+	}
+
+	detail, err := h.groupService.GetGroupDetail(userID, groupID)
+	if err != nil {
+		handleGroupError(c, err, "获取小组详情失败")
+		return
+	}
+
+	response.Success(c, detail)
+}
+
+// LeaveGroup 退出小组
+func (h *GroupHandler) LeaveGroup(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	groupID, ok := parseGroupID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.groupService.LeaveGroup(userID, groupID); err != nil {
+		handleGroupError(c, err, "退出小组失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "已退出小组", nil)
+}
+
+// DeleteGroup 解散小组
+func (h *GroupHandler) DeleteGroup(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	groupID, ok := parseGroupID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.groupService.DeleteGroup(userID, groupID); err != nil {
+		handleGroupError(c, err, "解散小组失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "小组已解散", nil)
+}
+
+// RemoveMember 移除小组成员
+func (h *GroupHandler) RemoveMember(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	groupID, ok := parseGroupID(c)
+	if !ok {
+		return
+	}
+
+	targetID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的用户ID")
+		return
+	}
+
+	if err := h.groupService.RemoveMember(userID, groupID, uint(targetID)); err != nil {
+		handleGroupError(c, err, "移除成员失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "成员已移除", nil)
+}
+
+// RegenerateInviteCode 重新生成邀请码
+func (h *GroupHandler) RegenerateInviteCode(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	groupID, ok := parseGroupID(c)
+	if !ok {
+		return
+	}
+
+	group, err := h.groupService.RegenerateInviteCode(userID, groupID)
+	if err != nil {
+		handleGroupError(c, err, "生成邀请码失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "邀请码已更新", group)
+}
+
+// GetGroupLeaderboard 获取小组排行榜
+func (h *GroupHandler) GetGroupLeaderboard(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	groupID, ok := parseGroupID(c)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	users, err := h.groupService.GetGroupLeaderboard(userID, groupID, limit)
+	if err != nil {
+		handleGroupError(c, err, "获取小组排行榜失败")
+		return
+	}
+
+	response.Success(c, users)
+}
+
+// GetGroupHeatmap 获取小组热力图
+func (h *GroupHandler) GetGroupHeatmap(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	groupID, ok := parseGroupID(c)
+	if !ok {
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "365"))
+	if err != nil || days <= 0 {
+		days = 365
+	}
+	if days > 365 {
+		days = 365
+	}
+
+	heatmap, err := h.groupService.GetGroupHeatmap(userID, groupID, days)
+	if err != nil {
+		handleGroupError(c, err, "获取小组热力图失败")
+		return
+	}
+
+	response.Success(c, heatmap)
+}
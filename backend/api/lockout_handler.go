@@ -0,0 +1,56 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"tidalcore-backend/internal/service"
+	"tidalcore-backend/pkg/response"
+)
+
+type LockoutHandler struct {
+	guard *service.LoginGuardService
+}
+
+func NewLockoutHandler() *LockoutHandler {
+	return &LockoutHandler{
+		guard: service.GetLoginGuard(),
+	}
+}
+
+// ListLockouts 获取登录失败及锁定记录（管理员）
+func (h *LockoutHandler) ListLockouts(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	lockedOnly := c.Query("locked") == "true"
+
+	lockouts, total, err := h.guard.ListLockouts(lockedOnly, page, pageSize)
+	if err != nil {
+		response.ServerError(c, "获取锁定记录失败")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"lockouts":  lockouts,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// Unlock 解除账号的登录锁定（管理员）
+func (h *LockoutHandler) Unlock(c *gin.Context) {
+	username := c.Param("username")
+	if err := h.guard.Unlock(auditActor(c), username); err != nil {
+		if errors.Is(err, service.ErrLockoutNotFound) {
+			response.NotFound(c, "该用户名没有锁定记录")
+			return
+		}
+		response.ServerError(c, "解除锁定失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "已解除锁定", nil)
+}
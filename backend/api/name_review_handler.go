@@ -0,0 +1,97 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"tidalcore-backend/internal/service"
+	"tidalcore-backend/pkg/response"
+)
+
+type NameReviewHandler struct {
+	reviewService *service.NameReviewService
+}
+
+func NewNameReviewHandler() *NameReviewHandler {
+	return &NameReviewHandler{
+		reviewService: service.NewNameReviewService(),
+	}
+}
+
+// ListReviews 获取显示名称审核队列（管理员），status 可选 pending、approved、rejected、cancelled
+func (h *NameReviewHandler) ListReviews(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	reviews, total, err := h.reviewService.List(c.Query("status"), page, pageSize)
+	if err != nil {
+		response.ServerError(c, "获取审核列表失败")
+		return
+	}
+	pending, err := h.reviewService.CountPending()
+	if err != nil {
+		response.ServerError(c, "获取审核列表失败")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"reviews":       reviews,
+		"total":         total,
+		"pending_count": pending,
+		"page":          page,
+		"page_size":     pageSize,
+	})
+}
+
+// ApproveReview 通过显示名称审核（管理员）
+func (h *NameReviewHandler) ApproveReview(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的审核ID")
+		return
+	}
+
+	if err := h.reviewService.Approve(auditActor(c), uint(id)); err != nil {
+		handleNameReviewError(c, err)
+		return
+	}
+
+	response.SuccessWithMsg(c, "已通过，用户的显示名称已更新", nil)
+}
+
+// RejectReview 驳回显示名称（管理员）
+func (h *NameReviewHandler) RejectReview(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的审核ID")
+		return
+	}
+
+	// 请求体可选，可附带驳回原因
+	var req service.RejectNameRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(c, "请求参数无效")
+		return
+	}
+
+	if err := h.reviewService.Reject(auditActor(c), uint(id), &req); err != nil {
+		handleNameReviewError(c, err)
+		return
+	}
+
+	response.SuccessWithMsg(c, "已驳回", nil)
+}
+
+func handleNameReviewError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrNameReviewNotFound):
+		response.NotFound(c, "审核记录不存在")
+	case errors.Is(err, service.ErrNameReviewHandled):
+		response.BadRequest(c, "该申请已被处理或已被用户取消")
+	default:
+		response.ServerError(c, "操作失败")
+	}
+}
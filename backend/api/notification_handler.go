@@ -0,0 +1,124 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"tidalcore-backend/internal/service"
+	"tidalcore-backend/pkg/response"
+)
+
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+}
+
+func NewNotificationHandler() *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: service.NewNotificationService(),
+	}
+}
+
+// GetNotifications 获取通知列表及未读数
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "30"))
+	if err != nil || limit <= 0 {
+		limit = 30
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	unreadOnly := c.Query("unread") == "true"
+
+	list, err := h.notificationService.List(userID, unreadOnly, limit)
+	if err != nil {
+		response.ServerError(c, "获取通知失败")
+		return
+	}
+
+	response.Success(c, list)
+}
+
+// GetUnreadCount 获取未读通知数
+func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	count, err := h.notificationService.UnreadCount(userID)
+	if err != nil {
+		response.ServerError(c, "获取未读数失败")
+		return
+	}
+
+	response.Success(c, gin.H{"unread_count": count})
+}
+
+// MarkRead 标记通知为已读
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的通知ID")
+		return
+	}
+
+	if err := h.notificationService.MarkRead(userID, uint(id)); err != nil {
+		if errors.Is(err, service.ErrNotificationNotFound) {
+			response.NotFound(c, "通知不存在")
+			return
+		}
+		response.ServerError(c, "操作失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "已标记为已读", nil)
+}
+
+// MarkAllRead 标记所有通知为已读
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	count, err := h.notificationService.MarkAllRead(userID)
+	if err != nil {
+		response.ServerError(c, "操作失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "已全部标记为已读", gin.H{"marked": count})
+}
+
+// Announce 发布公告（管理员）
+func (h *NotificationHandler) Announce(c *gin.Context) {
+	var req service.AnnouncementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数无效")
+		return
+	}
+
+	count, err := h.notificationService.Announce(auditActor(c), &req)
+	if err != nil {
+		response.ServerError(c, "发布公告失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "公告已发布", gin.H{"recipients": count})
+}
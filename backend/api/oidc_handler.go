@@ -0,0 +1,177 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"tidalcore-backend/internal/service"
+	"tidalcore-backend/pkg/response"
+)
+
+type OIDCHandler struct {
+	oidcService *service.OIDCService
+	userService *service.UserService
+}
+
+func NewOIDCHandler() *OIDCHandler {
+	return &OIDCHandler{
+		oidcService: service.GetOIDCService(),
+		userService: service.NewUserService(),
+	}
+}
+
+// GetInfo 获取单点登录配置，前端据此决定是否显示登录按钮
+func (h *OIDCHandler) GetInfo(c *gin.Context) {
+	response.Success(c, h.oidcService.Info())
+}
+
+// Login 跳转到身份提供方授权页面
+func (h *OIDCHandler) Login(c *gin.Context) {
+	authURL, err := h.oidcService.BeginAuth(c.Request.Context(), c.Query("redirect"), 0)
+	if err != nil {
+		if errors.Is(err, service.ErrOIDCDisabled) {
+			response.NotFound(c, "未启用单点登录")
+			return
+		}
+		log.Printf("Warning: Failed to start OIDC login: %v", err)
+		c.Redirect(http.StatusFound, h.oidcService.ErrorRedirect("provider_unavailable"))
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback 身份提供方授权完成后的回调，处理后跳转回前端
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	if idpErr := c.Query("error"); idpErr != "" {
+		c.Redirect(http.StatusFound, h.oidcService.ErrorRedirect(idpErr))
+		return
+	}
+
+	redirect, err := h.oidcService.HandleCallback(c.Request.Context(), c.Query("state"), c.Query("code"))
+	if err != nil {
+		var reason string
+		switch {
+		case errors.Is(err, service.ErrOIDCDisabled):
+			response.NotFound(c, "未启用单点登录")
+			return
+		case errors.Is(err, service.ErrOIDCInvalidState):
+			reason = "invalid_state"
+		case errors.Is(err, service.ErrOIDCSignupDisabled):
+			reason = "signup_disabled"
+		case errors.Is(err, service.ErrOIDCIdentityLinked):
+			reason = "already_linked"
+		default:
+			log.Printf("Warning: OIDC callback failed: %v", err)
+			reason = "login_failed"
+		}
+		c.Redirect(http.StatusFound, h.oidcService.ErrorRedirect(reason))
+		return
+	}
+
+	c.Redirect(http.StatusFound, redirect)
+}
+
+// Exchange 使用一次性登录码换取令牌
+func (h *OIDCHandler) Exchange(c *gin.Context) {
+	var req service.OIDCExchangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数无效")
+		return
+	}
+
+	resp, err := h.userService.LoginOIDC(&req, clientInfo(c))
+	if err != nil {
+		if errors.Is(err, service.ErrOIDCInvalidLoginCode) {
+			response.Unauthorized(c, "登录已失效，请重新登录")
+			return
+		}
+		if respondSuspended(c, err) {
+			return
+		}
+		response.ServerError(c, "登录失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "登录成功", resp)
+}
+
+// ListIdentities 获取已绑定的外部身份
+func (h *OIDCHandler) ListIdentities(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	identities, err := h.oidcService.ListIdentities(userID)
+	if err != nil {
+		response.ServerError(c, "获取绑定信息失败")
+		return
+	}
+
+	response.Success(c, identities)
+}
+
+// LinkIdentity 获取绑定外部身份的授权地址，由前端跳转
+func (h *OIDCHandler) LinkIdentity(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	authURL, err := h.oidcService.BeginAuth(c.Request.Context(), "", userID)
+	if err != nil {
+		if errors.Is(err, service.ErrOIDCDisabled) {
+			response.NotFound(c, "未启用单点登录")
+			return
+		}
+		log.Printf("Warning: Failed to start OIDC link: %v", err)
+		response.ServerError(c, "无法连接身份提供方")
+		return
+	}
+
+	response.Success(c, gin.H{"url": authURL})
+}
+
+// UnlinkIdentity 解除外部身份绑定
+func (h *OIDCHandler) UnlinkIdentity(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	identityID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的绑定ID")
+		return
+	}
+
+	var req service.UnlinkIdentityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请输入密码")
+		return
+	}
+
+	if err := h.oidcService.Unlink(userID, uint(identityID), &req); err != nil {
+		switch {
+		case errors.Is(err, service.ErrOldPasswordWrong):
+			response.BadRequest(c, "密码错误")
+		case errors.Is(err, service.ErrPasswordNotSet):
+			response.BadRequest(c, "请先设置登录密码再解除绑定")
+		case errors.Is(err, service.ErrIdentityNotFound):
+			response.NotFound(c, "绑定不存在")
+		default:
+			response.ServerError(c, "解除绑定失败")
+		}
+		return
+	}
+
+	response.SuccessWithMsg(c, "已解除绑定", nil)
+}
@@ -0,0 +1,186 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"tidalcore-backend/internal/service"
+	"tidalcore-backend/pkg/response"
+)
+
+type PasskeyHandler struct {
+	passkeyService *service.PasskeyService
+	userService    *service.UserService
+}
+
+func NewPasskeyHandler() *PasskeyHandler {
+	return &PasskeyHandler{
+		passkeyService: service.GetPasskeyService(),
+		userService:    service.NewUserService(),
+	}
+}
+
+// BeginLogin 获取通行密钥登录参数
+func (h *PasskeyHandler) BeginLogin(c *gin.Context) {
+	resp, err := h.passkeyService.BeginLogin()
+	if err != nil {
+		response.ServerError(c, "获取登录参数失败")
+		return
+	}
+
+	response.Success(c, resp)
+}
+
+// FinishLogin 提交通行密钥签名完成登录
+func (h *PasskeyHandler) FinishLogin(c *gin.Context) {
+	var req service.PasskeyLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数无效")
+		return
+	}
+
+	resp, err := h.userService.LoginPasskey(&req, clientInfo(c))
+	if err != nil {
+		if respondSuspended(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, service.ErrInvalidPasskeySession):
+			response.Unauthorized(c, "登录已超时，请重试")
+		case errors.Is(err, service.ErrPasskeyVerification):
+			response.Unauthorized(c, "通行密钥验证失败")
+		default:
+			response.ServerError(c, "登录失败")
+		}
+		return
+	}
+
+	response.SuccessWithMsg(c, "登录成功", resp)
+}
+
+// ListPasskeys 获取已注册的通行密钥
+func (h *PasskeyHandler) ListPasskeys(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	passkeys, err := h.passkeyService.List(userID)
+	if err != nil {
+		response.ServerError(c, "获取通行密钥失败")
+		return
+	}
+
+	response.Success(c, passkeys)
+}
+
+// BeginRegistration 获取通行密钥注册参数
+func (h *PasskeyHandler) BeginRegistration(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	options, err := h.passkeyService.BeginRegistration(userID)
+	if err != nil {
+		if errors.Is(err, service.ErrTooManyPasskeys) {
+			response.BadRequest(c, fmt.Sprintf("最多只能添加 %d 个通行密钥", service.MaxPasskeysPerUser))
+			return
+		}
+		response.ServerError(c, "获取注册参数失败")
+		return
+	}
+
+	response.Success(c, options)
+}
+
+// FinishRegistration 提交浏览器生成的通行密钥
+func (h *PasskeyHandler) FinishRegistration(c *gin.Context) {
+	if c.GetUint("user_id") == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	var req service.PasskeyRegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数无效")
+		return
+	}
+
+	passkey, err := h.passkeyService.FinishRegistration(auditActor(c), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidPasskeySession):
+			response.BadRequest(c, "注册已超时，请重试")
+		case errors.Is(err, service.ErrPasskeyVerification):
+			response.BadRequest(c, "通行密钥验证失败")
+		default:
+			response.ServerError(c, "添加通行密钥失败")
+		}
+		return
+	}
+
+	response.SuccessWithMsg(c, "通行密钥已添加", passkey)
+}
+
+// RenamePasskey 修改通行密钥名称
+func (h *PasskeyHandler) RenamePasskey(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	passkeyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的通行密钥ID")
+		return
+	}
+
+	var req service.RenamePasskeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "名称长度为 1-50 个字符")
+		return
+	}
+
+	if err := h.passkeyService.Rename(userID, uint(passkeyID), &req); err != nil {
+		if errors.Is(err, service.ErrPasskeyNotFound) {
+			response.NotFound(c, "通行密钥不存在")
+			return
+		}
+		response.ServerError(c, "修改失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "已修改", nil)
+}
+
+// DeletePasskey 删除通行密钥
+func (h *PasskeyHandler) DeletePasskey(c *gin.Context) {
+	if c.GetUint("user_id") == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	passkeyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的通行密钥ID")
+		return
+	}
+
+	if err := h.passkeyService.Delete(auditActor(c), uint(passkeyID)); err != nil {
+		if errors.Is(err, service.ErrPasskeyNotFound) {
+			response.NotFound(c, "通行密钥不存在")
+			return
+		}
+		response.ServerError(c, "删除通行密钥失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "通行密钥已删除", nil)
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"tidalcore-backend/internal/service"
+	"tidalcore-backend/pkg/response"
+)
+
+type PasswordResetHandler struct {
+	resetService *service.PasswordResetService
+}
+
+func NewPasswordResetHandler() *PasswordResetHandler {
+	return &PasswordResetHandler{
+		resetService: service.NewPasswordResetService(),
+	}
+}
+
+// ResetPassword 凭管理员签发的重置码设置新密码
+func (h *PasswordResetHandler) ResetPassword(c *gin.Context) {
+	var req service.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数无效")
+		return
+	}
+
+	if err := h.resetService.ResetPassword(&req, c.ClientIP()); err != nil {
+		if errors.Is(err, service.ErrInvalidResetCode) {
+			response.BadRequest(c, "重置码无效或已过期")
+			return
+		}
+		if respondPasswordPolicy(c, err) {
+			return
+		}
+		response.ServerError(c, "重置密码失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "密码已重置，请使用新密码登录", nil)
+}
+
+// RecoverPassword 使用自己保存的恢复码重置密码
+func (h *PasswordResetHandler) RecoverPassword(c *gin.Context) {
+	var req service.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数无效")
+		return
+	}
+
+	if err := h.resetService.RecoverPassword(&req, c.ClientIP()); err != nil {
+		if respondPasswordPolicy(c, err) {
+			return
+		}
+		var lockedErr *service.AccountLockedError
+		switch {
+		case errors.Is(err, service.ErrInvalidRecoveryCode):
+			response.BadRequest(c, "用户名或恢复码错误")
+		case errors.As(err, &lockedErr), errors.Is(err, service.ErrTooManyAttempts):
+			response.TooManyRequests(c, "尝试次数过多，请稍后再试")
+		default:
+			response.ServerError(c, "重置密码失败")
+		}
+		return
+	}
+
+	response.SuccessWithMsg(c, "密码已重置，请使用新密码登录", nil)
+}
+
+// GetRecoveryCodeStatus 查看剩余恢复码数量
+func (h *PasswordResetHandler) GetRecoveryCodeStatus(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	status, err := h.resetService.GetRecoveryCodeStatus(userID)
+	if err != nil {
+		response.ServerError(c, "获取恢复码状态失败")
+		return
+	}
+
+	response.Success(c, status)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧的一组全部作废
+func (h *PasswordResetHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	actor := auditActor(c)
+	if actor.ID == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	var req service.RegenerateRecoveryCodesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请输入密码")
+		return
+	}
+
+	codes, err := h.resetService.RegenerateRecoveryCodes(actor, &req)
+	if err != nil {
+		if errors.Is(err, service.ErrOldPasswordWrong) {
+			response.BadRequest(c, "密码错误")
+			return
+		}
+		response.ServerError(c, "生成恢复码失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "已生成新的恢复码，旧恢复码已失效", gin.H{"recovery_codes": codes})
+}
+
+// IssueResetCode 为用户签发一次性密码重置码（管理员）
+func (h *PasswordResetHandler) IssueResetCode(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的用户ID")
+		return
+	}
+
+	issued, err := h.resetService.IssueResetCode(auditActor(c), uint(userID))
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			response.NotFound(c, "用户不存在")
+			return
+		}
+		response.ServerError(c, "生成重置码失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "重置码已生成，请通过可信渠道转交给用户", issued)
+}
+
+// ListResetCodes 查看用户的重置码记录（管理员）
+func (h *PasswordResetHandler) ListResetCodes(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的用户ID")
+		return
+	}
+
+	resets, err := h.resetService.ListResetCodes(uint(userID))
+	if err != nil {
+		response.ServerError(c, "获取重置记录失败")
+		return
+	}
+
+	response.Success(c, resets)
+}
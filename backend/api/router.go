@@ -1,9 +1,15 @@
 package api
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 
+	"tidalcore-backend/config"
+	"tidalcore-backend/internal/auth"
+	"tidalcore-backend/internal/service"
 	"tidalcore-backend/middleware"
+	"tidalcore-backend/pkg/ratelimit"
 )
 
 func SetupRouter(mode string) *gin.Engine {
@@ -18,20 +24,63 @@ func SetupRouter(mode string) *gin.Engine {
 	checkinHandler := NewCheckinHandler()
 	visitHandler := NewVisitHandler()
 	backupHandler := NewBackupHandler()
+	groupHandler := NewGroupHandler()
+	challengeHandler := NewChallengeHandler()
+	cheerHandler := NewCheerHandler()
+	notificationHandler := NewNotificationHandler()
+	eventHandler := NewEventHandler()
+	sessionHandler := NewSessionHandler()
+	lockoutHandler := NewLockoutHandler()
+	twoFactorHandler := NewTwoFactorHandler()
+	apiTokenHandler := NewAPITokenHandler()
+	oidcHandler := NewOIDCHandler()
+	resetHandler := NewPasswordResetHandler()
+	passkeyHandler := NewPasskeyHandler()
+	nameReviewHandler := NewNameReviewHandler()
+	auditHandler := NewAuditHandler()
+	statsHandler := NewStatsHandler()
+
+	// 登录/注册接口按 IP 限流
+	authRate := config.Get().Security.AuthRatePerMinute
+	authLimit := middleware.RateLimit(ratelimit.New(authRate, time.Minute, authRate))
 
 	// 健康检查
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 
+	// 访问令牌验证公钥 (JWKS)，供其他服务离线校验令牌
+	r.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		keys, err := auth.JWKS()
+		if err != nil {
+			c.JSON(500, gin.H{"error": "failed to load keys"})
+			return
+		}
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(200, gin.H{"keys": keys})
+	})
+
 	// API v1
 	v1 := r.Group("/api/v1")
 	{
 		// 公开接口
 		auth := v1.Group("/auth")
 		{
-			auth.POST("/register", userHandler.Register)
-			auth.POST("/login", userHandler.Login)
+			auth.POST("/register", authLimit, userHandler.Register)
+			auth.POST("/login", authLimit, userHandler.Login)
+			auth.POST("/login/2fa", authLimit, userHandler.LoginTwoFactor)
+			auth.POST("/refresh", userHandler.RefreshToken)
+			auth.POST("/reset", authLimit, resetHandler.ResetPassword)
+			auth.POST("/recover", authLimit, resetHandler.RecoverPassword)
+			auth.POST("/logout", middleware.JWTAuth(), userHandler.Logout)
+
+			// 单点登录 (OIDC)
+			auth.GET("/oidc", oidcHandler.GetInfo)
+			auth.GET("/oidc/login", authLimit, oidcHandler.Login)
+			auth.GET("/oidc/callback", oidcHandler.Callback)
+			auth.POST("/oidc/exchange", authLimit, oidcHandler.Exchange)
+			auth.POST("/passkey/login/begin", authLimit, passkeyHandler.BeginLogin)
+			auth.POST("/passkey/login/finish", authLimit, passkeyHandler.FinishLogin)
 		}
 
 		// 公开数据
@@ -43,20 +92,75 @@ func SetupRouter(mode string) *gin.Engine {
 		v1.GET("/visit/stats", visitHandler.GetStats)
 		v1.GET("/visit/heatmap", visitHandler.GetHeatmap)
 
+		// 实时事件推送 (SSE，登录可选)
+		v1.GET("/events", middleware.OptionalJWTAuth(), eventHandler.Stream)
+		v1.POST("/events/ticket", middleware.JWTAuth(), eventHandler.IssueTicket)
+
+		// 需要认证、同时支持个人访问令牌的接口
+		v1.GET("/user/profile", middleware.JWTAuth(service.ScopeStatsRead), userHandler.GetProfile)
+		v1.POST("/checkin", middleware.JWTAuth(service.ScopeCheckinWrite), checkinHandler.Checkin)
+		v1.GET("/checkin/history", middleware.JWTAuth(service.ScopeStatsRead), checkinHandler.GetHistory)
+		v1.GET("/checkin/heatmap", middleware.JWTAuth(service.ScopeStatsRead), checkinHandler.GetHeatmap)
+
 		// 需要认证的接口
 		protected := v1.Group("")
 		protected.Use(middleware.JWTAuth())
 		{
 			// 用户相关
-			protected.GET("/user/profile", userHandler.GetProfile)
 			protected.PUT("/user/profile", userHandler.UpdateProfile)
 			protected.PUT("/user/username", userHandler.UpdateUsername)
 			protected.PUT("/user/password", userHandler.UpdatePassword)
+			protected.POST("/user/password", userHandler.SetPassword)
+			protected.GET("/user/sessions", sessionHandler.ListSessions)
+			protected.DELETE("/user/sessions", sessionHandler.RevokeOtherSessions)
+			protected.DELETE("/user/sessions/:id", sessionHandler.RevokeSession)
+			protected.GET("/user/recovery-codes", resetHandler.GetRecoveryCodeStatus)
+			protected.POST("/user/recovery-codes", resetHandler.RegenerateRecoveryCodes)
+			protected.GET("/user/2fa", twoFactorHandler.GetStatus)
+			protected.POST("/user/2fa/setup", twoFactorHandler.Setup)
+			protected.POST("/user/2fa/enable", twoFactorHandler.Enable)
+			protected.POST("/user/2fa/disable", twoFactorHandler.Disable)
+			protected.POST("/user/2fa/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes)
+			protected.GET("/user/tokens", apiTokenHandler.ListTokens)
+			protected.POST("/user/tokens", apiTokenHandler.CreateToken)
+			protected.DELETE("/user/tokens/:id", apiTokenHandler.RevokeToken)
+			protected.GET("/user/passkeys", passkeyHandler.ListPasskeys)
+			protected.POST("/user/passkeys/register/begin", passkeyHandler.BeginRegistration)
+			protected.POST("/user/passkeys/register/finish", passkeyHandler.FinishRegistration)
+			protected.PUT("/user/passkeys/:id", passkeyHandler.RenamePasskey)
+			protected.DELETE("/user/passkeys/:id", passkeyHandler.DeletePasskey)
+			protected.GET("/user/identities", oidcHandler.ListIdentities)
+			protected.POST("/user/identities/link", oidcHandler.LinkIdentity)
+			protected.DELETE("/user/identities/:id", oidcHandler.UnlinkIdentity)
+
+			// 小组相关
+			protected.POST("/groups", groupHandler.CreateGroup)
+			protected.GET("/groups", groupHandler.GetMyGroups)
+			protected.POST("/groups/join", groupHandler.JoinGroup)
+			protected.GET("/groups/:id", groupHandler.GetGroup)
+			protected.DELETE("/groups/:id", groupHandler.DeleteGroup)
+			protected.POST("/groups/:id/leave", groupHandler.LeaveGroup)
+			protected.POST("/groups/:id/invite-code", groupHandler.RegenerateInviteCode)
+			protected.DELETE("/groups/:id/members/:user_id", groupHandler.RemoveMember)
+			protected.GET("/groups/:id/leaderboard", groupHandler.GetGroupLeaderboard)
+			protected.GET("/groups/:id/heatmap", groupHandler.GetGroupHeatmap)
 
-			// 打卡相关
-			protected.POST("/checkin", checkinHandler.Checkin)
-			protected.GET("/checkin/history", checkinHandler.GetHistory)
-			protected.GET("/checkin/heatmap", checkinHandler.GetHeatmap)
+			// 挑战相关
+			protected.GET("/challenges", challengeHandler.ListChallenges)
+			protected.POST("/challenges/:id/join", challengeHandler.JoinChallenge)
+			protected.POST("/challenges/:id/leave", challengeHandler.LeaveChallenge)
+			protected.GET("/challenges/:id/progress", challengeHandler.GetProgress)
+			protected.GET("/challenges/:id/ranking", challengeHandler.GetRanking)
+
+			// 鼓励相关
+			protected.POST("/cheers", cheerHandler.SendCheer)
+			protected.GET("/cheers/received", cheerHandler.GetReceived)
+
+			// 通知相关
+			protected.GET("/notifications", notificationHandler.GetNotifications)
+			protected.GET("/notifications/unread-count", notificationHandler.GetUnreadCount)
+			protected.PUT("/notifications/read-all", notificationHandler.MarkAllRead)
+			protected.PUT("/notifications/:id/read", notificationHandler.MarkRead)
 		}
 
 		// 管理员接口
@@ -65,9 +169,40 @@ func SetupRouter(mode string) *gin.Engine {
 		admin.Use(middleware.AdminAuth())
 		{
 			admin.GET("/users", userHandler.GetAllUsers)
+			admin.GET("/users/deleted", userHandler.GetDeletedUsers)
+			admin.POST("/users/:id/restore", userHandler.RestoreUser)
+			admin.DELETE("/users/:id/purge", userHandler.PurgeUser)
 			admin.DELETE("/users/:id", userHandler.DeleteUser)
 			admin.PUT("/users/:id/admin", userHandler.SetUserAdmin)
 			admin.PUT("/users/:id/stats", userHandler.UpdateUserStats)
+			admin.PUT("/users/:id/suspend", userHandler.SuspendUser)
+			admin.DELETE("/users/:id/suspend", userHandler.UnsuspendUser)
+			admin.POST("/users/:id/reset-code", resetHandler.IssueResetCode)
+			admin.GET("/users/:id/reset-codes", resetHandler.ListResetCodes)
+
+			// 显示名称审核
+			admin.GET("/name-reviews", nameReviewHandler.ListReviews)
+			admin.POST("/name-reviews/:id/approve", nameReviewHandler.ApproveReview)
+			admin.POST("/name-reviews/:id/reject", nameReviewHandler.RejectReview)
+
+			// 审计记录
+			admin.GET("/audit", auditHandler.ListAuditLogs)
+			admin.GET("/audit/export", auditHandler.ExportAuditLogs)
+
+			// 统计数据
+			admin.GET("/stats", statsHandler.GetStats)
+
+			// 登录锁定管理
+			admin.GET("/lockouts", lockoutHandler.ListLockouts)
+			admin.DELETE("/lockouts/:username", lockoutHandler.Unlock)
+
+			// 挑战管理
+			admin.POST("/challenges", challengeHandler.CreateChallenge)
+			admin.PUT("/challenges/:id", challengeHandler.UpdateChallenge)
+			admin.DELETE("/challenges/:id", challengeHandler.DeleteChallenge)
+
+			// 公告
+			admin.POST("/notifications/announce", notificationHandler.Announce)
 
 			// 备份相关
 			admin.POST("/backup", backupHandler.CreateBackup)
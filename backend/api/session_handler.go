@@ -0,0 +1,87 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"tidalcore-backend/internal/service"
+	"tidalcore-backend/pkg/response"
+)
+
+type SessionHandler struct {
+	sessionService *service.SessionService
+}
+
+func NewSessionHandler() *SessionHandler {
+	return &SessionHandler{
+		sessionService: service.NewSessionService(),
+	}
+}
+
+// ListSessions 获取登录设备列表
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	sessions, err := h.sessionService.ListSessions(userID, c.GetUint("session_id"))
+	if err != nil {
+		response.ServerError(c, "获取登录设备失败")
+		return
+	}
+
+	response.Success(c, sessions)
+}
+
+// RevokeSession 注销指定设备
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的会话ID")
+		return
+	}
+
+	if err := h.sessionService.RevokeSession(userID, uint(sessionID)); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			response.NotFound(c, "会话不存在")
+			return
+		}
+		response.ServerError(c, "注销设备失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "设备已退出登录", nil)
+}
+
+// RevokeOtherSessions 退出除当前设备外的所有设备
+func (h *SessionHandler) RevokeOtherSessions(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	currentSessionID := c.GetUint("session_id")
+	if currentSessionID == 0 {
+		response.BadRequest(c, "当前登录凭证不支持此操作，请重新登录")
+		return
+	}
+
+	count, err := h.sessionService.RevokeOtherSessions(userID, currentSessionID)
+	if err != nil {
+		response.ServerError(c, "操作失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "其他设备已退出登录", gin.H{"revoked": count})
+}
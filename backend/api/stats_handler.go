@@ -0,0 +1,35 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"tidalcore-backend/internal/service"
+	"tidalcore-backend/pkg/response"
+)
+
+type StatsHandler struct {
+	statsService *service.StatsService
+}
+
+func NewStatsHandler() *StatsHandler {
+	return &StatsHandler{
+		statsService: service.NewStatsService(),
+	}
+}
+
+// GetStats 获取管理后台统计数据（管理员），from、to 为 YYYY-MM-DD，默认最近 30 天
+func (h *StatsHandler) GetStats(c *gin.Context) {
+	stats, err := h.statsService.GetStats(c.Query("from"), c.Query("to"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidStatsRange) {
+			response.BadRequest(c, "日期格式应为 YYYY-MM-DD，开始日期不能晚于结束日期，且范围不超过 366 天")
+			return
+		}
+		response.ServerError(c, "获取统计数据失败")
+		return
+	}
+
+	response.Success(c, stats)
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"tidalcore-backend/internal/service"
+	"tidalcore-backend/pkg/response"
+)
+
+type TwoFactorHandler struct {
+	twoFactorService *service.TwoFactorService
+}
+
+func NewTwoFactorHandler() *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: service.GetTwoFactorService(),
+	}
+}
+
+// GetStatus 获取两步验证状态
+func (h *TwoFactorHandler) GetStatus(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	status, err := h.twoFactorService.GetStatus(userID)
+	if err != nil {
+		response.ServerError(c, "获取两步验证状态失败")
+		return
+	}
+
+	response.Success(c, status)
+}
+
+// Setup 生成两步验证密钥
+func (h *TwoFactorHandler) Setup(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	var req service.TwoFactorSetupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请输入密码")
+		return
+	}
+
+	setup, err := h.twoFactorService.Setup(userID, &req)
+	if err != nil {
+		handleTwoFactorError(c, err, "生成密钥失败")
+		return
+	}
+
+	response.Success(c, setup)
+}
+
+// Enable 提交验证码启用两步验证
+func (h *TwoFactorHandler) Enable(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	var req service.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请输入验证码")
+		return
+	}
+
+	codes, err := h.twoFactorService.Enable(userID, &req)
+	if err != nil {
+		handleTwoFactorError(c, err, "启用两步验证失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "两步验证已启用，请妥善保存恢复码", gin.H{"recovery_codes": codes})
+}
+
+// Disable 关闭两步验证
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	var req service.TwoFactorDisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请输入密码和验证码")
+		return
+	}
+
+	if err := h.twoFactorService.Disable(userID, &req); err != nil {
+		handleTwoFactorError(c, err, "关闭两步验证失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "两步验证已关闭", nil)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	var req service.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请输入验证码")
+		return
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(userID, &req)
+	if err != nil {
+		handleTwoFactorError(c, err, "生成恢复码失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "已生成新的恢复码，旧恢复码已失效", gin.H{"recovery_codes": codes})
+}
+
+func handleTwoFactorError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrOldPasswordWrong):
+		response.BadRequest(c, "密码错误")
+	case errors.Is(err, service.ErrInvalidTwoFactorCode):
+		response.BadRequest(c, "验证码错误")
+	case errors.Is(err, service.ErrTwoFactorAlreadyEnabled):
+		response.BadRequest(c, "两步验证已启用")
+	case errors.Is(err, service.ErrTwoFactorNotSetup):
+		response.BadRequest(c, "请先生成两步验证密钥")
+	case errors.Is(err, service.ErrTwoFactorNotEnabled):
+		response.BadRequest(c, "尚未启用两步验证")
+	case errors.Is(err, service.ErrTwoFactorRequired):
+		response.Forbidden(c, "管理员账号必须启用两步验证")
+	default:
+		response.ServerError(c, fallback)
+	}
+}
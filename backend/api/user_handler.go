@@ -2,10 +2,15 @@ package api
 
 import (
 	"errors"
+	"fmt"
+	"math"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"tidalcore-backend/config"
 	"tidalcore-backend/internal/service"
 	"tidalcore-backend/pkg/response"
 )
@@ -20,6 +25,74 @@ func NewUserHandler() *UserHandler {
 	}
 }
 
+// clientInfo 提取客户端 IP 和 User-Agent，用于记录登录会话
+func clientInfo(c *gin.Context) service.ClientInfo {
+	return service.ClientInfo{
+		IP:        c.ClientIP(),
+		UserAgent: c.GetHeader("User-Agent"),
+	}
+}
+
+// auditActor 当前登录的管理员或用户本人及其 IP，用于写入审计记录
+func auditActor(c *gin.Context) service.AuditActor {
+	return service.AuditActor{
+		ID: c.GetUint("user_id"),
+		IP: c.ClientIP(),
+	}
+}
+
+// respondPasswordPolicy 密码不符合策略时返回 400 及逐条原因，已处理时返回 true
+func respondPasswordPolicy(c *gin.Context, err error) bool {
+	var policyErr *service.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+
+	messages := make([]string, 0, len(policyErr.Reasons))
+	for _, r := range policyErr.Reasons {
+		messages = append(messages, r.Message)
+	}
+	response.BadRequestWithData(c, "密码不符合要求："+strings.Join(messages, "；"), gin.H{"reasons": policyErr.Reasons})
+	return true
+}
+
+// respondAccountLocked 账号因连续登录失败被锁定时返回 429 及解锁时间
+func respondAccountLocked(c *gin.Context, err error) bool {
+	var lockedErr *service.AccountLockedError
+	if !errors.As(err, &lockedErr) {
+		return false
+	}
+	retryAfter := int(math.Ceil(time.Until(lockedErr.Until).Seconds()))
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	response.TooManyRequests(c, fmt.Sprintf("登录失败次数过多，账号已锁定，请 %d 分钟后再试", (retryAfter+59)/60))
+	return true
+}
+
+// respondSuspended 账号被封禁时返回 403 及封禁到期时间和原因，已处理时返回 true
+func respondSuspended(c *gin.Context, err error) bool {
+	var suspendedErr *service.SuspendedError
+	if !errors.As(err, &suspendedErr) {
+		return false
+	}
+
+	msg := "账号已被永久封禁"
+	if suspendedErr.Until != nil {
+		loc := time.Local
+		if l, err := time.LoadLocation(config.Get().Server.Timezone); err == nil {
+			loc = l
+		}
+		msg = "账号已被封禁至 " + suspendedErr.Until.In(loc).Format("2006-01-02 15:04")
+	}
+	if suspendedErr.Reason != "" {
+		msg += "，原因：" + suspendedErr.Reason
+	}
+	response.ForbiddenWithData(c, msg, gin.H{
+		"suspended_until": suspendedErr.Until,
+		"reason":          suspendedErr.Reason,
+	})
+	return true
+}
+
 func (h *UserHandler) Register(c *gin.Context) {
 	var req service.RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -27,7 +100,7 @@ func (h *UserHandler) Register(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.userService.Register(&req)
+	resp, err := h.userService.Register(&req, clientInfo(c))
 	if err != nil {
 		if errors.Is(err, service.ErrUserExists) {
 			response.BadRequest(c, "用户名已存在")
@@ -37,6 +110,13 @@ func (h *UserHandler) Register(c *gin.Context) {
 			response.BadRequest(c, "用户名只能包含字母、数字和下划线")
 			return
 		}
+		if errors.Is(err, service.ErrUsernameNotAllowed) {
+			response.BadRequest(c, "该用户名为保留用户名")
+			return
+		}
+		if respondPasswordPolicy(c, err) {
+			return
+		}
 		response.ServerError(c, "注册失败")
 		return
 	}
@@ -51,12 +131,23 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.userService.Login(&req)
+	resp, err := h.userService.Login(&req, clientInfo(c))
 	if err != nil {
 		if errors.Is(err, service.ErrUserNotFound) || errors.Is(err, service.ErrInvalidPassword) {
 			response.Unauthorized(c, "用户名或密码错误")
 			return
 		}
+		if respondAccountLocked(c, err) {
+			return
+		}
+		if errors.Is(err, service.ErrTooManyAttempts) {
+			c.Header("Retry-After", "60")
+			response.TooManyRequests(c, "登录尝试过于频繁，请稍后再试")
+			return
+		}
+		if respondSuspended(c, err) {
+			return
+		}
 		response.ServerError(c, "登录失败")
 		return
 	}
@@ -64,6 +155,62 @@ func (h *UserHandler) Login(c *gin.Context) {
 	response.SuccessWithMsg(c, "登录成功", resp)
 }
 
+// LoginTwoFactor 提交两步验证码完成登录
+func (h *UserHandler) LoginTwoFactor(c *gin.Context) {
+	var req service.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数无效")
+		return
+	}
+
+	resp, err := h.userService.LoginTwoFactor(&req, clientInfo(c))
+	if err != nil {
+		if respondSuspended(c, err) || respondAccountLocked(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, service.ErrInvalidTwoFactorToken):
+			response.Unauthorized(c, "验证已过期，请重新登录")
+		case errors.Is(err, service.ErrInvalidTwoFactorCode):
+			response.Unauthorized(c, "验证码错误")
+		default:
+			response.ServerError(c, "登录失败")
+		}
+		return
+	}
+
+	response.SuccessWithMsg(c, "登录成功", resp)
+}
+
+// RefreshToken 使用刷新令牌换取新的访问令牌
+func (h *UserHandler) RefreshToken(c *gin.Context) {
+	var req service.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数无效")
+		return
+	}
+
+	resp, err := h.userService.RefreshToken(&req, clientInfo(c))
+	if err != nil {
+		if respondSuspended(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, service.ErrRefreshTokenReused):
+			response.Unauthorized(c, "登录凭证已失效，请重新登录")
+		case errors.Is(err, service.ErrRefreshTokenExpired):
+			response.Unauthorized(c, "登录已过期，请重新登录")
+		case errors.Is(err, service.ErrInvalidRefreshToken):
+			response.Unauthorized(c, "无效的刷新令牌")
+		default:
+			response.ServerError(c, "刷新令牌失败")
+		}
+		return
+	}
+
+	response.Success(c, resp)
+}
+
 func (h *UserHandler) GetProfile(c *gin.Context) {
 	userID := c.GetUint("user_id")
 	if userID == 0 {
@@ -113,7 +260,7 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.UpdateProfile(userID, &req)
+	resp, err := h.userService.UpdateProfile(userID, &req)
 	if err != nil {
 		if errors.Is(err, service.ErrUserNotFound) {
 			response.NotFound(c, "用户不存在")
@@ -123,7 +270,11 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	response.SuccessWithMsg(c, "更新成功", user)
+	if resp.DisplayNamePending {
+		response.SuccessWithMsg(c, "新的显示名称已提交审核，通过后生效", resp)
+		return
+	}
+	response.SuccessWithMsg(c, "更新成功", resp)
 }
 
 // UpdateUsername 更新用户名
@@ -154,6 +305,10 @@ func (h *UserHandler) UpdateUsername(c *gin.Context) {
 			response.BadRequest(c, "用户名只能包含字母、数字和下划线")
 			return
 		}
+		if errors.Is(err, service.ErrUsernameNotAllowed) {
+			response.BadRequest(c, "该用户名为保留用户名")
+			return
+		}
 		response.ServerError(c, "更新失败")
 		return
 	}
@@ -175,7 +330,7 @@ func (h *UserHandler) UpdatePassword(c *gin.Context) {
 		return
 	}
 
-	err := h.userService.UpdatePassword(userID, &req)
+	resp, err := h.userService.UpdatePassword(userID, &req, clientInfo(c))
 	if err != nil {
 		if errors.Is(err, service.ErrUserNotFound) {
 			response.NotFound(c, "用户不存在")
@@ -185,11 +340,71 @@ func (h *UserHandler) UpdatePassword(c *gin.Context) {
 			response.BadRequest(c, "原密码错误")
 			return
 		}
+		if errors.Is(err, service.ErrPasswordNotSet) {
+			response.BadRequest(c, "账号尚未设置密码，请先设置密码")
+			return
+		}
+		if respondPasswordPolicy(c, err) {
+			return
+		}
 		response.ServerError(c, "更新失败")
 		return
 	}
 
-	response.SuccessWithMsg(c, "密码更新成功", nil)
+	response.SuccessWithMsg(c, "密码更新成功", resp)
+}
+
+// SetPassword 为单点登录创建的账号设置初始密码
+func (h *UserHandler) SetPassword(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	var req service.SetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数无效")
+		return
+	}
+
+	if err := h.userService.SetPassword(userID, &req); err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			response.NotFound(c, "用户不存在")
+			return
+		}
+		if errors.Is(err, service.ErrPasswordSet) {
+			response.BadRequest(c, "账号已设置密码，请使用修改密码")
+			return
+		}
+		if respondPasswordPolicy(c, err) {
+			return
+		}
+		response.ServerError(c, "设置失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "密码设置成功", nil)
+}
+
+// Logout 退出登录
+func (h *UserHandler) Logout(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		response.Unauthorized(c, "无效的用户")
+		return
+	}
+
+	// 请求体可选，携带刷新令牌时一并吊销
+	var req service.LogoutRequest
+	_ = c.ShouldBindJSON(&req)
+
+	if err := h.userService.Logout(userID, c.GetString("jti"), c.GetUint("session_id"), c.GetTime("token_expires_at"), &req); err != nil {
+		response.ServerError(c, "退出登录失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "已退出登录", nil)
 }
 
 // ========== 管理员接口 ==========
@@ -232,7 +447,11 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	if err := h.userService.DeleteUser(uint(userID)); err != nil {
+	if err := h.userService.DeleteUser(auditActor(c), uint(userID)); err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			response.NotFound(c, "用户不存在")
+			return
+		}
 		response.ServerError(c, "删除用户失败")
 		return
 	}
@@ -240,6 +459,69 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 	response.SuccessWithMsg(c, "用户已删除", nil)
 }
 
+// GetDeletedUsers 获取已删除的用户列表（管理员）
+func (h *UserHandler) GetDeletedUsers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	users, total, err := h.userService.GetDeletedUsers(page, pageSize)
+	if err != nil {
+		response.ServerError(c, "获取已删除用户失败")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"users":     users,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// RestoreUser 恢复已删除的用户（管理员）
+func (h *UserHandler) RestoreUser(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的用户ID")
+		return
+	}
+
+	user, err := h.userService.RestoreUser(auditActor(c), uint(userID))
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			response.NotFound(c, "已删除的用户不存在")
+			return
+		}
+		response.ServerError(c, "恢复用户失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "用户已恢复", user)
+}
+
+// PurgeUser 永久删除用户及其全部数据（管理员）
+func (h *UserHandler) PurgeUser(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的用户ID")
+		return
+	}
+
+	if err := h.userService.PurgeUser(auditActor(c), uint(userID)); err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotDeleted):
+			response.BadRequest(c, "请先删除该用户，再永久清除")
+		case errors.Is(err, service.ErrUserNotFound):
+			response.NotFound(c, "用户不存在")
+		default:
+			response.ServerError(c, "永久删除失败")
+		}
+		return
+	}
+
+	response.SuccessWithMsg(c, "用户数据已永久删除", nil)
+}
+
 // SetUserAdmin 设置用户管理员状态（管理员）
 func (h *UserHandler) SetUserAdmin(c *gin.Context) {
 	userIDStr := c.Param("id")
@@ -264,7 +546,7 @@ func (h *UserHandler) SetUserAdmin(c *gin.Context) {
 		return
 	}
 
-	if err := h.userService.SetUserAdmin(uint(userID), req.IsAdmin); err != nil {
+	if err := h.userService.SetUserAdmin(auditActor(c), uint(userID), req.IsAdmin); err != nil {
 		if errors.Is(err, service.ErrUserNotFound) {
 			response.NotFound(c, "用户不存在")
 			return
@@ -276,6 +558,63 @@ func (h *UserHandler) SetUserAdmin(c *gin.Context) {
 	response.SuccessWithMsg(c, "设置成功", nil)
 }
 
+// SuspendUser 封禁用户（管理员）
+func (h *UserHandler) SuspendUser(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的用户ID")
+		return
+	}
+
+	var req service.SuspendUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数无效")
+		return
+	}
+
+	currentUserID := c.GetUint("user_id")
+	if uint(userID) == currentUserID {
+		response.BadRequest(c, "不能封禁自己的账号")
+		return
+	}
+
+	user, err := h.userService.SuspendUser(auditActor(c), uint(userID), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			response.NotFound(c, "用户不存在")
+		case errors.Is(err, service.ErrCannotSuspend):
+			response.BadRequest(c, "不能封禁管理员账号，请先取消其管理员权限")
+		default:
+			response.ServerError(c, "封禁失败")
+		}
+		return
+	}
+
+	response.SuccessWithMsg(c, "用户已封禁", user)
+}
+
+// UnsuspendUser 解除封禁（管理员）
+func (h *UserHandler) UnsuspendUser(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的用户ID")
+		return
+	}
+
+	user, err := h.userService.UnsuspendUser(auditActor(c), uint(userID))
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			response.NotFound(c, "用户不存在")
+			return
+		}
+		response.ServerError(c, "解除封禁失败")
+		return
+	}
+
+	response.SuccessWithMsg(c, "已解除封禁", user)
+}
+
 // UpdateUserStats 更新用户统计数据（管理员）
 func (h *UserHandler) UpdateUserStats(c *gin.Context) {
 	userIDStr := c.Param("id")
@@ -291,7 +630,7 @@ func (h *UserHandler) UpdateUserStats(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.UpdateUserStats(uint(userID), &req)
+	user, err := h.userService.UpdateUserStats(auditActor(c), uint(userID), &req)
 	if err != nil {
 		if errors.Is(err, service.ErrUserNotFound) {
 			response.NotFound(c, "用户不存在")
@@ -1,13 +1,18 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"tidalcore-backend/api"
 	"tidalcore-backend/config"
+	"tidalcore-backend/internal/auth"
 	"tidalcore-backend/internal/model"
+	"tidalcore-backend/internal/moderation"
 	"tidalcore-backend/internal/service"
 	"tidalcore-backend/pkg/database"
 )
@@ -21,6 +26,24 @@ func main() {
 	cfg := config.Get()
 
 	log.Printf("Starting TidalCore Backend...")
+
+	// 加载访问令牌签名密钥，release 模式下拒绝使用默认密钥
+	if err := auth.InitKeys(); err != nil {
+		log.Fatalf("Failed to load JWT keys: %v", err)
+	}
+	log.Printf("Access tokens signed with %s", cfg.JWT.Algorithm)
+
+	if err := auth.InitPasswordPolicy(); err != nil {
+		log.Fatalf("Failed to load password policy: %v", err)
+	}
+
+	if err := moderation.InitNameLists(); err != nil {
+		log.Fatalf("Failed to load name moderation lists: %v", err)
+	}
+
+	if cfg.OIDC.Enabled && (cfg.OIDC.Issuer == "" || cfg.OIDC.ClientID == "" || cfg.OIDC.RedirectURL == "" || cfg.OIDC.FrontendURL == "") {
+		log.Fatalf("OIDC is enabled but issuer, client_id, redirect_url and frontend_url must all be configured")
+	}
 	log.Printf("Database: %s@%s:%s/%s", cfg.Database.User, cfg.Database.Host, cfg.Database.Port, cfg.Database.DBName)
 
 	// 初始化数据库
@@ -35,18 +58,55 @@ func main() {
 	}
 	log.Printf("Database migration completed")
 
+	if err := service.InitPasskeyService(); err != nil {
+		log.Fatalf("Failed to init passkeys: %v", err)
+	}
+
 	// 初始化管理员账号
 	if cfg.Admin.Username != "" && cfg.Admin.Password != "" {
 		userService := service.NewUserService()
 		if err := userService.InitAdmin(cfg.Admin.Username, cfg.Admin.Password); err != nil {
+			// 密码不合规时拒绝启动，避免以为管理员已创建或已改用新密码
+			var policyErr *service.PasswordPolicyError
+			if errors.As(err, &policyErr) {
+				log.Fatalf("Admin password (admin.password / ADMIN_PASSWORD) does not meet the password policy: %s",
+					adminPolicyReasons(policyErr))
+			}
 			log.Printf("Warning: Failed to init admin account: %v", err)
 		} else {
 			log.Printf("Admin account initialized: %s", cfg.Admin.Username)
 		}
 	}
 
+	// 启动连续打卡中断提醒
+	if cfg.Notification.StreakReminderHour > 0 {
+		service.NewNotificationService().StartStreakRiskReminder(cfg.Notification.StreakReminderHour)
+		log.Printf("Streak risk reminder scheduled at %02d:00", cfg.Notification.StreakReminderHour)
+	}
+
+	// 加载令牌吊销列表
+	revocation := service.GetRevocationService()
+	if err := revocation.Load(); err != nil {
+		log.Fatalf("Failed to load token revocations: %v", err)
+	}
+	revocation.StartCleanup(time.Hour)
+
+	// 定期清理过期的刷新令牌和会话
+	service.NewTokenService().StartCleanup(time.Hour)
+
+	// 定期永久清除超过保留期的已删除账号和过期的登录失败记录
+	days := cfg.Account.DeletedRetentionDays
+	service.NewUserService().StartPurge(days, time.Hour)
+	if days > 0 {
+		log.Printf("Deleted accounts are purged after %d days", days)
+	}
+
 	// 启动服务器
 	r := api.SetupRouter(cfg.Server.Mode)
+	// 限流、审计和登录锁定使用的客户端 IP 只从可信代理转发的请求头中读取
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("Invalid server.trusted_proxies: %v", err)
+	}
 
 	addr := fmt.Sprintf(":%s", cfg.Server.Port)
 	log.Printf("Server starting on %s", addr)
@@ -56,11 +116,47 @@ func main() {
 	}
 }
 
+// adminPolicyReasons 列出管理员密码不符合策略的原因
+func adminPolicyReasons(err *service.PasswordPolicyError) string {
+	reasons := make([]string, 0, len(err.Reasons))
+	for _, r := range err.Reasons {
+		reasons = append(reasons, fmt.Sprintf("%s (%s)", r.Message, r.Code))
+	}
+	return strings.Join(reasons, "; ")
+}
+
 func autoMigrate() error {
 	db := database.Get()
-	return db.AutoMigrate(
+	err := db.AutoMigrate(
 		&model.User{},
 		&model.Checkin{},
 		&model.Visit{},
+		&model.Group{},
+		&model.GroupMember{},
+		&model.Challenge{},
+		&model.ChallengeParticipant{},
+		&model.Cheer{},
+		&model.Notification{},
+		&model.RefreshToken{},
+		&model.RevokedToken{},
+		&model.Session{},
+		&model.LoginFailure{},
+		&model.TwoFactorRecoveryCode{},
+		&model.APIToken{},
+		&model.UserIdentity{},
+		&model.PasswordReset{},
+		&model.PasswordRecoveryCode{},
+		&model.PasskeyCredential{},
+		&model.NameReview{},
+		&model.AuditLog{},
 	)
+	if err != nil {
+		return err
+	}
+
+	// 早期版本在 user_identities 中保存了身份提供方返回的邮箱，本站不收集邮箱，删除该列
+	if db.Migrator().HasColumn(&model.UserIdentity{}, "email") {
+		return db.Migrator().DropColumn(&model.UserIdentity{}, "email")
+	}
+	return nil
 }
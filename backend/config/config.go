@@ -1,6 +1,7 @@
 package config
 
 import (
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -10,21 +11,87 @@ import (
 )
 
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	JWT      JWTConfig      `mapstructure:"jwt"`
-	Admin    AdminConfig    `mapstructure:"admin"`
+	Server       ServerConfig       `mapstructure:"server"`
+	Database     DatabaseConfig     `mapstructure:"database"`
+	JWT          JWTConfig          `mapstructure:"jwt"`
+	Admin        AdminConfig        `mapstructure:"admin"`
+	Notification NotificationConfig `mapstructure:"notification"`
+	Security     SecurityConfig     `mapstructure:"security"`
+	OIDC         OIDCConfig         `mapstructure:"oidc"`
+	Password     PasswordConfig     `mapstructure:"password"`
+	WebAuthn     WebAuthnConfig     `mapstructure:"webauthn"`
+	Account      AccountConfig      `mapstructure:"account"`
+}
+
+// AccountConfig 账号生命周期及用户名、显示名称审核配置
+type AccountConfig struct {
+	DeletedRetentionDays int      `mapstructure:"deleted_retention_days"` // 已删除账号保留天数，到期后永久清除，-1 表示不自动清除
+	ReservedUsernames    []string `mapstructure:"reserved_usernames"`     // 额外的保留用户名，与内置列表合并
+	NameBlocklistFile    string   `mapstructure:"name_blocklist_file"`    // 额外的屏蔽词列表，每行一个，与内置列表合并
+}
+
+// WebAuthnConfig 通行密钥 (WebAuthn) 依赖方配置
+type WebAuthnConfig struct {
+	RPID          string   `mapstructure:"rp_id"`           // 依赖方 ID，即前端站点域名（不含协议和端口）
+	RPDisplayName string   `mapstructure:"rp_display_name"` // 系统弹窗中显示的站点名称
+	RPOrigins     []string `mapstructure:"rp_origins"`      // 允许发起通行密钥认证的前端地址，默认同 allowed_origins
+}
+
+// PasswordConfig 密码策略，注册、修改密码、重置密码和初始化管理员时校验
+type PasswordConfig struct {
+	MinLength          int    `mapstructure:"min_length"`
+	MaxLength          int    `mapstructure:"max_length"`
+	MinCharClasses     int    `mapstructure:"min_char_classes"`     // 至少包含几类字符（小写字母、大写字母、数字、符号），1 表示不限制
+	AllowUsername      bool   `mapstructure:"allow_username"`       // 允许密码中包含用户名
+	DisableCommonCheck bool   `mapstructure:"disable_common_check"` // 关闭常见/已泄露密码检查
+	CommonListFile     string `mapstructure:"common_list_file"`     // 额外的已泄露密码列表，每行一个，与内置列表合并
+
+	// 密码哈希算法及参数，调整后旧哈希会在用户下次登录时自动升级
+	Hasher            string `mapstructure:"hasher"`             // argon2id 或 bcrypt
+	BcryptCost        int    `mapstructure:"bcrypt_cost"`        // bcrypt 计算成本 (4-31)
+	Argon2Memory      uint32 `mapstructure:"argon2_memory"`      // argon2id 内存开销 (KiB)
+	Argon2Iterations  uint32 `mapstructure:"argon2_iterations"`  // argon2id 迭代次数
+	Argon2Parallelism uint8  `mapstructure:"argon2_parallelism"` // argon2id 并行度
+}
+
+// OIDCConfig OpenID Connect 单点登录配置（授权码 + PKCE）
+type OIDCConfig struct {
+	Enabled       bool     `mapstructure:"enabled"`
+	ProviderName  string   `mapstructure:"provider_name"` // 登录按钮上显示的身份提供方名称
+	Issuer        string   `mapstructure:"issuer"`        // 通过 {issuer}/.well-known/openid-configuration 自动发现端点
+	ClientID      string   `mapstructure:"client_id"`
+	ClientSecret  string   `mapstructure:"client_secret"` // 公共客户端可留空，仅依赖 PKCE
+	RedirectURL   string   `mapstructure:"redirect_url"`  // 后端回调地址，需在身份提供方登记
+	FrontendURL   string   `mapstructure:"frontend_url"`  // 登录完成后跳转的前端地址
+	Scopes        []string `mapstructure:"scopes"`
+	DisableSignup bool     `mapstructure:"disable_signup"` // 禁止自动创建账号，仅允许已绑定的用户登录
+}
+
+type SecurityConfig struct {
+	AuthRatePerMinute  int `mapstructure:"auth_rate_per_minute"`  // 每个 IP 每分钟可调用登录/注册接口的次数
+	LoginRatePerMinute int `mapstructure:"login_rate_per_minute"` // 每个用户名每分钟可尝试登录的次数
+	MaxFailedLogins    int `mapstructure:"max_failed_logins"`     // 连续失败多少次后锁定账号
+	LockoutBaseMinutes int `mapstructure:"lockout_base_minutes"`  // 首次锁定时长，之后每次锁定翻倍
+	LockoutMaxMinutes  int `mapstructure:"lockout_max_minutes"`   // 锁定时长上限
+	ResetCodeMinutes   int `mapstructure:"reset_code_minutes"`    // 管理员签发的密码重置码有效期
+	MaxResetAttempts   int `mapstructure:"max_reset_attempts"`    // 重置码输错多少次后作废
+}
+
+type NotificationConfig struct {
+	StreakReminderHour int `mapstructure:"streak_reminder_hour"` // 每天提醒连续打卡即将中断的整点 (1-23)，-1 表示关闭
 }
 
 type AdminConfig struct {
-	Username string `mapstructure:"username"`
-	Password string `mapstructure:"password"`
+	Username   string `mapstructure:"username"`
+	Password   string `mapstructure:"password"`
+	Require2FA bool   `mapstructure:"require_2fa"` // 管理员账号必须启用两步验证才能访问管理接口
 }
 
 type ServerConfig struct {
 	Port           string   `mapstructure:"port"`
 	Mode           string   `mapstructure:"mode"`
 	AllowedOrigins []string `mapstructure:"allowed_origins"`
+	TrustedProxies []string `mapstructure:"trusted_proxies"` // 可信反向代理的 IP 或网段，为空时不信任 X-Forwarded-For，客户端 IP 取连接地址
 	Timezone       string   `mapstructure:"timezone"`
 }
 
@@ -40,8 +107,32 @@ type DatabaseConfig struct {
 }
 
 type JWTConfig struct {
-	Secret     string `mapstructure:"secret"`
-	ExpireHour int    `mapstructure:"expire_hour"`
+	Secret               string   `mapstructure:"secret"`                 // HS256 签名密钥
+	ExpireHour           int      `mapstructure:"expire_hour"`            // 刷新令牌（登录会话）有效期
+	AccessExpireMinute   int      `mapstructure:"access_expire_minute"`   // 访问令牌有效期
+	Algorithm            string   `mapstructure:"algorithm"`              // HS256、RS256 或 EdDSA
+	SigningKeyFile       string   `mapstructure:"signing_key_file"`       // 非对称签名私钥（PEM）
+	VerificationKeyFiles []string `mapstructure:"verification_key_files"` // 轮换期间仍需验证的旧公钥（PEM）
+}
+
+// DefaultJWTSecret 未配置 JWT 密钥时使用的默认值，release 模式下拒绝启动
+const DefaultJWTSecret = "default-secret-please-change"
+
+// placeholderJWTSecrets 示例配置中的占位密钥，与默认值同等对待
+var placeholderJWTSecrets = []string{
+	DefaultJWTSecret,
+	"your-jwt-secret-key-change-in-production-at-least-32-chars",
+	"your-jwt-secret-change-this",
+}
+
+// UsesDefaultSecret 判断是否仍在使用默认或示例中的 JWT 密钥
+func (c JWTConfig) UsesDefaultSecret() bool {
+	for _, s := range placeholderJWTSecrets {
+		if c.Secret == s {
+			return true
+		}
+	}
+	return false
 }
 
 var (
@@ -99,6 +190,20 @@ func loadFromEnv() {
 			appConfig.JWT.ExpireHour = i
 		}
 	}
+	if v := os.Getenv("JWT_ALGORITHM"); v != "" {
+		appConfig.JWT.Algorithm = v
+	}
+	if v := os.Getenv("JWT_SIGNING_KEY_FILE"); v != "" {
+		appConfig.JWT.SigningKeyFile = v
+	}
+	if v := os.Getenv("JWT_VERIFICATION_KEY_FILES"); v != "" {
+		appConfig.JWT.VerificationKeyFiles = strings.Split(v, ",")
+	}
+	if v := os.Getenv("JWT_ACCESS_EXPIRE_MINUTE"); v != "" {
+		if i, err := strconv.Atoi(v); err == nil {
+			appConfig.JWT.AccessExpireMinute = i
+		}
+	}
 
 	// 服务器配置
 	if v := os.Getenv("SERVER_PORT"); v != "" {
@@ -110,10 +215,103 @@ func loadFromEnv() {
 	if v := os.Getenv("ALLOWED_ORIGINS"); v != "" {
 		appConfig.Server.AllowedOrigins = strings.Split(v, ",")
 	}
+	if v := os.Getenv("TRUSTED_PROXIES"); v != "" {
+		appConfig.Server.TrustedProxies = strings.Split(v, ",")
+	}
 	if v := os.Getenv("TIMEZONE"); v != "" {
 		appConfig.Server.Timezone = v
 	}
 
+	// 通知配置
+	if v := os.Getenv("STREAK_REMINDER_HOUR"); v != "" {
+		if i, err := strconv.Atoi(v); err == nil {
+			appConfig.Notification.StreakReminderHour = i
+		}
+	}
+
+	// 安全配置
+	if v := os.Getenv("AUTH_RATE_PER_MINUTE"); v != "" {
+		if i, err := strconv.Atoi(v); err == nil {
+			appConfig.Security.AuthRatePerMinute = i
+		}
+	}
+	if v := os.Getenv("LOGIN_RATE_PER_MINUTE"); v != "" {
+		if i, err := strconv.Atoi(v); err == nil {
+			appConfig.Security.LoginRatePerMinute = i
+		}
+	}
+	if v := os.Getenv("MAX_FAILED_LOGINS"); v != "" {
+		if i, err := strconv.Atoi(v); err == nil {
+			appConfig.Security.MaxFailedLogins = i
+		}
+	}
+
+	// OIDC 配置
+	if v := os.Getenv("OIDC_ENABLED"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			appConfig.OIDC.Enabled = b
+		}
+	}
+	if v := os.Getenv("OIDC_ISSUER"); v != "" {
+		appConfig.OIDC.Issuer = v
+	}
+	if v := os.Getenv("OIDC_CLIENT_ID"); v != "" {
+		appConfig.OIDC.ClientID = v
+	}
+	if v := os.Getenv("OIDC_CLIENT_SECRET"); v != "" {
+		appConfig.OIDC.ClientSecret = v
+	}
+	if v := os.Getenv("OIDC_REDIRECT_URL"); v != "" {
+		appConfig.OIDC.RedirectURL = v
+	}
+	if v := os.Getenv("OIDC_FRONTEND_URL"); v != "" {
+		appConfig.OIDC.FrontendURL = v
+	}
+
+	// 密码策略
+	if v := os.Getenv("PASSWORD_MIN_LENGTH"); v != "" {
+		if i, err := strconv.Atoi(v); err == nil {
+			appConfig.Password.MinLength = i
+		}
+	}
+	if v := os.Getenv("PASSWORD_MIN_CHAR_CLASSES"); v != "" {
+		if i, err := strconv.Atoi(v); err == nil {
+			appConfig.Password.MinCharClasses = i
+		}
+	}
+	if v := os.Getenv("PASSWORD_COMMON_LIST_FILE"); v != "" {
+		appConfig.Password.CommonListFile = v
+	}
+	if v := os.Getenv("PASSWORD_HASHER"); v != "" {
+		appConfig.Password.Hasher = v
+	}
+	if v := os.Getenv("PASSWORD_BCRYPT_COST"); v != "" {
+		if i, err := strconv.Atoi(v); err == nil {
+			appConfig.Password.BcryptCost = i
+		}
+	}
+
+	// 通行密钥配置
+	if v := os.Getenv("WEBAUTHN_RP_ID"); v != "" {
+		appConfig.WebAuthn.RPID = v
+	}
+	if v := os.Getenv("WEBAUTHN_RP_ORIGINS"); v != "" {
+		appConfig.WebAuthn.RPOrigins = strings.Split(v, ",")
+	}
+
+	// 账号配置
+	if v := os.Getenv("ACCOUNT_DELETED_RETENTION_DAYS"); v != "" {
+		if i, err := strconv.Atoi(v); err == nil {
+			appConfig.Account.DeletedRetentionDays = i
+		}
+	}
+	if v := os.Getenv("ACCOUNT_RESERVED_USERNAMES"); v != "" {
+		appConfig.Account.ReservedUsernames = strings.Split(v, ",")
+	}
+	if v := os.Getenv("ACCOUNT_NAME_BLOCKLIST_FILE"); v != "" {
+		appConfig.Account.NameBlocklistFile = v
+	}
+
 	// 管理员配置
 	if v := os.Getenv("ADMIN_USERNAME"); v != "" {
 		appConfig.Admin.Username = v
@@ -121,6 +319,11 @@ func loadFromEnv() {
 	if v := os.Getenv("ADMIN_PASSWORD"); v != "" {
 		appConfig.Admin.Password = v
 	}
+	if v := os.Getenv("ADMIN_REQUIRE_2FA"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			appConfig.Admin.Require2FA = b
+		}
+	}
 }
 
 func setDefaults() {
@@ -154,8 +357,86 @@ func setDefaults() {
 	if appConfig.JWT.ExpireHour == 0 {
 		appConfig.JWT.ExpireHour = 168
 	}
+	if appConfig.JWT.AccessExpireMinute == 0 {
+		appConfig.JWT.AccessExpireMinute = 15
+	}
+	if appConfig.Notification.StreakReminderHour == 0 || appConfig.Notification.StreakReminderHour > 23 || appConfig.Notification.StreakReminderHour < -1 {
+		appConfig.Notification.StreakReminderHour = 20
+	}
+	if appConfig.Security.AuthRatePerMinute == 0 {
+		appConfig.Security.AuthRatePerMinute = 20
+	}
+	if appConfig.Security.LoginRatePerMinute == 0 {
+		appConfig.Security.LoginRatePerMinute = 5
+	}
+	if appConfig.Security.MaxFailedLogins == 0 {
+		appConfig.Security.MaxFailedLogins = 5
+	}
+	if appConfig.Security.LockoutBaseMinutes == 0 {
+		appConfig.Security.LockoutBaseMinutes = 1
+	}
+	if appConfig.Security.LockoutMaxMinutes == 0 {
+		appConfig.Security.LockoutMaxMinutes = 1440
+	}
+	if appConfig.Security.ResetCodeMinutes == 0 {
+		appConfig.Security.ResetCodeMinutes = 60
+	}
+	if appConfig.Security.MaxResetAttempts == 0 {
+		appConfig.Security.MaxResetAttempts = 5
+	}
+	if appConfig.Password.MinLength <= 0 {
+		appConfig.Password.MinLength = 8
+	}
+	if appConfig.Password.MaxLength == 0 {
+		appConfig.Password.MaxLength = 64
+	}
+	if appConfig.Password.MaxLength < appConfig.Password.MinLength {
+		appConfig.Password.MaxLength = appConfig.Password.MinLength
+	}
+	if appConfig.Password.MinCharClasses <= 0 || appConfig.Password.MinCharClasses > 4 {
+		appConfig.Password.MinCharClasses = 2
+	}
+	if appConfig.Password.Hasher == "" {
+		appConfig.Password.Hasher = "argon2id"
+	}
+	if appConfig.Password.BcryptCost < 4 || appConfig.Password.BcryptCost > 31 {
+		appConfig.Password.BcryptCost = 10
+	}
+	if appConfig.Password.Argon2Memory == 0 {
+		appConfig.Password.Argon2Memory = 19 * 1024
+	}
+	if appConfig.Password.Argon2Iterations == 0 {
+		appConfig.Password.Argon2Iterations = 2
+	}
+	if appConfig.Password.Argon2Parallelism == 0 {
+		appConfig.Password.Argon2Parallelism = 1
+	}
+	if appConfig.OIDC.ProviderName == "" {
+		appConfig.OIDC.ProviderName = "SSO"
+	}
+	if len(appConfig.OIDC.Scopes) == 0 {
+		appConfig.OIDC.Scopes = []string{"openid", "profile"}
+	}
+	if appConfig.WebAuthn.RPDisplayName == "" {
+		appConfig.WebAuthn.RPDisplayName = "TidalCore"
+	}
+	if len(appConfig.WebAuthn.RPOrigins) == 0 {
+		appConfig.WebAuthn.RPOrigins = appConfig.Server.AllowedOrigins
+	}
+	if appConfig.WebAuthn.RPID == "" {
+		// 未配置时取第一个前端地址的域名
+		if u, err := url.Parse(appConfig.WebAuthn.RPOrigins[0]); err == nil {
+			appConfig.WebAuthn.RPID = u.Hostname()
+		}
+	}
+	if appConfig.Account.DeletedRetentionDays == 0 || appConfig.Account.DeletedRetentionDays < -1 {
+		appConfig.Account.DeletedRetentionDays = 30
+	}
+	if appConfig.JWT.Algorithm == "" {
+		appConfig.JWT.Algorithm = "HS256"
+	}
 	if appConfig.JWT.Secret == "" {
-		appConfig.JWT.Secret = "default-secret-please-change"
+		appConfig.JWT.Secret = DefaultJWTSecret
 	}
 }
 
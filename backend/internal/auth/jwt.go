@@ -12,12 +12,30 @@ import (
 var (
 	ErrInvalidToken = errors.New("invalid token")
 	ErrExpiredToken = errors.New("token has expired")
+	ErrRevokedToken = errors.New("token has been revoked")
 )
 
+// RevocationChecker 判断令牌是否已被吊销
+type RevocationChecker func(claims *Claims) bool
+
+var revocationChecker RevocationChecker
+
+func init() {
+	// 签发时间精确到微秒，与精确到毫秒的用户令牌失效时间比较时，同一秒内吊销前后签发的令牌也能区分
+	// 解析时经浮点数转换可能少 1 微秒，不影响毫秒级的比较
+	jwt.TimePrecision = time.Microsecond
+}
+
+// SetRevocationChecker 设置令牌吊销检查，ParseToken 会拒绝已吊销的令牌
+func SetRevocationChecker(checker RevocationChecker) {
+	revocationChecker = checker
+}
+
 type Claims struct {
-	UserID   uint   `json:"user_id"`
-	Username string `json:"username"`
-	IsAdmin  bool   `json:"is_admin"`
+	UserID    uint   `json:"user_id"`
+	Username  string `json:"username"`
+	IsAdmin   bool   `json:"is_admin"`
+	SessionID uint   `json:"sid,omitempty"` // 所属登录会话
 	jwt.RegisteredClaims
 }
 
@@ -25,31 +43,70 @@ func GenerateToken(userID uint, username string) (string, error) {
 	return GenerateTokenWithAdmin(userID, username, false)
 }
 
+// AccessTokenTTL 访问令牌有效期
+func AccessTokenTTL() time.Duration {
+	return time.Duration(config.Get().JWT.AccessExpireMinute) * time.Minute
+}
+
+// RefreshTokenTTL 刷新令牌有效期，即登录会话的最长空闲时间
+func RefreshTokenTTL() time.Duration {
+	return time.Duration(config.Get().JWT.ExpireHour) * time.Hour
+}
+
 func GenerateTokenWithAdmin(userID uint, username string, isAdmin bool) (string, error) {
-	cfg := config.Get().JWT
-	expireTime := time.Now().Add(time.Duration(cfg.ExpireHour) * time.Hour)
+	return GenerateSessionToken(userID, username, isAdmin, 0)
+}
+
+// GenerateSessionToken 签发绑定登录会话的访问令牌
+func GenerateSessionToken(userID uint, username string, isAdmin bool, sessionID uint) (string, error) {
+	keys, err := getKeys()
+	if err != nil {
+		return "", err
+	}
+	expireTime := time.Now().Add(AccessTokenTTL())
+
+	jti, err := GenerateRandomID(16)
+	if err != nil {
+		return "", err
+	}
 
 	claims := Claims{
-		UserID:   userID,
-		Username: username,
-		IsAdmin:  isAdmin,
+		UserID:    userID,
+		Username:  username,
+		IsAdmin:   isAdmin,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expireTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    "tidalcore",
+			ID:        jti,
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(cfg.Secret))
+	token := jwt.NewWithClaims(keys.method, claims)
+	if keys.signKID != "" {
+		token.Header["kid"] = keys.signKID
+	}
+	return token.SignedString(keys.signKey)
 }
 
 func ParseToken(tokenString string) (*Claims, error) {
-	cfg := config.Get().JWT
+	keys, err := getKeys()
+	if err != nil {
+		return nil, err
+	}
 
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(cfg.Secret), nil
-	})
+		if keys.verifyKeys == nil {
+			return keys.signKey, nil
+		}
+		kid, _ := token.Header["kid"].(string)
+		key, ok := keys.verifyKeys[kid]
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+		return key, nil
+	}, jwt.WithValidMethods([]string{keys.method.Alg()}))
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
@@ -59,6 +116,9 @@ func ParseToken(tokenString string) (*Claims, error) {
 	}
 
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+		if revocationChecker != nil && revocationChecker(claims) {
+			return nil, ErrRevokedToken
+		}
 		return claims, nil
 	}
 
@@ -0,0 +1,285 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"tidalcore-backend/config"
+)
+
+// signingKeys 当前的签名密钥及所有可用于验证的公钥
+type signingKeys struct {
+	method     jwt.SigningMethod
+	signKey    interface{}                 // HS256 为 []byte，非对称算法为私钥
+	signKID    string                      // 非对称算法的 kid，写入令牌头部
+	verifyKeys map[string]crypto.PublicKey // kid -> 公钥
+}
+
+var (
+	keysMu     sync.RWMutex
+	activeKeys *signingKeys
+)
+
+// InitKeys 根据配置加载签名密钥，启动时调用
+// release 模式下拒绝使用默认 HS256 密钥，也不会为非对称算法生成临时密钥
+func InitKeys() error {
+	cfg := config.Get()
+	keys, err := loadKeys(cfg.JWT, cfg.Server.Mode == "release")
+	if err != nil {
+		return err
+	}
+
+	keysMu.Lock()
+	activeKeys = keys
+	keysMu.Unlock()
+	return nil
+}
+
+func getKeys() (*signingKeys, error) {
+	keysMu.RLock()
+	keys := activeKeys
+	keysMu.RUnlock()
+	if keys != nil {
+		return keys, nil
+	}
+	if err := InitKeys(); err != nil {
+		return nil, err
+	}
+	return getKeys()
+}
+
+func loadKeys(cfg config.JWTConfig, release bool) (*signingKeys, error) {
+	switch strings.ToUpper(cfg.Algorithm) {
+	case "HS256":
+		if release && cfg.UsesDefaultSecret() {
+			return nil, errors.New("refusing to start in release mode with the default JWT secret; set jwt.secret (JWT_SECRET) or use an asymmetric algorithm")
+		}
+		return &signingKeys{
+			method:  jwt.SigningMethodHS256,
+			signKey: []byte(cfg.Secret),
+		}, nil
+	case "RS256":
+		return loadAsymmetricKeys(cfg, jwt.SigningMethodRS256, release)
+	case "EDDSA":
+		return loadAsymmetricKeys(cfg, jwt.SigningMethodEdDSA, release)
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", cfg.Algorithm)
+	}
+}
+
+func loadAsymmetricKeys(cfg config.JWTConfig, method jwt.SigningMethod, release bool) (*signingKeys, error) {
+	var private crypto.Signer
+	if cfg.SigningKeyFile == "" {
+		if release {
+			return nil, fmt.Errorf("jwt.signing_key_file is required for %s in release mode", method.Alg())
+		}
+		// 开发环境未配置私钥时生成临时密钥，重启后已签发的访问令牌失效
+		generated, err := generateKey(method)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Warning: No JWT signing key configured, using an ephemeral %s key", method.Alg())
+		private = generated
+	} else {
+		loaded, err := readPrivateKey(cfg.SigningKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load JWT signing key: %w", err)
+		}
+		private = loaded
+	}
+
+	if err := checkKeyType(method, private.Public()); err != nil {
+		return nil, fmt.Errorf("JWT signing key: %w", err)
+	}
+
+	keys := &signingKeys{
+		method:     method,
+		signKey:    private,
+		verifyKeys: make(map[string]crypto.PublicKey),
+	}
+	keys.signKID = keyThumbprint(private.Public())
+	keys.verifyKeys[keys.signKID] = private.Public()
+
+	for _, path := range cfg.VerificationKeyFiles {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		public, err := readPublicKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("load JWT verification key %s: %w", path, err)
+		}
+		if err := checkKeyType(method, public); err != nil {
+			return nil, fmt.Errorf("JWT verification key %s: %w", path, err)
+		}
+		keys.verifyKeys[keyThumbprint(public)] = public
+	}
+
+	return keys, nil
+}
+
+func generateKey(method jwt.SigningMethod) (crypto.Signer, error) {
+	if method == jwt.SigningMethodEdDSA {
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		return private, err
+	}
+	return rsa.GenerateKey(rand.Reader, 2048)
+}
+
+func checkKeyType(method jwt.SigningMethod, public crypto.PublicKey) error {
+	switch public.(type) {
+	case *rsa.PublicKey:
+		if method == jwt.SigningMethodRS256 {
+			return nil
+		}
+	case ed25519.PublicKey:
+		if method == jwt.SigningMethodEdDSA {
+			return nil
+		}
+	}
+	return fmt.Errorf("key type %T does not match algorithm %s", public, method.Alg())
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+	return block, nil
+}
+
+// readPrivateKey 读取 PKCS#8 或 PKCS#1 格式的私钥
+func readPrivateKey(path string) (crypto.Signer, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+// readPublicKey 读取公钥，也接受私钥文件并取其公钥部分
+func readPublicKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		private, err := readPrivateKey(path)
+		if err != nil {
+			return nil, err
+		}
+		return private.Public(), nil
+	}
+}
+
+// JWK JSON Web Key（RFC 7517）公钥
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS 返回所有验证公钥，HS256 模式下为空
+func JWKS() ([]JWK, error) {
+	keys, err := getKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]JWK, 0, len(keys.verifyKeys))
+	for kid, public := range keys.verifyKeys {
+		jwk := publicJWK(public)
+		jwk.Kid = kid
+		jwk.Use = "sig"
+		jwk.Alg = keys.method.Alg()
+		result = append(result, jwk)
+	}
+	return result, nil
+}
+
+func publicJWK(public crypto.PublicKey) JWK {
+	switch k := public.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		}
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(k),
+		}
+	}
+	return JWK{}
+}
+
+// keyThumbprint 计算公钥的 JWK Thumbprint（RFC 7638）作为 kid
+// kid 只由公钥决定，轮换后旧公钥作为验证密钥时 kid 保持不变
+func keyThumbprint(public crypto.PublicKey) string {
+	jwk := publicJWK(public)
+
+	var members interface{}
+	switch jwk.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	}
+
+	data, _ := json.Marshal(members)
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,259 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"tidalcore-backend/config"
+)
+
+// writeKeyFile 将私钥以 PKCS#8 PEM 写入临时文件
+func writeKeyFile(t *testing.T, key crypto.Signer) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+	return path
+}
+
+// writePublicKeyFile 将公钥以 PKIX PEM 写入临时文件
+func writePublicKeyFile(t *testing.T, key crypto.PublicKey) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "public.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("write public key: %v", err)
+	}
+	return path
+}
+
+// useKeys 以 release 模式加载密钥配置，测试结束后恢复原来的密钥
+func useKeys(t *testing.T, cfg config.JWTConfig) {
+	t.Helper()
+	keys, err := loadKeys(cfg, true)
+	if err != nil {
+		t.Fatalf("loadKeys: %v", err)
+	}
+	keysMu.Lock()
+	previous := activeKeys
+	activeKeys = keys
+	keysMu.Unlock()
+	t.Cleanup(func() {
+		keysMu.Lock()
+		activeKeys = previous
+		keysMu.Unlock()
+	})
+}
+
+var asymmetricAlgorithms = []struct {
+	alg    string
+	method jwt.SigningMethod
+}{
+	{"RS256", jwt.SigningMethodRS256},
+	{"EdDSA", jwt.SigningMethodEdDSA},
+}
+
+func TestAsymmetricSignAndVerify(t *testing.T) {
+	for _, tt := range asymmetricAlgorithms {
+		t.Run(tt.alg, func(t *testing.T) {
+			key, err := generateKey(tt.method)
+			if err != nil {
+				t.Fatalf("generateKey: %v", err)
+			}
+			useKeys(t, config.JWTConfig{Algorithm: tt.alg, SigningKeyFile: writeKeyFile(t, key)})
+
+			token, err := GenerateSessionToken(1, "alice", true, 7)
+			if err != nil {
+				t.Fatalf("GenerateSessionToken: %v", err)
+			}
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+			if err != nil {
+				t.Fatalf("ParseUnverified: %v", err)
+			}
+			if parsed.Method.Alg() != tt.method.Alg() || parsed.Header["kid"] != keyThumbprint(key.Public()) {
+				t.Fatalf("header = %v, want alg %s and the key thumbprint as kid", parsed.Header, tt.method.Alg())
+			}
+
+			claims, err := ParseToken(token)
+			if err != nil {
+				t.Fatalf("ParseToken: %v", err)
+			}
+			if claims.UserID != 1 || claims.Username != "alice" || !claims.IsAdmin || claims.SessionID != 7 {
+				t.Fatalf("claims = %+v", claims)
+			}
+		})
+	}
+}
+
+func TestKeyThumbprint(t *testing.T) {
+	decode := func(s string) []byte {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatalf("decode %q: %v", s, err)
+		}
+		return b
+	}
+
+	tests := []struct {
+		name string
+		key  crypto.PublicKey
+		want string
+	}{
+		{
+			// RFC 7638 第 3.1 节示例
+			name: "rsa",
+			key: &rsa.PublicKey{
+				N: new(big.Int).SetBytes(decode("0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw")),
+				E: 65537,
+			},
+			want: "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs",
+		},
+		{
+			// RFC 8037 附录 A.3 示例
+			name: "ed25519",
+			key:  ed25519.PublicKey(decode("11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo")),
+			want: "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k",
+		},
+	}
+	for _, tt := range tests {
+		if got := keyThumbprint(tt.key); got != tt.want {
+			t.Errorf("%s: keyThumbprint = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyWithRotatedKey(t *testing.T) {
+	for _, tt := range asymmetricAlgorithms {
+		t.Run(tt.alg, func(t *testing.T) {
+			oldKey, err := generateKey(tt.method)
+			if err != nil {
+				t.Fatalf("generateKey: %v", err)
+			}
+			newKey, err := generateKey(tt.method)
+			if err != nil {
+				t.Fatalf("generateKey: %v", err)
+			}
+			oldKeyFile := writeKeyFile(t, oldKey)
+
+			useKeys(t, config.JWTConfig{Algorithm: tt.alg, SigningKeyFile: oldKeyFile})
+			oldToken, err := GenerateToken(1, "alice")
+			if err != nil {
+				t.Fatalf("GenerateToken: %v", err)
+			}
+
+			// 未配置旧公钥时，轮换前签发的令牌无法验证
+			useKeys(t, config.JWTConfig{Algorithm: tt.alg, SigningKeyFile: writeKeyFile(t, newKey)})
+			if _, err := ParseToken(oldToken); !errors.Is(err, ErrInvalidToken) {
+				t.Fatalf("old token without verification key: err = %v, want ErrInvalidToken", err)
+			}
+
+			// 旧公钥或旧私钥文件都可以作为验证密钥
+			for _, verification := range []string{writePublicKeyFile(t, oldKey.Public()), oldKeyFile} {
+				useKeys(t, config.JWTConfig{
+					Algorithm:            tt.alg,
+					SigningKeyFile:       writeKeyFile(t, newKey),
+					VerificationKeyFiles: []string{verification},
+				})
+				if _, err := ParseToken(oldToken); err != nil {
+					t.Fatalf("old token with verification key: %v", err)
+				}
+				newToken, err := GenerateToken(1, "alice")
+				if err != nil {
+					t.Fatalf("GenerateToken: %v", err)
+				}
+				if _, err := ParseToken(newToken); err != nil {
+					t.Fatalf("new token: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	useKeys(t, config.JWTConfig{Algorithm: "HS256", Secret: "tidalcore-test-secret"})
+	if keys, err := JWKS(); err != nil || len(keys) != 0 {
+		t.Fatalf("HS256 JWKS = %v, %v; want no keys", keys, err)
+	}
+
+	for _, tt := range asymmetricAlgorithms {
+		t.Run(tt.alg, func(t *testing.T) {
+			signing, err := generateKey(tt.method)
+			if err != nil {
+				t.Fatalf("generateKey: %v", err)
+			}
+			rotated, err := generateKey(tt.method)
+			if err != nil {
+				t.Fatalf("generateKey: %v", err)
+			}
+			useKeys(t, config.JWTConfig{
+				Algorithm:            tt.alg,
+				SigningKeyFile:       writeKeyFile(t, signing),
+				VerificationKeyFiles: []string{writePublicKeyFile(t, rotated.Public())},
+			})
+
+			keys, err := JWKS()
+			if err != nil {
+				t.Fatalf("JWKS: %v", err)
+			}
+			want := map[string]crypto.PublicKey{
+				keyThumbprint(signing.Public()): signing.Public(),
+				keyThumbprint(rotated.Public()): rotated.Public(),
+			}
+			if len(keys) != len(want) {
+				t.Fatalf("JWKS returned %d keys, want %d", len(keys), len(want))
+			}
+			for _, jwk := range keys {
+				public, ok := want[jwk.Kid]
+				if !ok {
+					t.Fatalf("unexpected kid %s", jwk.Kid)
+				}
+				expected := publicJWK(public)
+				if jwk.Use != "sig" || jwk.Alg != tt.method.Alg() || jwk.Kty != expected.Kty ||
+					jwk.N != expected.N || jwk.E != expected.E || jwk.Crv != expected.Crv || jwk.X != expected.X {
+					t.Fatalf("jwk = %+v", jwk)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadKeysInReleaseMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.JWTConfig
+		release bool
+		wantErr bool
+	}{
+		{"default secret in release", config.JWTConfig{Algorithm: "HS256", Secret: config.DefaultJWTSecret}, true, true},
+		{"example secret in release", config.JWTConfig{Algorithm: "HS256", Secret: "your-jwt-secret-key-change-in-production-at-least-32-chars"}, true, true},
+		{"default secret in debug", config.JWTConfig{Algorithm: "HS256", Secret: config.DefaultJWTSecret}, false, false},
+		{"custom secret in release", config.JWTConfig{Algorithm: "HS256", Secret: "tidalcore-test-secret"}, true, false},
+		{"asymmetric without key in release", config.JWTConfig{Algorithm: "RS256"}, true, true},
+		{"unknown algorithm", config.JWTConfig{Algorithm: "none"}, false, true},
+	}
+	for _, tt := range tests {
+		_, err := loadKeys(tt.cfg, tt.release)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package auth
+
+import (
+	"os"
+	"testing"
+
+	"tidalcore-backend/config"
+)
+
+func TestMain(m *testing.M) {
+	if err := config.Load(""); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
@@ -1,15 +1,123 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
+
+	"tidalcore-backend/config"
+)
+
+const (
+	HasherArgon2id = "argon2id"
+	HasherBcrypt   = "bcrypt"
+
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
 )
 
+// argon2Params 编码在哈希字符串中的 argon2id 参数
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+}
+
+// HashPassword 使用配置的算法计算密码哈希
+// argon2id 哈希采用 PHC 字符串格式：$argon2id$v=19$m=...,t=...,p=...$salt$hash
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(bytes), err
+	cfg := config.Get().Password
+	if cfg.Hasher == HasherBcrypt {
+		bytes, err := bcrypt.GenerateFromPassword([]byte(password), cfg.BcryptCost)
+		return string(bytes), err
+	}
+
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	p := configuredArgon2Params()
+	key := argon2.IDKey([]byte(password), salt, p.iterations, p.memory, p.parallelism, argon2KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.memory, p.iterations, p.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
 }
 
+// CheckPassword 校验密码，根据哈希前缀自动识别 argon2id 或 bcrypt
 func CheckPassword(password, hash string) bool {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		p, salt, key, err := decodeArgon2Hash(hash)
+		if err != nil {
+			return false
+		}
+		computed := argon2.IDKey([]byte(password), salt, p.iterations, p.memory, p.parallelism, uint32(len(key)))
+		return subtle.ConstantTimeCompare(computed, key) == 1
+	}
+
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
 }
+
+// PasswordNeedsRehash 判断哈希是否使用了旧算法或旧参数，需要在登录成功后重新计算
+func PasswordNeedsRehash(hash string) bool {
+	cfg := config.Get().Password
+
+	if cfg.Hasher == HasherBcrypt {
+		cost, err := bcrypt.Cost([]byte(hash))
+		return err != nil || cost != cfg.BcryptCost
+	}
+
+	p, _, _, err := decodeArgon2Hash(hash)
+	return err != nil || p != configuredArgon2Params()
+}
+
+func configuredArgon2Params() argon2Params {
+	cfg := config.Get().Password
+	return argon2Params{
+		memory:      cfg.Argon2Memory,
+		iterations:  cfg.Argon2Iterations,
+		parallelism: cfg.Argon2Parallelism,
+	}
+}
+
+// decodeArgon2Hash 解析 PHC 格式的 argon2id 哈希
+func decodeArgon2Hash(hash string) (argon2Params, []byte, []byte, error) {
+	var p argon2Params
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return p, nil, nil, fmt.Errorf("not an argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return p, nil, nil, err
+	}
+	if version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("unsupported argon2 version %d", version)
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.iterations, &p.parallelism); err != nil {
+		return p, nil, nil, err
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, err
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, err
+	}
+	if len(salt) == 0 || len(key) == 0 {
+		return p, nil, nil, fmt.Errorf("malformed argon2id hash")
+	}
+	return p, salt, key, nil
+}
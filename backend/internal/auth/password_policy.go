@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"tidalcore-backend/config"
+)
+
+// 密码不符合策略的原因代码，前端可据此展示提示
+const (
+	PasswordTooShort         = "too_short"
+	PasswordTooLong          = "too_long"
+	PasswordTooSimple        = "too_few_char_classes"
+	PasswordContainsName     = "contains_username"
+	PasswordCommonOrBreached = "common_password"
+)
+
+// PasswordViolation 密码不符合策略的一项原因
+type PasswordViolation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+//go:embed common_passwords.txt
+var bundledCommonPasswords string
+
+var (
+	commonPasswords     map[string]struct{}
+	commonPasswordsOnce sync.Once
+	commonPasswordsErr  error
+)
+
+// InitPasswordPolicy 检查密码哈希算法配置并加载常见密码列表，配置了额外列表文件但无法读取时返回错误
+func InitPasswordPolicy() error {
+	if hasher := config.Get().Password.Hasher; hasher != HasherArgon2id && hasher != HasherBcrypt {
+		return fmt.Errorf("unsupported password hasher %q", hasher)
+	}
+
+	loadCommonPasswords()
+	return commonPasswordsErr
+}
+
+func loadCommonPasswords() {
+	commonPasswordsOnce.Do(func() {
+		commonPasswords = make(map[string]struct{})
+		readPasswordList(strings.NewReader(bundledCommonPasswords))
+
+		path := config.Get().Password.CommonListFile
+		if path == "" {
+			return
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			commonPasswordsErr = fmt.Errorf("open common password list: %w", err)
+			return
+		}
+		defer f.Close()
+		if err := readPasswordList(f); err != nil {
+			commonPasswordsErr = fmt.Errorf("read common password list: %w", err)
+		}
+	})
+}
+
+// readPasswordList 每行一个密码，忽略空行和 # 开头的注释
+func readPasswordList(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		commonPasswords[strings.ToLower(line)] = struct{}{}
+	}
+	return scanner.Err()
+}
+
+// IsCommonPassword 判断密码是否在常见/已泄露密码列表中（不区分大小写）
+func IsCommonPassword(password string) bool {
+	loadCommonPasswords()
+	_, ok := commonPasswords[strings.ToLower(password)]
+	return ok
+}
+
+// CheckPasswordPolicy 按配置的密码策略检查密码，返回所有不符合的原因，符合时返回 nil
+func CheckPasswordPolicy(password, username string) []PasswordViolation {
+	policy := config.Get().Password
+	var violations []PasswordViolation
+
+	length := utf8.RuneCountInString(password)
+	if length < policy.MinLength {
+		violations = append(violations, PasswordViolation{
+			Code:    PasswordTooShort,
+			Message: fmt.Sprintf("密码至少 %d 个字符", policy.MinLength),
+		})
+	}
+	if length > policy.MaxLength {
+		violations = append(violations, PasswordViolation{
+			Code:    PasswordTooLong,
+			Message: fmt.Sprintf("密码最多 %d 个字符", policy.MaxLength),
+		})
+	}
+
+	if classes := countCharClasses(password); classes < policy.MinCharClasses {
+		violations = append(violations, PasswordViolation{
+			Code:    PasswordTooSimple,
+			Message: fmt.Sprintf("密码需包含小写字母、大写字母、数字、符号中的至少 %d 类", policy.MinCharClasses),
+		})
+	}
+
+	name := strings.ToLower(strings.TrimSpace(username))
+	if !policy.AllowUsername && name != "" && strings.Contains(strings.ToLower(password), name) {
+		violations = append(violations, PasswordViolation{
+			Code:    PasswordContainsName,
+			Message: "密码不能包含用户名",
+		})
+	}
+
+	if !policy.DisableCommonCheck && IsCommonPassword(password) {
+		violations = append(violations, PasswordViolation{
+			Code:    PasswordCommonOrBreached,
+			Message: "密码过于常见或已在泄露数据中出现",
+		})
+	}
+
+	return violations
+}
+
+// countCharClasses 统计密码包含的字符类别数：小写字母、大写字母、数字、其他符号
+func countCharClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	count := 0
+	for _, ok := range []bool{lower, upper, digit, symbol} {
+		if ok {
+			count++
+		}
+	}
+	return count
+}
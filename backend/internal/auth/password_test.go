@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"tidalcore-backend/config"
+)
+
+// usePasswordConfig 临时修改密码哈希配置，测试结束后恢复
+func usePasswordConfig(t *testing.T, change func(cfg *config.PasswordConfig)) {
+	t.Helper()
+	cfg := &config.Get().Password
+	previous := *cfg
+	change(cfg)
+	t.Cleanup(func() { *cfg = previous })
+}
+
+func TestArgon2idHash(t *testing.T) {
+	usePasswordConfig(t, func(cfg *config.PasswordConfig) {
+		cfg.Hasher = HasherArgon2id
+		cfg.Argon2Memory = 8 * 1024
+		cfg.Argon2Iterations = 1
+		cfg.Argon2Parallelism = 2
+	})
+
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=8192,t=1,p=2$") {
+		t.Fatalf("hash = %s, want PHC string with configured params", hash)
+	}
+	p, salt, key, err := decodeArgon2Hash(hash)
+	if err != nil {
+		t.Fatalf("decodeArgon2Hash: %v", err)
+	}
+	if p != (argon2Params{memory: 8 * 1024, iterations: 1, parallelism: 2}) || len(salt) != argon2SaltLength || len(key) != argon2KeyLength {
+		t.Fatalf("decoded params = %+v, salt %d bytes, key %d bytes", p, len(salt), len(key))
+	}
+
+	if !CheckPassword("correct horse", hash) {
+		t.Fatal("CheckPassword rejected the correct password")
+	}
+	if CheckPassword("wrong horse", hash) {
+		t.Fatal("CheckPassword accepted a wrong password")
+	}
+	if PasswordNeedsRehash(hash) {
+		t.Fatal("fresh hash reported as needing rehash")
+	}
+
+	// 同一密码每次使用不同的盐
+	again, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	if again == hash {
+		t.Fatal("two hashes of the same password are identical")
+	}
+}
+
+func TestDecodeArgon2HashRejectsMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		hash string
+	}{
+		{"bcrypt", "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy"},
+		{"missing part", "$argon2id$v=19$m=8192,t=1,p=1$c2FsdHNhbHQ"},
+		{"wrong version", "$argon2id$v=16$m=8192,t=1,p=1$c2FsdHNhbHQ$a2V5a2V5"},
+		{"bad params", "$argon2id$v=19$m=x,t=1,p=1$c2FsdHNhbHQ$a2V5a2V5"},
+		{"bad salt", "$argon2id$v=19$m=8192,t=1,p=1$!!!$a2V5a2V5"},
+		{"empty key", "$argon2id$v=19$m=8192,t=1,p=1$c2FsdHNhbHQ$"},
+	}
+	for _, tt := range tests {
+		if _, _, _, err := decodeArgon2Hash(tt.hash); err == nil {
+			t.Errorf("%s: decodeArgon2Hash(%q) succeeded", tt.name, tt.hash)
+		}
+		if CheckPassword("password", tt.hash) {
+			t.Errorf("%s: CheckPassword accepted %q", tt.name, tt.hash)
+		}
+	}
+}
+
+func TestPasswordNeedsRehash(t *testing.T) {
+	usePasswordConfig(t, func(cfg *config.PasswordConfig) {
+		cfg.Hasher = HasherArgon2id
+		cfg.Argon2Memory = 8 * 1024
+		cfg.Argon2Iterations = 1
+		cfg.Argon2Parallelism = 1
+		cfg.BcryptCost = bcrypt.MinCost
+	})
+	cfg := &config.Get().Password
+
+	current, err := HashPassword("password")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("bcrypt: %v", err)
+	}
+	cfg.Argon2Iterations = 2
+	oldParams, err := HashPassword("password")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	cfg.Argon2Iterations = 1
+
+	tests := []struct {
+		name   string
+		hasher string
+		hash   string
+		want   bool
+	}{
+		{"current argon2id", HasherArgon2id, current, false},
+		{"legacy bcrypt", HasherArgon2id, string(legacy), true},
+		{"old argon2id params", HasherArgon2id, oldParams, true},
+		{"garbage", HasherArgon2id, "not-a-hash", true},
+		{"bcrypt configured, same cost", HasherBcrypt, string(legacy), false},
+		{"bcrypt configured, argon2id hash", HasherBcrypt, current, true},
+	}
+	for _, tt := range tests {
+		cfg.Hasher = tt.hasher
+		if got := PasswordNeedsRehash(tt.hash); got != tt.want {
+			t.Errorf("%s: PasswordNeedsRehash = %v, want %v", tt.name, got, tt.want)
+		}
+		// 无论配置哪种算法，旧哈希都能继续校验
+		if tt.hash != "not-a-hash" && !CheckPassword("password", tt.hash) {
+			t.Errorf("%s: CheckPassword rejected the correct password", tt.name)
+		}
+	}
+}
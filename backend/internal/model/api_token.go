@@ -0,0 +1,43 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// APIToken 个人访问令牌，供脚本、快捷指令等第三方集成使用
+// 仅可访问令牌授权范围内的接口
+type APIToken struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"index;not null" json:"-"`
+	Name       string     `gorm:"size:50;not null" json:"name"`
+	Prefix     string     `gorm:"size:16;not null" json:"prefix"`        // 令牌开头几位，便于用户辨认
+	TokenHash  string     `gorm:"uniqueIndex;size:64;not null" json:"-"` // SHA-256，不保存明文
+	Scopes     string     `gorm:"size:255;not null" json:"-"`            // 逗号分隔的授权范围
+	ExpiresAt  *time.Time `gorm:"index" json:"expires_at"`               // 为空表示永不过期
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `gorm:"size:64" json:"last_used_ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (APIToken) TableName() string {
+	return "api_tokens"
+}
+
+// ScopeList 返回令牌的授权范围列表
+func (t *APIToken) ScopeList() []string {
+	if t.Scopes == "" {
+		return nil
+	}
+	return strings.Split(t.Scopes, ",")
+}
+
+// HasScope 判断令牌是否拥有指定授权范围
+func (t *APIToken) HasScope(scope string) bool {
+	for _, s := range t.ScopeList() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+)
+
+// 审计记录的目标类型
+const (
+	AuditTargetUser       = "user"
+	AuditTargetBackup     = "backup"
+	AuditTargetChallenge  = "challenge"
+	AuditTargetNameReview = "name_review"
+	AuditTargetLockout    = "lockout"
+	AuditTargetPasskey    = "passkey"
+	AuditTargetSystem     = "system"
+)
+
+// AuditLog 管理员操作和账号安全事件的审计记录，与所记录的变更在同一事务中写入，只增不改
+type AuditLog struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ActorID    uint      `gorm:"index;not null;default:0" json:"actor_id"` // 执行操作的管理员或用户本人，0 表示系统后台任务或未登录的请求
+	ActorName  string    `gorm:"size:50;default:''" json:"actor_name"`     // 操作时的用户名快照，管理员账号清除后仍可辨认
+	Action     string    `gorm:"size:50;index;not null" json:"action"`     // 如 user.delete、backup.restore
+	TargetType string    `gorm:"size:30;index:idx_audit_target" json:"target_type"`
+	TargetID   string    `gorm:"size:100;index:idx_audit_target" json:"target_id"` // 用户 ID、备份文件名等
+	Before     JSONText  `gorm:"type:text" json:"before"`                          // 变更前的值
+	After      JSONText  `gorm:"type:text" json:"after"`                           // 变更后的值
+	IP         string    `gorm:"size:64;default:''" json:"ip"`
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+}
+
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+// JSONText 以文本存储的 JSON，接口中原样输出为 JSON 值，为空时输出 null
+type JSONText string
+
+func (j JSONText) MarshalJSON() ([]byte, error) {
+	if j == "" {
+		return []byte("null"), nil
+	}
+	return []byte(j), nil
+}
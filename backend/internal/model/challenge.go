@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ChallengeGoalSessions = "sessions" // 打卡次数
+	ChallengeGoalMinutes  = "minutes"  // 累计训练分钟数
+	ChallengeGoalStreak   = "streak"   // 挑战期内最长连续打卡天数
+)
+
+// Challenge 限时挑战活动
+type Challenge struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Title       string         `gorm:"size:100;not null" json:"title"`
+	Description string         `gorm:"size:500;default:''" json:"description"`
+	GoalType    string         `gorm:"size:20;not null" json:"goal_type"`
+	GoalValue   int            `gorm:"not null" json:"goal_value"`
+	StartDate   string         `gorm:"index;size:10;not null" json:"start_date"` // 格式: 2006-01-02，包含当天
+	EndDate     string         `gorm:"index;size:10;not null" json:"end_date"`   // 格式: 2006-01-02，包含当天
+	CreatorID   uint           `gorm:"not null" json:"creator_id"`
+	FinalizedAt *time.Time     `json:"finalized_at"` // 结束后生成最终排名的时间
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (Challenge) TableName() string {
+	return "challenges"
+}
+
+// ChallengeParticipant 挑战参与记录
+type ChallengeParticipant struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	ChallengeID uint       `gorm:"uniqueIndex:idx_challenge_user;not null" json:"challenge_id"`
+	UserID      uint       `gorm:"uniqueIndex:idx_challenge_user;index;not null" json:"user_id"`
+	Progress    int        `gorm:"default:0" json:"progress"`                // 挑战结束时的最终进度
+	CompletedAt *time.Time `json:"completed_at"`                             // 达成目标的时间，未完成为空
+	Rank        int        `gorm:"column:finish_rank;default:0" json:"rank"` // 完成者排名，未完成为 0
+	CreatedAt   time.Time  `json:"joined_at"`
+}
+
+func (ChallengeParticipant) TableName() string {
+	return "challenge_participants"
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+)
+
+// 允许的鼓励类型，不支持自由文本以保护隐私
+const (
+	CheerKindClap   = "clap"   // 👏
+	CheerKindFire   = "fire"   // 🔥
+	CheerKindWave   = "wave"   // 🌊
+	CheerKindStrong = "strong" // 💪
+)
+
+// Cheer 用户之间的鼓励
+type Cheer struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	SenderID   uint      `gorm:"uniqueIndex:idx_cheer_sender_receiver_date;index:idx_cheer_sender_date;not null" json:"sender_id"`
+	ReceiverID uint      `gorm:"uniqueIndex:idx_cheer_sender_receiver_date;index;not null" json:"receiver_id"`
+	Kind       string    `gorm:"size:20;not null" json:"kind"`
+	SentDate   string    `gorm:"uniqueIndex:idx_cheer_sender_receiver_date;index:idx_cheer_sender_date;size:10;not null" json:"sent_date"` // 格式: 2006-01-02
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (Cheer) TableName() string {
+	return "cheers"
+}
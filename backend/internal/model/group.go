@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	GroupRoleOwner  = "owner"
+	GroupRoleMember = "member"
+)
+
+// Group 私有小组
+type Group struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Name        string         `gorm:"size:50;not null" json:"name"`
+	Description string         `gorm:"size:255;default:''" json:"description"`
+	OwnerID     uint           `gorm:"index;not null" json:"owner_id"`
+	InviteCode  string         `gorm:"uniqueIndex;size:16;not null" json:"invite_code,omitempty"` // 邀请码，仅成员可见
+	MemberCount int            `gorm:"-" json:"member_count"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName 避免与 MySQL 8 保留字 GROUPS 冲突
+func (Group) TableName() string {
+	return "user_groups"
+}
+
+// GroupMember 小组成员关系
+type GroupMember struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	GroupID   uint      `gorm:"uniqueIndex:idx_group_user;not null" json:"group_id"`
+	UserID    uint      `gorm:"uniqueIndex:idx_group_user;index;not null" json:"user_id"`
+	Role      string    `gorm:"size:20;not null;default:'member'" json:"role"` // owner / member
+	CreatedAt time.Time `json:"joined_at"`
+}
+
+func (GroupMember) TableName() string {
+	return "group_members"
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+)
+
+// LoginFailure 按用户名记录的登录失败状态，用于渐进式锁定
+type LoginFailure struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	Username     string     `gorm:"uniqueIndex;size:50;not null" json:"username"`
+	FailedCount  int        `gorm:"default:0" json:"failed_count"` // 当前连续失败次数
+	LockCount    int        `gorm:"default:0" json:"lock_count"`   // 累计锁定次数，决定下次锁定时长
+	LastFailedAt *time.Time `json:"last_failed_at"`
+	LastFailedIP string     `gorm:"size:64" json:"last_failed_ip"`
+	LockedUntil  *time.Time `gorm:"index" json:"locked_until"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (LoginFailure) TableName() string {
+	return "login_failures"
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+)
+
+// 显示名称审核状态
+const (
+	NameReviewPending   = "pending"
+	NameReviewApproved  = "approved"
+	NameReviewRejected  = "rejected"
+	NameReviewCancelled = "cancelled" // 用户改用了其他名称
+)
+
+// 审核的名称类型
+const (
+	NameFieldDisplayName = "display_name"
+	NameFieldUsername    = "username" // 用户名是登录凭据，命中屏蔽词时先行生效，驳回后通知用户更换
+)
+
+// NameReview 命中屏蔽词或保留名称、等待管理员审核的显示名称或用户名
+// 显示名称在审核通过前保持不变（新注册用户暂用用户名）
+type NameReview struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	UserID       uint       `gorm:"index;not null" json:"user_id"`
+	Field        string     `gorm:"size:20;not null;default:'display_name'" json:"field"` // NameFieldDisplayName 或 NameFieldUsername
+	DisplayName  string     `gorm:"size:50;not null" json:"display_name"`                 // 申请使用的名称，Field 为 username 时是用户名
+	MatchedTerms string     `gorm:"size:255;default:''" json:"matched_terms"`             // 命中的词语，逗号分隔
+	Status       string     `gorm:"size:20;index;not null;default:'pending'" json:"status"`
+	ReviewedBy   *uint      `json:"reviewed_by"`
+	ReviewedAt   *time.Time `json:"reviewed_at"`
+	RejectReason string     `gorm:"size:255;default:''" json:"reject_reason"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+func (NameReview) TableName() string {
+	return "name_reviews"
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+)
+
+// 通知类型
+const (
+	NotificationAchievement  = "achievement"  // 成就解锁
+	NotificationFriend       = "friend"       // 好友请求
+	NotificationCheer        = "cheer"        // 收到鼓励
+	NotificationStreakRisk   = "streak_risk"  // 连续打卡即将中断
+	NotificationAnnouncement = "announcement" // 管理员公告
+	NotificationSystem       = "system"       // 系统消息
+)
+
+// Notification 站内通知
+type Notification struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"index:idx_notification_user_read;not null" json:"user_id"`
+	Type      string     `gorm:"size:20;not null" json:"type"`
+	Title     string     `gorm:"size:100;not null" json:"title"`
+	Content   string     `gorm:"size:500;default:''" json:"content"`
+	ReadAt    *time.Time `gorm:"index:idx_notification_user_read" json:"read_at"`
+	CreatedAt time.Time  `gorm:"index" json:"created_at"`
+}
+
+func (Notification) TableName() string {
+	return "notifications"
+}
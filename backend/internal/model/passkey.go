@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// PasskeyCredential 用户注册的通行密钥 (WebAuthn 凭据)
+// 只保存公钥和随机生成的用户句柄，不涉及任何个人信息
+type PasskeyCredential struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	UserID          uint       `gorm:"index;not null" json:"-"`
+	Name            string     `gorm:"size:50;not null" json:"name"`
+	CredentialID    string     `gorm:"uniqueIndex;size:255;not null" json:"-"` // base64url 编码的凭据 ID
+	UserHandle      string     `gorm:"index;size:64;not null" json:"-"`        // base64url 编码的随机用户句柄，同一用户的凭据共用
+	PublicKey       []byte     `gorm:"type:blob;not null" json:"-"`            // COSE 格式公钥
+	AttestationType string     `gorm:"size:32" json:"-"`
+	Transports      string     `gorm:"size:100" json:"-"` // 逗号分隔的传输方式，如 internal,hybrid
+	AAGUID          []byte     `gorm:"type:varbinary(16)" json:"-"`
+	SignCount       uint32     `gorm:"not null;default:0" json:"-"`
+	Flags           uint8      `gorm:"not null;default:0" json:"-"`     // 注册时认证器标志位原始值
+	BackupEligible  bool       `gorm:"not null" json:"backup_eligible"` // 可在设备间同步的通行密钥
+	LastUsedAt      *time.Time `json:"last_used_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+func (PasskeyCredential) TableName() string {
+	return "passkey_credentials"
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+)
+
+// PasswordRecoveryCode 用户自助找回密码的一次性恢复码
+// 注册时或在个人资料中生成，重新生成时旧的一组全部作废
+type PasswordRecoveryCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	CodeHash  string     `gorm:"size:64;not null" json:"-"` // SHA-256，不保存明文
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (PasswordRecoveryCode) TableName() string {
+	return "password_recovery_codes"
+}
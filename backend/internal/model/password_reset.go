@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+)
+
+// PasswordReset 管理员为用户签发的一次性密码重置码
+// 记录签发人和使用情况，便于审计
+type PasswordReset struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	CodeHash  string     `gorm:"size:64;not null" json:"-"` // SHA-256，不保存明文
+	IssuedBy  uint       `gorm:"not null" json:"issued_by"` // 签发的管理员
+	IssuedIP  string     `gorm:"size:64" json:"issued_ip"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	Attempts  int        `gorm:"default:0" json:"attempts"` // 输错次数
+	UsedAt    *time.Time `json:"used_at"`
+	UsedIP    string     `gorm:"size:64" json:"used_ip"`
+	RevokedAt *time.Time `json:"revoked_at"` // 被新重置码取代或输错次数过多而作废
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (PasswordReset) TableName() string {
+	return "password_resets"
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+)
+
+// TwoFactorRecoveryCode 两步验证恢复码，无法使用身份验证器时代替验证码登录
+// 每个恢复码只能使用一次
+type TwoFactorRecoveryCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	CodeHash  string     `gorm:"size:64;not null" json:"-"` // SHA-256，不保存明文
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (TwoFactorRecoveryCode) TableName() string {
+	return "two_factor_recovery_codes"
+}
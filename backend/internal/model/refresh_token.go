@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+)
+
+// RefreshToken 服务端保存的刷新令牌
+// 每次刷新都会轮换新令牌，同一登录会话派生的令牌共享 FamilyID
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	TokenHash string     `gorm:"uniqueIndex;size:64;not null" json:"-"` // SHA-256，不保存明文
+	FamilyID  string     `gorm:"index;size:32;not null" json:"family_id"`
+	ExpiresAt time.Time  `gorm:"index;not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`    // 已轮换的时间
+	RevokedAt *time.Time `json:"revoked_at"` // 被吊销的时间
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
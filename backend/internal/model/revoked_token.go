@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+)
+
+// RevokedToken 已吊销的访问令牌，按 jti 记录，令牌过期后可清理
+type RevokedToken struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	JTI       string    `gorm:"column:jti;uniqueIndex;size:32;not null" json:"jti"`
+	UserID    uint      `gorm:"index;not null" json:"user_id"`
+	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (RevokedToken) TableName() string {
+	return "revoked_tokens"
+}
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+)
+
+// Session 登录会话，对应一个刷新令牌家族
+type Session struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"index;not null" json:"-"`
+	FamilyID   string     `gorm:"uniqueIndex;size:32;not null" json:"-"`
+	UserAgent  string     `gorm:"size:512" json:"user_agent"`
+	IP         string     `gorm:"size:64" json:"ip"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	RevokedAt  *time.Time `gorm:"index" json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (Session) TableName() string {
+	return "sessions"
+}
@@ -7,21 +7,37 @@ import (
 )
 
 type User struct {
-	ID           uint           `gorm:"primaryKey" json:"id"`
-	Username     string         `gorm:"uniqueIndex;size:50;not null" json:"username"`
-	DisplayName  string         `gorm:"size:50;not null" json:"display_name"` // 显示名称，支持中文和符号
-	PasswordHash string         `gorm:"size:255;not null" json:"-"`
-	IsAdmin      bool           `gorm:"default:false" json:"is_admin"` // 是否为管理员
-	Title        string         `gorm:"size:50;default:''" json:"title"`  // 用户称号，空字符串表示自动根据打卡次数计算
-	Streak       int            `gorm:"default:0" json:"streak"`
-	MaxStreak    int            `gorm:"default:0" json:"max_streak"`
-	TotalCheckin int            `gorm:"default:0" json:"total_checkin"`
-	LastCheckin  *time.Time     `json:"last_checkin"`
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at"`
-	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	Username        string         `gorm:"uniqueIndex;size:50;not null" json:"username"`
+	DisplayName     string         `gorm:"size:50;not null" json:"display_name"` // 显示名称，支持中文和符号
+	PasswordHash    string         `gorm:"size:255;not null" json:"-"`           // 为空表示单点登录创建的账号尚未设置本地密码
+	IsAdmin         bool           `gorm:"default:false" json:"is_admin"`        // 是否为管理员
+	Title           string         `gorm:"size:50;default:''" json:"title"`      // 用户称号，空字符串表示自动根据打卡次数计算
+	Streak          int            `gorm:"default:0" json:"streak"`
+	MaxStreak       int            `gorm:"default:0" json:"max_streak"`
+	TotalCheckin    int            `gorm:"default:0" json:"total_checkin"`
+	LastCheckin     *time.Time     `json:"last_checkin"`
+	TokensRevokedAt *time.Time     `json:"-"`                           // 此时间之前签发的访问令牌全部失效
+	TOTPSecret      string         `gorm:"size:64;default:''" json:"-"` // 两步验证密钥（Base32），启用前为待确认状态
+	TOTPEnabled     bool           `gorm:"default:false" json:"-"`      // 是否已启用两步验证，仅在本人资料中返回
+	TOTPLastStep    int64          `gorm:"default:0" json:"-"`          // 最近一次通过校验的时间步，防止验证码重放
+	SuspendedAt     *time.Time     `gorm:"index" json:"-"`              // 封禁时间，为空表示账号正常；封禁相关字段仅在管理后台返回
+	SuspendedUntil  *time.Time     `json:"-"`                           // 封禁到期时间，为空表示永久封禁
+	SuspendReason   string         `gorm:"size:255;default:''" json:"-"`
+	SuspendedBy     *uint          `json:"-"` // 执行封禁的管理员
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 func (User) TableName() string {
 	return "users"
 }
+
+// IsSuspended 判断账号在指定时间是否处于封禁中，到期的临时封禁视为已解除
+func (u *User) IsSuspended(now time.Time) bool {
+	if u.SuspendedAt == nil {
+		return false
+	}
+	return u.SuspendedUntil == nil || now.Before(*u.SuspendedUntil)
+}
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+)
+
+// UserIdentity 用户绑定的外部身份（OIDC 单点登录）
+// 以身份提供方的 issuer + sub 唯一确定一个外部账号；不保存邮箱等个人信息
+type UserIdentity struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"index;not null" json:"-"`
+	Issuer      string     `gorm:"uniqueIndex:idx_identity_subject;size:255;not null" json:"issuer"`
+	Subject     string     `gorm:"uniqueIndex:idx_identity_subject;size:255;not null" json:"subject"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// User 会出现在排行榜等公开接口中，安全相关字段不能序列化
+func TestUserJSONHidesPrivateFields(t *testing.T) {
+	now := time.Now()
+	data, err := json.Marshal(User{
+		PasswordHash:  "hash",
+		TOTPSecret:    "secret",
+		TOTPEnabled:   true,
+		SuspendedAt:   &now,
+		SuspendReason: "spam",
+	})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	for _, key := range []string{"password_hash", "totp_secret", "totp_enabled", "totp_last_step", "suspended_at", "suspended_until", "suspend_reason", "suspended_by"} {
+		if _, ok := fields[key]; ok {
+			t.Errorf("User JSON contains %q", key)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package moderation
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+
+	"tidalcore-backend/config"
+)
+
+//go:embed reserved_usernames.txt
+var bundledReservedNames string
+
+//go:embed name_blocklist.txt
+var bundledBlocklist string
+
+// blockedTerm 屏蔽词及其规范化形式
+type blockedTerm struct {
+	term     string
+	skeleton string
+}
+
+var (
+	reservedNames map[string]string // 规范化形式 -> 原始词
+	blockedTerms  []blockedTerm
+	listsOnce     sync.Once
+	listsErr      error
+)
+
+// confusables 形近字符映射，在统一大小写和兼容字符（全角、上标等）之后使用
+var confusables = map[rune]rune{
+	// 数字和符号
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '8': 'b', '9': 'g',
+	'@': 'a', '$': 's', '!': 'i', '|': 'i', 'l': 'i',
+	// 西里尔字母
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o',
+	'р': 'p', 'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'і': 'i', 'ј': 'j', 'ѕ': 's', 'ԁ': 'd',
+	// 希腊字母
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o',
+	'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x',
+}
+
+// InitNameLists 加载保留用户名和屏蔽词列表，配置了额外列表文件但无法读取时返回错误
+func InitNameLists() error {
+	loadLists()
+	return listsErr
+}
+
+func loadLists() {
+	listsOnce.Do(func() {
+		cfg := config.Get().Account
+
+		reservedNames = make(map[string]string)
+		readList(strings.NewReader(bundledReservedNames), addReserved)
+		for _, name := range cfg.ReservedUsernames {
+			addReserved(strings.TrimSpace(name))
+		}
+
+		blockedTerms = nil
+		readList(strings.NewReader(bundledBlocklist), addBlocked)
+
+		if cfg.NameBlocklistFile == "" {
+			return
+		}
+		f, err := os.Open(cfg.NameBlocklistFile)
+		if err != nil {
+			listsErr = fmt.Errorf("open name blocklist: %w", err)
+			return
+		}
+		defer f.Close()
+		if err := readList(f, addBlocked); err != nil {
+			listsErr = fmt.Errorf("read name blocklist: %w", err)
+		}
+	})
+}
+
+func addReserved(name string) {
+	if s := Skeleton(name); s != "" {
+		reservedNames[s] = name
+	}
+}
+
+func addBlocked(term string) {
+	if s := Skeleton(term); s != "" {
+		blockedTerms = append(blockedTerms, blockedTerm{term: term, skeleton: s})
+	}
+}
+
+// readList 每行一项，忽略空行和 # 开头的注释
+func readList(r io.Reader, add func(string)) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		add(line)
+	}
+	return scanner.Err()
+}
+
+// Skeleton 将名称规范化为用于比较的形式：
+// 兼容分解并去掉重音符号（全角转半角、é 转 e），统一小写，替换形近字符，只保留字母和数字
+func Skeleton(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFKD.String(s) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		r = unicode.ToLower(r)
+		if mapped, ok := confusables[r]; ok {
+			r = mapped
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// IsUsernameAllowed 判断用户名是否可以使用：规范化后与保留用户名相同的不能使用
+// 包含屏蔽词的用户名不直接拒绝（正常名称可能碰巧包含屏蔽词，如 Scunthorpe），由 CheckUsername 检出后交管理员审核
+func IsUsernameAllowed(username string) bool {
+	loadLists()
+	_, reserved := reservedNames[Skeleton(username)]
+	return !reserved
+}
+
+// CheckUsername 检查用户名，返回命中的屏蔽词，未命中时返回 nil
+func CheckUsername(username string) []string {
+	loadLists()
+	return blockedMatches(Skeleton(username))
+}
+
+// CheckDisplayName 检查显示名称，返回命中的保留名称和屏蔽词，未命中时返回 nil
+// 命中不代表一定违规（如正常词语中碰巧包含屏蔽词），应交由管理员审核
+func CheckDisplayName(name string) []string {
+	loadLists()
+	s := Skeleton(name)
+	if s == "" {
+		return nil
+	}
+
+	var matched []string
+	if term, ok := reservedNames[s]; ok {
+		matched = append(matched, term)
+	}
+	return append(matched, blockedMatches(s)...)
+}
+
+// blockedMatches 返回规范化名称中包含的屏蔽词
+func blockedMatches(skeleton string) []string {
+	if skeleton == "" {
+		return nil
+	}
+	var matched []string
+	for _, t := range blockedTerms {
+		if strings.Contains(skeleton, t.skeleton) {
+			matched = append(matched, t.term)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,100 @@
+package moderation
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"tidalcore-backend/config"
+)
+
+func TestMain(m *testing.M) {
+	if err := config.Load(""); err != nil {
+		panic(err)
+	}
+	if err := InitNameLists(); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func TestSkeleton(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Admin", "admin"},
+		{"ＡＤＭＩＮ", "admin"},              // 全角
+		{"Adm1n", "admin"},              // 数字形近
+		{"аdmin", "admin"},              // 西里尔字母 а
+		{"adm_in!", "admini"},           // 感叹号视为 i，下划线忽略
+		{"f.u.c.k", "fuck"},             // 标点忽略
+		{"Crème brûlée", "cremebruiee"}, // 去掉重音，l 视为 i
+		{"潮汐 核心", "潮汐核心"},
+		{"___", ""},
+	}
+	for _, tt := range tests {
+		if got := Skeleton(tt.in); got != tt.want {
+			t.Errorf("Skeleton(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestIsUsernameAllowed(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"admin", false},
+		{"Adm1n_", false},
+		{"ＲＯＯＴ", false},
+		{"tidalcore", false},
+		// 只拒绝与保留用户名完全相同的名称
+		{"admin_alice", true},
+		{"rootbeer", true},
+		// 包含屏蔽词的用户名不直接拒绝，交由审核
+		{"scunthorpe", true},
+		{"alice", true},
+	}
+	for _, tt := range tests {
+		if got := IsUsernameAllowed(tt.name); got != tt.want {
+			t.Errorf("IsUsernameAllowed(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCheckUsername(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"scunthorpe", []string{"cunt"}},
+		{"ShiTake", []string{"shit"}},
+		{"f_u_c_k_1", []string{"fuck"}},
+		{"alice", nil},
+		// 保留用户名由 IsUsernameAllowed 处理，不计入屏蔽词
+		{"admin", nil},
+	}
+	for _, tt := range tests {
+		if got := CheckUsername(tt.name); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("CheckUsername(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCheckDisplayName(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"Ａdmin", []string{"admin"}},
+		{"官方 客服", []string{"官方客服"}},
+		{"Scunthorpe United", []string{"cunt"}},
+		{"潮汐打卡人", nil},
+		{"!!!", nil},
+	}
+	for _, tt := range tests {
+		if got := CheckDisplayName(tt.name); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("CheckDisplayName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
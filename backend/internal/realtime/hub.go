@@ -0,0 +1,150 @@
+package realtime
+
+import (
+	"sync"
+	"time"
+)
+
+// 事件类型
+const (
+	EventCheckinStats = "checkin_stats" // 全站今日打卡人数
+	EventVisitStats   = "visit_stats"   // 今日访问人数
+	EventNotification = "notification"  // 用户站内通知
+)
+
+const (
+	historySize      = 256 // 保留最近事件用于断线重连补发
+	subscriberBuffer = 32  // 每个订阅者的缓冲区大小，写满后丢弃新事件
+)
+
+// Event 推送事件
+// UserID 为 0 表示广播给所有订阅者，否则只推送给该用户
+type Event struct {
+	ID        uint64      `json:"id"`
+	Type      string      `json:"type"`
+	UserID    uint        `json:"-"`
+	Data      interface{} `json:"data"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// visibleTo 判断事件是否对订阅者可见
+func (e *Event) visibleTo(userID uint) bool {
+	return e.UserID == 0 || e.UserID == userID
+}
+
+// Subscriber 事件订阅者
+type Subscriber struct {
+	UserID uint
+	Events chan *Event
+}
+
+// Hub 进程内发布/订阅中心
+type Hub struct {
+	mu          sync.RWMutex
+	nextID      uint64
+	subscribers map[*Subscriber]struct{}
+	history     []*Event
+}
+
+var (
+	defaultHub *Hub
+	once       sync.Once
+)
+
+// Default 获取全局 Hub
+func Default() *Hub {
+	once.Do(func() {
+		defaultHub = NewHub()
+	})
+	return defaultHub
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		// 以启动时间作为起始 ID，保证服务重启后事件 ID 仍单调递增
+		nextID:      uint64(time.Now().UnixMilli()),
+		subscribers: make(map[*Subscriber]struct{}),
+		history:     make([]*Event, 0, historySize),
+	}
+}
+
+// Publish 广播事件给所有订阅者
+func (h *Hub) Publish(eventType string, data interface{}) {
+	h.publish(&Event{Type: eventType, Data: data})
+}
+
+// PublishTo 推送事件给指定用户
+func (h *Hub) PublishTo(userID uint, eventType string, data interface{}) {
+	if userID == 0 {
+		return
+	}
+	h.publish(&Event{Type: eventType, UserID: userID, Data: data})
+}
+
+func (h *Hub) publish(event *Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.nextID++
+	event.ID = h.nextID
+	event.CreatedAt = time.Now()
+
+	if len(h.history) == historySize {
+		copy(h.history, h.history[1:])
+		h.history = h.history[:historySize-1]
+	}
+	h.history = append(h.history, event)
+
+	for sub := range h.subscribers {
+		if !event.visibleTo(sub.UserID) {
+			continue
+		}
+		select {
+		case sub.Events <- event:
+		default:
+			// 订阅者消费过慢，丢弃事件，客户端可通过 Last-Event-ID 重连补发
+		}
+	}
+}
+
+// Subscribe 注册订阅者，userID 为 0 表示匿名订阅（仅接收广播事件）
+// lastEventID 大于 0 时返回之后错过的事件用于补发
+func (h *Hub) Subscribe(userID uint, lastEventID uint64) (*Subscriber, []*Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sub := &Subscriber{
+		UserID: userID,
+		Events: make(chan *Event, subscriberBuffer),
+	}
+	h.subscribers[sub] = struct{}{}
+
+	var missed []*Event
+	if lastEventID > 0 {
+		for _, e := range h.history {
+			if e.ID > lastEventID && e.visibleTo(userID) {
+				missed = append(missed, e)
+			}
+		}
+	}
+	return sub, missed
+}
+
+// Unsubscribe 注销订阅者
+func (h *Hub) Unsubscribe(sub *Subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subscribers, sub)
+}
+
+// IsOnline 判断用户当前是否有活跃订阅
+func (h *Hub) IsOnline(userID uint) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for sub := range h.subscribers {
+		if sub.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
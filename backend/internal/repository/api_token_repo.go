@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/pkg/database"
+)
+
+type APITokenRepository struct {
+	db *gorm.DB
+}
+
+func NewAPITokenRepository() *APITokenRepository {
+	return &APITokenRepository{db: database.Get()}
+}
+
+// WithTx 返回在指定事务中执行的仓库
+func (r *APITokenRepository) WithTx(tx *gorm.DB) *APITokenRepository {
+	return &APITokenRepository{db: tx}
+}
+
+func (r *APITokenRepository) Create(token *model.APIToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *APITokenRepository) GetByHash(hash string) (*model.APIToken, error) {
+	var token model.APIToken
+	err := r.db.Where("token_hash = ?", hash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *APITokenRepository) GetByUserID(userID uint) ([]model.APIToken, error) {
+	var tokens []model.APIToken
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+func (r *APITokenRepository) CountByUserID(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.APIToken{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// DeleteByUser 删除用户的指定令牌，返回是否删除成功
+func (r *APITokenRepository) DeleteByUser(id, userID uint) (bool, error) {
+	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&model.APIToken{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteByUserID 删除用户的全部令牌
+func (r *APITokenRepository) DeleteByUserID(userID uint) error {
+	return r.db.Where("user_id = ?", userID).Delete(&model.APIToken{}).Error
+}
+
+// TouchLastUsed 记录令牌最近使用的时间和 IP
+func (r *APITokenRepository) TouchLastUsed(id uint, ip string, t time.Time) error {
+	return r.db.Model(&model.APIToken{}).Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"last_used_at": t,
+			"last_used_ip": ip,
+		}).Error
+}
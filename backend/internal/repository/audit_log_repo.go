@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/pkg/database"
+)
+
+type AuditLogRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditLogRepository() *AuditLogRepository {
+	return &AuditLogRepository{db: database.Get()}
+}
+
+// WithTx 返回在指定事务中执行的仓库
+func (r *AuditLogRepository) WithTx(tx *gorm.DB) *AuditLogRepository {
+	return &AuditLogRepository{db: tx}
+}
+
+// Transaction 开启事务，审计记录与所记录的变更在同一事务中写入
+func (r *AuditLogRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+func (r *AuditLogRepository) Create(entry *model.AuditLog) error {
+	return r.db.Create(entry).Error
+}
+
+// AuditLogFilter 审计记录筛选条件，零值表示不限
+type AuditLogFilter struct {
+	ActorID    *uint
+	Action     string // 完整动作名（如 user.delete），或不含点的分类（如 user）匹配该分类下全部动作
+	TargetType string
+	TargetID   string
+	From       *time.Time
+	To         *time.Time // 不含
+}
+
+func (f *AuditLogFilter) apply(db *gorm.DB) *gorm.DB {
+	if f.ActorID != nil {
+		db = db.Where("actor_id = ?", *f.ActorID)
+	}
+	if f.Action != "" {
+		db = db.Where("action = ? OR action LIKE ?", f.Action, f.Action+".%")
+	}
+	if f.TargetType != "" {
+		db = db.Where("target_type = ?", f.TargetType)
+	}
+	if f.TargetID != "" {
+		db = db.Where("target_id = ?", f.TargetID)
+	}
+	if f.From != nil {
+		db = db.Where("created_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		db = db.Where("created_at < ?", *f.To)
+	}
+	return db
+}
+
+// List 按条件分页获取审计记录，最新的在前
+func (r *AuditLogRepository) List(filter *AuditLogFilter, page, pageSize int) ([]model.AuditLog, int64, error) {
+	var logs []model.AuditLog
+	var total int64
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	if err := filter.apply(r.db.Model(&model.AuditLog{})).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := filter.apply(r.db).Order("id DESC").
+		Offset((page - 1) * pageSize).Limit(pageSize).Find(&logs).Error
+	return logs, total, err
+}
+
+// ListForExport 按条件获取最新的 limit 条审计记录用于导出
+func (r *AuditLogRepository) ListForExport(filter *AuditLogFilter, limit int) ([]model.AuditLog, error) {
+	var logs []model.AuditLog
+	err := filter.apply(r.db).Order("id DESC").Limit(limit).Find(&logs).Error
+	return logs, err
+}
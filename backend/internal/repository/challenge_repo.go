@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/pkg/database"
+)
+
+type ChallengeRepository struct {
+	db *gorm.DB
+}
+
+func NewChallengeRepository() *ChallengeRepository {
+	return &ChallengeRepository{db: database.Get()}
+}
+
+// WithTx 返回在指定事务中执行的仓库
+func (r *ChallengeRepository) WithTx(tx *gorm.DB) *ChallengeRepository {
+	return &ChallengeRepository{db: tx}
+}
+
+func (r *ChallengeRepository) Create(challenge *model.Challenge) error {
+	return r.db.Create(challenge).Error
+}
+
+func (r *ChallengeRepository) GetByID(id uint) (*model.Challenge, error) {
+	var challenge model.Challenge
+	err := r.db.First(&challenge, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+func (r *ChallengeRepository) Update(challenge *model.Challenge) error {
+	return r.db.Save(challenge).Error
+}
+
+// Delete 删除挑战（软删除）
+func (r *ChallengeRepository) Delete(id uint) error {
+	return r.db.Delete(&model.Challenge{}, id).Error
+}
+
+// List 按状态获取挑战列表
+// status: active 进行中, upcoming 未开始, ended 已结束, 其他值返回全部
+func (r *ChallengeRepository) List(status, today string) ([]model.Challenge, error) {
+	var challenges []model.Challenge
+	query := r.db.Model(&model.Challenge{})
+	switch status {
+	case "active":
+		query = query.Where("start_date <= ? AND end_date >= ?", today, today)
+	case "upcoming":
+		query = query.Where("start_date > ?", today)
+	case "ended":
+		query = query.Where("end_date < ?", today)
+	}
+	err := query.Order("start_date DESC").Find(&challenges).Error
+	return challenges, err
+}
+
+func (r *ChallengeRepository) AddParticipant(p *model.ChallengeParticipant) error {
+	return r.db.Create(p).Error
+}
+
+func (r *ChallengeRepository) RemoveParticipant(challengeID, userID uint) error {
+	return r.db.Where("challenge_id = ? AND user_id = ?", challengeID, userID).
+		Delete(&model.ChallengeParticipant{}).Error
+}
+
+func (r *ChallengeRepository) GetParticipant(challengeID, userID uint) (*model.ChallengeParticipant, error) {
+	var p model.ChallengeParticipant
+	err := r.db.Where("challenge_id = ? AND user_id = ?", challengeID, userID).First(&p).Error
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetParticipants 获取挑战的所有参与者（排除已删除的用户），结算时封禁中的用户同样计入
+func (r *ChallengeRepository) GetParticipants(challengeID uint) ([]model.ChallengeParticipant, error) {
+	var participants []model.ChallengeParticipant
+	err := r.participants(challengeID).Find(&participants).Error
+	return participants, err
+}
+
+// GetVisibleParticipants 获取用于展示的参与者，排除已删除和封禁中的用户
+func (r *ChallengeRepository) GetVisibleParticipants(challengeID uint) ([]model.ChallengeParticipant, error) {
+	var participants []model.ChallengeParticipant
+	err := r.participants(challengeID).Scopes(notSuspended(time.Now())).Find(&participants).Error
+	return participants, err
+}
+
+func (r *ChallengeRepository) participants(challengeID uint) *gorm.DB {
+	return r.db.Joins("JOIN users ON users.id = challenge_participants.user_id AND users.deleted_at IS NULL").
+		Where("challenge_participants.challenge_id = ?", challengeID).
+		Order("challenge_participants.created_at ASC")
+}
+
+func (r *ChallengeRepository) CountParticipants(challengeID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.ChallengeParticipant{}).
+		Where("challenge_id = ?", challengeID).
+		Count(&count).Error
+	return count, err
+}
+
+// GetJoinedChallengeIDs 获取用户在给定挑战中已参与的挑战 ID
+func (r *ChallengeRepository) GetJoinedChallengeIDs(userID uint, challengeIDs []uint) (map[uint]bool, error) {
+	joined := make(map[uint]bool)
+	if len(challengeIDs) == 0 {
+		return joined, nil
+	}
+
+	var ids []uint
+	err := r.db.Model(&model.ChallengeParticipant{}).
+		Where("user_id = ? AND challenge_id IN ?", userID, challengeIDs).
+		Pluck("challenge_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		joined[id] = true
+	}
+	return joined, nil
+}
+
+// SaveResults 保存最终排名并标记挑战已结算
+// 只有尚未结算时才写入，并发结算时只有一方成功，saved 为 false 表示已由其他请求结算
+func (r *ChallengeRepository) SaveResults(challenge *model.Challenge, participants []model.ChallengeParticipant) (saved bool, err error) {
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Challenge{}).
+			Where("id = ? AND finalized_at IS NULL", challenge.ID).
+			Update("finalized_at", challenge.FinalizedAt)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		for i := range participants {
+			p := &participants[i]
+			err := tx.Model(&model.ChallengeParticipant{}).
+				Where("id = ?", p.ID).
+				Updates(map[string]interface{}{
+					"progress":     p.Progress,
+					"completed_at": p.CompletedAt,
+					"finish_rank":  p.Rank,
+				}).Error
+			if err != nil {
+				return err
+			}
+		}
+		saved = true
+		return nil
+	})
+	return saved, err
+}
@@ -38,6 +38,18 @@ func (r *CheckinRepository) GetByUserIDAndDateRange(userID uint, start, end time
 	return checkins, err
 }
 
+// GetByUserIDsAndDateRange 批量获取多个用户在时间范围内的打卡记录
+func (r *CheckinRepository) GetByUserIDsAndDateRange(userIDs []uint, start, end time.Time) ([]model.Checkin, error) {
+	var checkins []model.Checkin
+	if len(userIDs) == 0 {
+		return checkins, nil
+	}
+	err := r.db.Where("user_id IN ? AND checked_at >= ? AND checked_at < ?", userIDs, start, end).
+		Order("checked_at ASC").
+		Find(&checkins).Error
+	return checkins, err
+}
+
 func (r *CheckinRepository) HasCheckedToday(userID uint, loc *time.Location) (bool, error) {
 	now := time.Now().In(loc)
 	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
@@ -50,26 +62,57 @@ func (r *CheckinRepository) HasCheckedToday(userID uint, loc *time.Location) (bo
 	return count > 0, err
 }
 
+// CountTodayUsers 统计今日打卡人数
+func (r *CheckinRepository) CountTodayUsers(loc *time.Location) (int64, error) {
+	now := time.Now().In(loc)
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	var count int64
+	err := r.db.Model(&model.Checkin{}).
+		Where("checked_at >= ?", startOfDay).
+		Distinct("user_id").
+		Count(&count).Error
+	return count, err
+}
+
+// GetGlobalHeatmap 获取全站热力图（每日打卡人数）
 func (r *CheckinRepository) GetGlobalHeatmap(days int, loc *time.Location) (map[string]int, error) {
-	startDate := time.Now().In(loc).AddDate(0, 0, -days)
+	return r.dailyUsers(r.db.Model(&model.Checkin{}), days, loc)
+}
 
-	type Result struct {
+// GetGroupHeatmap 获取小组热力图（每日打卡成员数）
+func (r *CheckinRepository) GetGroupHeatmap(groupID uint, days int, loc *time.Location) (map[string]int, error) {
+	query := r.db.Model(&model.Checkin{}).
+		Joins("JOIN group_members ON group_members.user_id = checkins.user_id").
+		Where("group_members.group_id = ?", groupID)
+	return r.dailyUsers(query, days, loc)
+}
+
+// dailyUsers 统计今天及之前 days 天每天打卡的人数，排除已删除和封禁中的用户
+// 按 loc 划分日期：数据库按服务进程时区保存时间，与配置的时区可能不同，因此日期边界由 dayRanges 给出
+func (r *CheckinRepository) dailyUsers(query *gorm.DB, days int, loc *time.Location) (map[string]int, error) {
+	now := time.Now()
+	local := now.In(loc)
+	end := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1)
+	start := end.AddDate(0, 0, -(days + 1))
+
+	var results []struct {
 		Date  string
 		Count int
 	}
-	var results []Result
-
-	err := r.db.Model(&model.Checkin{}).
-		Select("DATE(checked_at) as date, COUNT(DISTINCT user_id) as count").
-		Where("checked_at >= ?", startDate).
-		Group("DATE(checked_at)").
+	err := query.
+		Select("days.day AS date, COUNT(DISTINCT checkins.user_id) AS count").
+		Joins("JOIN users ON users.id = checkins.user_id AND users.deleted_at IS NULL").
+		Joins("JOIN (?) AS days ON checkins.checked_at >= days.day_start AND checkins.checked_at < days.day_end", dayRanges(r.db, start, end)).
+		Scopes(notSuspended(now)).
+		Where("checkins.checked_at >= ? AND checkins.checked_at < ?", start, end).
+		Group("days.day").
 		Scan(&results).Error
-
 	if err != nil {
 		return nil, err
 	}
 
-	heatmap := make(map[string]int)
+	heatmap := make(map[string]int, len(results))
 	for _, r := range results {
 		heatmap[r.Date] = r.Count
 	}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/pkg/database"
+)
+
+type CheerRepository struct {
+	db *gorm.DB
+}
+
+func NewCheerRepository() *CheerRepository {
+	return &CheerRepository{db: database.Get()}
+}
+
+func (r *CheerRepository) Create(cheer *model.Cheer) error {
+	return r.db.Create(cheer).Error
+}
+
+// CountSentOnDate 统计用户某天发出的鼓励数
+func (r *CheerRepository) CountSentOnDate(senderID uint, date string) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.Cheer{}).
+		Where("sender_id = ? AND sent_date = ?", senderID, date).
+		Count(&count).Error
+	return count, err
+}
+
+// ExistsOnDate 检查某天是否已给该用户发送过鼓励
+func (r *CheerRepository) ExistsOnDate(senderID, receiverID uint, date string) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.Cheer{}).
+		Where("sender_id = ? AND receiver_id = ? AND sent_date = ?", senderID, receiverID, date).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ReceivedCheer 收到的鼓励及发送者信息
+type ReceivedCheer struct {
+	ID                uint      `json:"id"`
+	SenderID          uint      `json:"sender_id"`
+	SenderUsername    string    `json:"sender_username"`
+	SenderDisplayName string    `json:"sender_display_name"`
+	Kind              string    `json:"kind"`
+	SentDate          string    `json:"sent_date"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// GetReceived 获取用户收到的鼓励（排除已删除的发送者）
+func (r *CheerRepository) GetReceived(receiverID uint, limit int) ([]ReceivedCheer, error) {
+	var cheers []ReceivedCheer
+	err := r.db.Model(&model.Cheer{}).
+		Select("cheers.id, cheers.sender_id, users.username as sender_username, users.display_name as sender_display_name, cheers.kind, cheers.sent_date, cheers.created_at").
+		Joins("JOIN users ON users.id = cheers.sender_id AND users.deleted_at IS NULL").
+		Where("cheers.receiver_id = ?", receiverID).
+		Order("cheers.created_at DESC").
+		Limit(limit).
+		Scan(&cheers).Error
+	return cheers, err
+}
+
+// CountReceivedByKind 按类型统计用户收到的鼓励数
+func (r *CheerRepository) CountReceivedByKind(receiverID uint) (map[string]int64, error) {
+	type Result struct {
+		Kind  string
+		Count int64
+	}
+	var results []Result
+
+	err := r.db.Model(&model.Cheer{}).
+		Select("kind, COUNT(*) as count").
+		Where("receiver_id = ?", receiverID).
+		Group("kind").
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64)
+	for _, r := range results {
+		counts[r.Kind] = r.Count
+	}
+	return counts, nil
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/pkg/database"
+)
+
+type GroupRepository struct {
+	db *gorm.DB
+}
+
+func NewGroupRepository() *GroupRepository {
+	return &GroupRepository{db: database.Get()}
+}
+
+// CreateWithOwner 创建小组并将创建者加入为组长
+func (r *GroupRepository) CreateWithOwner(group *model.Group) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(group).Error; err != nil {
+			return err
+		}
+		member := &model.GroupMember{
+			GroupID: group.ID,
+			UserID:  group.OwnerID,
+			Role:    model.GroupRoleOwner,
+		}
+		return tx.Create(member).Error
+	})
+}
+
+func (r *GroupRepository) GetByID(id uint) (*model.Group, error) {
+	var group model.Group
+	err := r.db.First(&group, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (r *GroupRepository) GetByInviteCode(code string) (*model.Group, error) {
+	var group model.Group
+	err := r.db.Where("invite_code = ?", code).First(&group).Error
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (r *GroupRepository) Update(group *model.Group) error {
+	return r.db.Save(group).Error
+}
+
+// Delete 解散小组，同时移除所有成员关系
+func (r *GroupRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ?", id).Delete(&model.GroupMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Group{}, id).Error
+	})
+}
+
+// GetByUserID 获取用户加入的所有小组
+func (r *GroupRepository) GetByUserID(userID uint) ([]model.Group, error) {
+	var groups []model.Group
+	err := r.db.Joins("JOIN group_members ON group_members.group_id = user_groups.id").
+		Where("group_members.user_id = ?", userID).
+		Order("group_members.created_at ASC").
+		Find(&groups).Error
+	return groups, err
+}
+
+// CountByUserID 统计用户加入的小组数量
+func (r *GroupRepository) CountByUserID(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.GroupMember{}).
+		Joins("JOIN user_groups ON user_groups.id = group_members.group_id AND user_groups.deleted_at IS NULL").
+		Where("group_members.user_id = ?", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *GroupRepository) AddMember(member *model.GroupMember) error {
+	return r.db.Create(member).Error
+}
+
+func (r *GroupRepository) RemoveMember(groupID, userID uint) error {
+	return r.db.Where("group_id = ? AND user_id = ?", groupID, userID).Delete(&model.GroupMember{}).Error
+}
+
+func (r *GroupRepository) GetMember(groupID, userID uint) (*model.GroupMember, error) {
+	var member model.GroupMember
+	err := r.db.Where("group_id = ? AND user_id = ?", groupID, userID).First(&member).Error
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *GroupRepository) CountMembers(groupID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.GroupMember{}).Where("group_id = ?", groupID).Count(&count).Error
+	return count, err
+}
+
+// CountMembersByGroupIDs 一次查询统计多个小组的成员数
+func (r *GroupRepository) CountMembersByGroupIDs(groupIDs []uint) (map[uint]int64, error) {
+	counts := make(map[uint]int64, len(groupIDs))
+	if len(groupIDs) == 0 {
+		return counts, nil
+	}
+
+	var results []struct {
+		GroupID uint
+		Count   int64
+	}
+	err := r.db.Model(&model.GroupMember{}).
+		Select("group_id, COUNT(*) AS count").
+		Where("group_id IN ?", groupIDs).
+		Group("group_id").
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, res := range results {
+		counts[res.GroupID] = res.Count
+	}
+	return counts, nil
+}
+
+// GroupMemberInfo 小组成员及其用户信息
+type GroupMemberInfo struct {
+	UserID      uint      `json:"user_id"`
+	Username    string    `json:"username"`
+	DisplayName string    `json:"display_name"`
+	Role        string    `json:"role"`
+	Streak      int       `json:"streak"`
+	JoinedAt    time.Time `json:"joined_at"`
+}
+
+// GetMembers 获取小组成员列表（排除已删除用户）
+func (r *GroupRepository) GetMembers(groupID uint) ([]GroupMemberInfo, error) {
+	var members []GroupMemberInfo
+	err := r.db.Model(&model.GroupMember{}).
+		Select("group_members.user_id, users.username, users.display_name, group_members.role, users.streak, group_members.created_at as joined_at").
+		Joins("JOIN users ON users.id = group_members.user_id AND users.deleted_at IS NULL").
+		Where("group_members.group_id = ?", groupID).
+		Order("group_members.created_at ASC").
+		Scan(&members).Error
+	return members, err
+}
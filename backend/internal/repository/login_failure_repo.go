@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/pkg/database"
+)
+
+type LoginFailureRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginFailureRepository() *LoginFailureRepository {
+	return &LoginFailureRepository{db: database.Get()}
+}
+
+// WithTx 返回在指定事务中执行的仓库
+func (r *LoginFailureRepository) WithTx(tx *gorm.DB) *LoginFailureRepository {
+	return &LoginFailureRepository{db: tx}
+}
+
+func (r *LoginFailureRepository) GetByUsername(username string) (*model.LoginFailure, error) {
+	var failure model.LoginFailure
+	err := r.db.Where("username = ?", username).First(&failure).Error
+	if err != nil {
+		return nil, err
+	}
+	return &failure, nil
+}
+
+// Update 在行锁下读取并修改用户名的失败记录，记录不存在时先创建
+// 同一用户名的并发失败依次执行，不会丢失计数或在唯一索引上冲突
+func (r *LoginFailureRepository) Update(username string, update func(failure *model.LoginFailure)) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&model.LoginFailure{Username: username}).Error
+		if err != nil {
+			return err
+		}
+
+		var failure model.LoginFailure
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("username = ?", username).
+			First(&failure).Error
+		if err != nil {
+			return err
+		}
+
+		update(&failure)
+		return tx.Save(&failure).Error
+	})
+}
+
+func (r *LoginFailureRepository) DeleteByUsername(username string) error {
+	return r.db.Where("username = ?", username).Delete(&model.LoginFailure{}).Error
+}
+
+// DeleteStale 删除 before 之后没有再失败、且已不在锁定中的记录，返回删除数量
+func (r *LoginFailureRepository) DeleteStale(before, now time.Time) (int64, error) {
+	result := r.db.Where("updated_at < ? AND (locked_until IS NULL OR locked_until <= ?)", before, now).
+		Delete(&model.LoginFailure{})
+	return result.RowsAffected, result.Error
+}
+
+// GetLocked 获取当前处于锁定状态的记录（分页）
+func (r *LoginFailureRepository) GetLocked(now time.Time, page, pageSize int) ([]model.LoginFailure, int64, error) {
+	var failures []model.LoginFailure
+	var total int64
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	query := r.db.Model(&model.LoginFailure{}).Where("locked_until > ?", now)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("locked_until DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&failures).Error
+	return failures, total, err
+}
+
+// GetRecent 获取最近有失败记录的用户名（分页）
+func (r *LoginFailureRepository) GetRecent(page, pageSize int) ([]model.LoginFailure, int64, error) {
+	var failures []model.LoginFailure
+	var total int64
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	query := r.db.Model(&model.LoginFailure{}).Where("failed_count > 0 OR locked_until IS NOT NULL")
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("last_failed_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&failures).Error
+	return failures, total, err
+}
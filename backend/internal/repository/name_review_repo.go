@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/pkg/database"
+)
+
+type NameReviewRepository struct {
+	db *gorm.DB
+}
+
+func NewNameReviewRepository() *NameReviewRepository {
+	return &NameReviewRepository{db: database.Get()}
+}
+
+// WithTx 返回在指定事务中执行的仓库
+func (r *NameReviewRepository) WithTx(tx *gorm.DB) *NameReviewRepository {
+	return &NameReviewRepository{db: tx}
+}
+
+// CreateReplacing 提交待审核的名称，并取消该用户之前同类名称未处理的申请
+func (r *NameReviewRepository) CreateReplacing(review *model.NameReview) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := cancelPending(tx, review.UserID, review.Field); err != nil {
+			return err
+		}
+		return tx.Create(review).Error
+	})
+}
+
+// CancelPending 取消用户某类名称未处理的申请，用户改用了无需审核的名称时调用
+func (r *NameReviewRepository) CancelPending(userID uint, field string) error {
+	return cancelPending(r.db, userID, field)
+}
+
+func cancelPending(db *gorm.DB, userID uint, field string) error {
+	return db.Model(&model.NameReview{}).
+		Where("user_id = ? AND field = ? AND status = ?", userID, field, model.NameReviewPending).
+		Update("status", model.NameReviewCancelled).Error
+}
+
+func (r *NameReviewRepository) GetByID(id uint) (*model.NameReview, error) {
+	var review model.NameReview
+	if err := r.db.First(&review, id).Error; err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// GetPendingByUserID 获取用户某类名称待审核的申请
+func (r *NameReviewRepository) GetPendingByUserID(userID uint, field string) (*model.NameReview, error) {
+	var review model.NameReview
+	err := r.db.Where("user_id = ? AND field = ? AND status = ?", userID, field, model.NameReviewPending).
+		Order("created_at DESC").
+		First(&review).Error
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// List 按状态分页获取审核记录，待审核的按提交时间先后排列，其余按最近处理排列
+func (r *NameReviewRepository) List(status string, page, pageSize int) ([]model.NameReview, int64, error) {
+	var reviews []model.NameReview
+	var total int64
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	if err := r.db.Model(&model.NameReview{}).Where("status = ?", status).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order := "created_at ASC"
+	if status != model.NameReviewPending {
+		order = "reviewed_at DESC, id DESC"
+	}
+	err := r.db.Where("status = ?", status).
+		Order(order).
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&reviews).Error
+	return reviews, total, err
+}
+
+// CountPending 统计待审核数量
+func (r *NameReviewRepository) CountPending() (int64, error) {
+	var count int64
+	err := r.db.Model(&model.NameReview{}).Where("status = ?", model.NameReviewPending).Count(&count).Error
+	return count, err
+}
+
+// Approve 通过审核，显示名称的申请同时将用户的显示名称改为申请的名称
+// 用户名在提交时已经生效，通过时无需修改；仅当申请仍待审核时生效，返回 false 表示已被处理或取消
+func (r *NameReviewRepository) Approve(review *model.NameReview, adminID uint) (bool, error) {
+	approved := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.NameReview{}).
+			Where("id = ? AND status = ?", review.ID, model.NameReviewPending).
+			Updates(map[string]interface{}{
+				"status":      model.NameReviewApproved,
+				"reviewed_by": adminID,
+				"reviewed_at": time.Now(),
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		approved = true
+		if review.Field == model.NameFieldUsername {
+			return nil
+		}
+		return tx.Model(&model.User{}).Where("id = ?", review.UserID).
+			UpdateColumn("display_name", review.DisplayName).Error
+	})
+	return approved && err == nil, err
+}
+
+// Reject 驳回申请，用户的名称保持不变
+// 仅当申请仍待审核时生效，返回 false 表示已被处理或取消
+func (r *NameReviewRepository) Reject(id, adminID uint, reason string) (bool, error) {
+	result := r.db.Model(&model.NameReview{}).
+		Where("id = ? AND status = ?", id, model.NameReviewPending).
+		Updates(map[string]interface{}{
+			"status":        model.NameReviewRejected,
+			"reviewed_by":   adminID,
+			"reviewed_at":   time.Now(),
+			"reject_reason": reason,
+		})
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/pkg/database"
+)
+
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository() *NotificationRepository {
+	return &NotificationRepository{db: database.Get()}
+}
+
+// WithTx 返回在指定事务中执行的仓库
+func (r *NotificationRepository) WithTx(tx *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{db: tx}
+}
+
+func (r *NotificationRepository) Create(n *model.Notification) error {
+	return r.db.Create(n).Error
+}
+
+// CreateBatch 批量创建通知
+func (r *NotificationRepository) CreateBatch(notifications []model.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	return r.db.CreateInBatches(notifications, 500).Error
+}
+
+// GetByUserID 获取用户通知（按时间倒序）
+func (r *NotificationRepository) GetByUserID(userID uint, unreadOnly bool, limit int) ([]model.Notification, error) {
+	var notifications []model.Notification
+	query := r.db.Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	err := query.Order("created_at DESC").Limit(limit).Find(&notifications).Error
+	return notifications, err
+}
+
+// CountUnread 统计未读通知数
+func (r *NotificationRepository) CountUnread(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// MarkRead 标记单条通知为已读，返回受影响行数
+func (r *NotificationRepository) MarkRead(userID, id uint) (int64, error) {
+	result := r.db.Model(&model.Notification{}).
+		Where("id = ? AND user_id = ? AND read_at IS NULL", id, userID).
+		Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// MarkAllRead 标记用户所有通知为已读
+func (r *NotificationRepository) MarkAllRead(userID uint) (int64, error) {
+	result := r.db.Model(&model.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// Exists 检查通知是否属于该用户
+func (r *NotificationRepository) Exists(userID, id uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ExistsSince 检查某时间之后是否已发送过该类型的通知
+func (r *NotificationRepository) ExistsSince(userID uint, notificationType string, since time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.Notification{}).
+		Where("user_id = ? AND type = ? AND created_at >= ?", userID, notificationType, since).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/pkg/database"
+)
+
+type PasskeyRepository struct {
+	db *gorm.DB
+}
+
+func NewPasskeyRepository() *PasskeyRepository {
+	return &PasskeyRepository{db: database.Get()}
+}
+
+// WithTx 返回在指定事务中执行的仓库
+func (r *PasskeyRepository) WithTx(tx *gorm.DB) *PasskeyRepository {
+	return &PasskeyRepository{db: tx}
+}
+
+func (r *PasskeyRepository) Create(credential *model.PasskeyCredential) error {
+	return r.db.Create(credential).Error
+}
+
+func (r *PasskeyRepository) GetByUserID(userID uint) ([]model.PasskeyCredential, error) {
+	var credentials []model.PasskeyCredential
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&credentials).Error
+	return credentials, err
+}
+
+func (r *PasskeyRepository) GetByUserHandle(handle string) ([]model.PasskeyCredential, error) {
+	var credentials []model.PasskeyCredential
+	err := r.db.Where("user_handle = ?", handle).Find(&credentials).Error
+	return credentials, err
+}
+
+func (r *PasskeyRepository) CountByUserID(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.PasskeyCredential{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// GetByUser 获取用户的指定通行密钥
+func (r *PasskeyRepository) GetByUser(id, userID uint) (*model.PasskeyCredential, error) {
+	var credential model.PasskeyCredential
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&credential).Error
+	if err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+func (r *PasskeyRepository) UpdateName(id uint, name string) error {
+	return r.db.Model(&model.PasskeyCredential{}).Where("id = ?", id).UpdateColumn("name", name).Error
+}
+
+// DeleteByUser 删除用户的指定通行密钥，返回是否删除成功
+func (r *PasskeyRepository) DeleteByUser(id, userID uint) (bool, error) {
+	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&model.PasskeyCredential{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// RecordUse 登录成功后更新签名计数和最近使用时间
+func (r *PasskeyRepository) RecordUse(id uint, signCount uint32, t time.Time) error {
+	return r.db.Model(&model.PasskeyCredential{}).Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"sign_count":   signCount,
+			"last_used_at": t,
+		}).Error
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/pkg/database"
+)
+
+type PasswordRecoveryRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordRecoveryRepository() *PasswordRecoveryRepository {
+	return &PasswordRecoveryRepository{db: database.Get()}
+}
+
+// WithTx 返回在指定事务中执行的仓库
+func (r *PasswordRecoveryRepository) WithTx(tx *gorm.DB) *PasswordRecoveryRepository {
+	return &PasswordRecoveryRepository{db: tx}
+}
+
+// Replace 用新的一组恢复码替换用户现有的恢复码
+func (r *PasswordRecoveryRepository) Replace(userID uint, hashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.PasswordRecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]model.PasswordRecoveryCode, 0, len(hashes))
+		for _, h := range hashes {
+			codes = append(codes, model.PasswordRecoveryCode{UserID: userID, CodeHash: h})
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// IsUsable 判断恢复码是否存在且未被使用
+func (r *PasswordRecoveryRepository) IsUsable(userID uint, hash string) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.PasswordRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Consume 使用一个恢复码，返回 false 表示恢复码不存在或已被使用
+func (r *PasswordRecoveryRepository) Consume(userID uint, hash string) (bool, error) {
+	result := r.db.Model(&model.PasswordRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetStatus 统计用户剩余可用的恢复码数量及生成时间
+func (r *PasswordRecoveryRepository) GetStatus(userID uint) (int64, *time.Time, error) {
+	var result struct {
+		Remaining   int64
+		GeneratedAt *time.Time
+	}
+	err := r.db.Model(&model.PasswordRecoveryCode{}).
+		Select("COALESCE(SUM(CASE WHEN used_at IS NULL THEN 1 ELSE 0 END), 0) AS remaining, MIN(created_at) AS generated_at").
+		Where("user_id = ?", userID).
+		Scan(&result).Error
+	return result.Remaining, result.GeneratedAt, err
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/pkg/database"
+)
+
+type PasswordResetRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetRepository() *PasswordResetRepository {
+	return &PasswordResetRepository{db: database.Get()}
+}
+
+// WithTx 返回在指定事务中执行的仓库
+func (r *PasswordResetRepository) WithTx(tx *gorm.DB) *PasswordResetRepository {
+	return &PasswordResetRepository{db: tx}
+}
+
+// CreateReplacing 创建重置码，并作废该用户尚未使用的旧重置码
+func (r *PasswordResetRepository) CreateReplacing(reset *model.PasswordReset) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.PasswordReset{}).
+			Where("user_id = ? AND used_at IS NULL AND revoked_at IS NULL", reset.UserID).
+			Update("revoked_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(reset).Error
+	})
+}
+
+// GetActiveByUserID 获取用户当前有效的重置码
+func (r *PasswordResetRepository) GetActiveByUserID(userID uint, now time.Time) (*model.PasswordReset, error) {
+	var reset model.PasswordReset
+	err := r.db.Where("user_id = ? AND used_at IS NULL AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("created_at DESC").
+		First(&reset).Error
+	if err != nil {
+		return nil, err
+	}
+	return &reset, nil
+}
+
+// RecordFailedAttempt 记录一次输错，达到上限时作废重置码
+func (r *PasswordResetRepository) RecordFailedAttempt(id uint, maxAttempts int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.PasswordReset{}).Where("id = ?", id).
+			UpdateColumn("attempts", gorm.Expr("attempts + 1")).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&model.PasswordReset{}).
+			Where("id = ? AND attempts >= ? AND revoked_at IS NULL", id, maxAttempts).
+			UpdateColumn("revoked_at", time.Now()).Error
+	})
+}
+
+// MarkUsed 标记重置码已使用，仅当其仍有效时生效
+// 返回 false 表示重置码已被并发使用或作废
+func (r *PasswordResetRepository) MarkUsed(id uint, ip string) (bool, error) {
+	result := r.db.Model(&model.PasswordReset{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"used_at": time.Now(),
+			"used_ip": ip,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetByUserID 获取用户的重置码记录
+func (r *PasswordResetRepository) GetByUserID(userID uint, limit int) ([]model.PasswordReset, error) {
+	var resets []model.PasswordReset
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Limit(limit).Find(&resets).Error
+	return resets, err
+}
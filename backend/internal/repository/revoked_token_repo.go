@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/pkg/database"
+)
+
+type RevokedTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRevokedTokenRepository() *RevokedTokenRepository {
+	return &RevokedTokenRepository{db: database.Get()}
+}
+
+// Create 记录吊销的令牌，重复吊销时忽略
+func (r *RevokedTokenRepository) Create(token *model.RevokedToken) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(token).Error
+}
+
+// GetActive 获取尚未过期的吊销记录
+func (r *RevokedTokenRepository) GetActive(now time.Time) ([]model.RevokedToken, error) {
+	var tokens []model.RevokedToken
+	err := r.db.Where("expires_at > ?", now).Find(&tokens).Error
+	return tokens, err
+}
+
+// DeleteExpired 删除令牌已过期的吊销记录
+func (r *RevokedTokenRepository) DeleteExpired(before time.Time) (int64, error) {
+	result := r.db.Where("expires_at < ?", before).Delete(&model.RevokedToken{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/pkg/database"
+)
+
+type SessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository() *SessionRepository {
+	return &SessionRepository{db: database.Get()}
+}
+
+// WithTx 返回在指定事务中执行的仓库
+func (r *SessionRepository) WithTx(tx *gorm.DB) *SessionRepository {
+	return &SessionRepository{db: tx}
+}
+
+func (r *SessionRepository) Create(session *model.Session) error {
+	return r.db.Create(session).Error
+}
+
+func (r *SessionRepository) GetByID(id uint) (*model.Session, error) {
+	var session model.Session
+	err := r.db.First(&session, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *SessionRepository) GetByFamilyID(familyID string) (*model.Session, error) {
+	var session model.Session
+	err := r.db.Where("family_id = ?", familyID).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Touch 更新会话最后活跃时间及客户端信息
+func (r *SessionRepository) Touch(id uint, ip, userAgent string) error {
+	return r.db.Model(&model.Session{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"last_seen_at": time.Now(),
+		"ip":           ip,
+		"user_agent":   userAgent,
+	}).Error
+}
+
+// GetActiveByUserID 获取用户未吊销且未过期的会话
+func (r *SessionRepository) GetActiveByUserID(userID uint, since time.Time) ([]model.Session, error) {
+	var sessions []model.Session
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND last_seen_at >= ?", userID, since).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// GetActiveIDsByUserID 获取用户未吊销会话的 ID
+func (r *SessionRepository) GetActiveIDsByUserID(userID uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&model.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// Revoke 吊销会话
+func (r *SessionRepository) Revoke(ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Model(&model.Session{}).
+		Where("id IN ? AND revoked_at IS NULL", ids).
+		Update("revoked_at", time.Now()).Error
+}
+
+// GetRevokedSince 获取某时间之后被吊销的会话
+func (r *SessionRepository) GetRevokedSince(since time.Time) ([]model.Session, error) {
+	var sessions []model.Session
+	err := r.db.Where("revoked_at >= ?", since).Find(&sessions).Error
+	return sessions, err
+}
+
+// DeleteInactive 删除长期未活跃或已吊销的会话
+func (r *SessionRepository) DeleteInactive(before time.Time) (int64, error) {
+	result := r.db.Where("last_seen_at < ? OR revoked_at < ?", before, before).Delete(&model.Session{})
+	return result.RowsAffected, result.Error
+}
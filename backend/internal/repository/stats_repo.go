@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/pkg/database"
+)
+
+// StatsRepository 管理后台统计，跨 users、checkins、visits 的聚合查询
+type StatsRepository struct {
+	db *gorm.DB
+}
+
+func NewStatsRepository() *StatsRepository {
+	return &StatsRepository{db: database.Get()}
+}
+
+// dayRanges 以绑定参数构造 [start, end) 内逐日的时间区间子查询，列为 day、day_start、day_end
+// start 为配置时区的零点，日期边界在这里按该时区计算，不依赖数据库时区和各方言的日期函数
+// offsets 为留存统计追加注册后第 N 天的区间列 dN_start、dN_end
+func dayRanges(db *gorm.DB, start, end time.Time, offsets ...int) *gorm.DB {
+	var sb strings.Builder
+	var args []interface{}
+	for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+		next := d.AddDate(0, 0, 1)
+		if len(args) == 0 {
+			sb.WriteString("SELECT ? AS day, ? AS day_start, ? AS day_end")
+			for _, n := range offsets {
+				fmt.Fprintf(&sb, ", ? AS d%d_start, ? AS d%d_end", n, n)
+			}
+		} else {
+			sb.WriteString(" UNION ALL SELECT ?, ?, ?")
+			sb.WriteString(strings.Repeat(", ?, ?", len(offsets)))
+		}
+		args = append(args, d.Format("2006-01-02"), d, next)
+		for _, n := range offsets {
+			args = append(args, d.AddDate(0, 0, n), next.AddDate(0, 0, n))
+		}
+	}
+	return db.Raw(sb.String(), args...)
+}
+
+// DailyCount 按日统计的数量，Date 可能带有时间部分，由调用方截取日期
+type DailyCount struct {
+	Date  string
+	Count int64
+}
+
+// UserCounts 用户总数，以及在 start 之前注册、当前处于封禁中的用户数
+type UserCounts struct {
+	Total            int64
+	RegisteredBefore int64
+	Suspended        int64
+}
+
+func (r *StatsRepository) CountUsers(start, now time.Time) (*UserCounts, error) {
+	var counts UserCounts
+	err := r.db.Model(&model.User{}).
+		Select("COUNT(*) AS total, "+
+			"COALESCE(SUM(CASE WHEN created_at < ? THEN 1 ELSE 0 END), 0) AS registered_before, "+
+			"COALESCE(SUM(CASE WHEN suspended_at IS NOT NULL AND (suspended_until IS NULL OR suspended_until > ?) THEN 1 ELSE 0 END), 0) AS suspended",
+			start, now).
+		Scan(&counts).Error
+	return &counts, err
+}
+
+// DailyNewUsers 每日注册人数，start 为配置时区的零点
+func (r *StatsRepository) DailyNewUsers(start, end time.Time) ([]DailyCount, error) {
+	var results []DailyCount
+	err := r.db.Model(&model.User{}).
+		Select("days.day AS date, COUNT(*) AS count").
+		Joins("JOIN (?) AS days ON users.created_at >= days.day_start AND users.created_at < days.day_end", dayRanges(r.db, start, end)).
+		Where("users.created_at >= ? AND users.created_at < ?", start, end).
+		Group("days.day").
+		Scan(&results).Error
+	return results, err
+}
+
+// activeCheckins 未删除用户的打卡，已删除用户的打卡不计入统计
+func (r *StatsRepository) activeCheckins() *gorm.DB {
+	return r.db.Model(&model.Checkin{}).
+		Joins("JOIN users ON users.id = checkins.user_id AND users.deleted_at IS NULL")
+}
+
+// CheckinSummary 时间范围内的打卡汇总
+type CheckinSummary struct {
+	Count         int64
+	Users         int64
+	TotalDuration int64
+	TotalCycles   int64
+}
+
+// SummarizeCheckins 统计打卡次数、打卡人数和总时长，start 为零值时不限开始时间
+func (r *StatsRepository) SummarizeCheckins(start, end time.Time) (*CheckinSummary, error) {
+	var summary CheckinSummary
+	query := r.activeCheckins().
+		Select("COUNT(*) AS count, COUNT(DISTINCT checkins.user_id) AS users, "+
+			"COALESCE(SUM(checkins.duration), 0) AS total_duration, COALESCE(SUM(checkins.cycles), 0) AS total_cycles").
+		Where("checkins.checked_at < ?", end)
+	if !start.IsZero() {
+		query = query.Where("checkins.checked_at >= ?", start)
+	}
+	err := query.Scan(&summary).Error
+	return &summary, err
+}
+
+// DailyCheckin 每日打卡次数和打卡人数
+type DailyCheckin struct {
+	Date     string
+	Checkins int64
+	Users    int64
+}
+
+// DailyCheckins start 为配置时区的零点
+func (r *StatsRepository) DailyCheckins(start, end time.Time) ([]DailyCheckin, error) {
+	var results []DailyCheckin
+	err := r.activeCheckins().
+		Select("days.day AS date, COUNT(*) AS checkins, COUNT(DISTINCT checkins.user_id) AS users").
+		Joins("JOIN (?) AS days ON checkins.checked_at >= days.day_start AND checkins.checked_at < days.day_end", dayRanges(r.db, start, end)).
+		Where("checkins.checked_at >= ? AND checkins.checked_at < ?", start, end).
+		Group("days.day").
+		Scan(&results).Error
+	return results, err
+}
+
+// CountActiveUsers 时间范围内有打卡的人数
+func (r *StatsRepository) CountActiveUsers(start, end time.Time) (int64, error) {
+	var count int64
+	err := r.activeCheckins().
+		Where("checkins.checked_at >= ? AND checkins.checked_at < ?", start, end).
+		Distinct("checkins.user_id").
+		Count(&count).Error
+	return count, err
+}
+
+// Retention 注册队列的留存：在 [start, end) 注册的用户中，注册后第 1、7、30 天打卡的人数
+// start 为配置时区的零点，注册日和第 N 天都按该时区划分
+// Eligible 为注册后第 N 天已经到来（不晚于 today）的人数，作为对应留存率的分母
+type Retention struct {
+	Cohort     int64
+	Eligible1  int64
+	Eligible7  int64
+	Eligible30 int64
+	Day1       int64
+	Day7       int64
+	Day30      int64
+}
+
+func (r *StatsRepository) Retention(start, end, today time.Time) (*Retention, error) {
+	var ret Retention
+	err := r.db.Model(&model.User{}).
+		Select("COUNT(*) AS cohort, "+
+			"COALESCE(SUM(CASE WHEN created_at < ? THEN 1 ELSE 0 END), 0) AS eligible1, "+
+			"COALESCE(SUM(CASE WHEN created_at < ? THEN 1 ELSE 0 END), 0) AS eligible7, "+
+			"COALESCE(SUM(CASE WHEN created_at < ? THEN 1 ELSE 0 END), 0) AS eligible30",
+			today, today.AddDate(0, 0, -6), today.AddDate(0, 0, -29)).
+		Where("created_at >= ? AND created_at < ?", start, end).
+		Scan(&ret).Error
+	if err != nil || ret.Cohort == 0 {
+		return &ret, err
+	}
+
+	// 按注册当天所在的日期区间计算第 N 天的时间范围，只扫描队列用户在可能命中的日期范围内的打卡
+	var retained struct {
+		Day1  int64
+		Day7  int64
+		Day30 int64
+	}
+	err = r.db.Table("users").
+		Select("COUNT(DISTINCT CASE WHEN checkins.checked_at >= days.d1_start AND checkins.checked_at < days.d1_end THEN users.id END) AS day1, "+
+			"COUNT(DISTINCT CASE WHEN checkins.checked_at >= days.d7_start AND checkins.checked_at < days.d7_end THEN users.id END) AS day7, "+
+			"COUNT(DISTINCT CASE WHEN checkins.checked_at >= days.d30_start AND checkins.checked_at < days.d30_end THEN users.id END) AS day30").
+		Joins("JOIN (?) AS days ON users.created_at >= days.day_start AND users.created_at < days.day_end", dayRanges(r.db, start, end, 1, 7, 30)).
+		Joins("JOIN checkins ON checkins.user_id = users.id").
+		Where("users.deleted_at IS NULL AND users.created_at >= ? AND users.created_at < ?", start, end).
+		Where("checkins.checked_at >= ? AND checkins.checked_at < ?", start.AddDate(0, 0, 1), end.AddDate(0, 0, 30)).
+		Scan(&retained).Error
+	ret.Day1, ret.Day7, ret.Day30 = retained.Day1, retained.Day7, retained.Day30
+	return &ret, err
+}
+
+// VisitSummary 访问统计：每位访客每天记录一次
+type VisitSummary struct {
+	Total    int64
+	Count    int64
+	Visitors int64
+}
+
+// SummarizeVisits 统计总访问量，以及日期范围内（含首尾，格式 2006-01-02）的访问量和独立访客数
+func (r *StatsRepository) SummarizeVisits(from, to string) (*VisitSummary, error) {
+	var summary VisitSummary
+	err := r.db.Model(&model.Visit{}).
+		Select("COUNT(*) AS count, COUNT(DISTINCT visitor_id) AS visitors").
+		Where("visited_date >= ? AND visited_date <= ?", from, to).
+		Scan(&summary).Error
+	if err != nil {
+		return nil, err
+	}
+	err = r.db.Model(&model.Visit{}).Count(&summary.Total).Error
+	return &summary, err
+}
+
+// DailyVisits 每日访问量
+func (r *StatsRepository) DailyVisits(from, to string) ([]DailyCount, error) {
+	var results []DailyCount
+	err := r.db.Model(&model.Visit{}).
+		Select("visited_date AS date, COUNT(*) AS count").
+		Where("visited_date >= ? AND visited_date <= ?", from, to).
+		Group("visited_date").
+		Scan(&results).Error
+	return results, err
+}
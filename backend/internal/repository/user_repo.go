@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 
 	"tidalcore-backend/internal/model"
@@ -15,6 +17,23 @@ func NewUserRepository() *UserRepository {
 	return &UserRepository{db: database.Get()}
 }
 
+// WithTx 返回在指定事务中执行的仓库
+func (r *UserRepository) WithTx(tx *gorm.DB) *UserRepository {
+	return &UserRepository{db: tx}
+}
+
+// Transaction 开启事务，用户数据与关联记录的变更在同一事务中写入
+func (r *UserRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+// notSuspended 排除当前处于封禁中的用户，到期的临时封禁不再过滤
+func notSuspended(now time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(users.suspended_at IS NULL OR (users.suspended_until IS NOT NULL AND users.suspended_until <= ?))", now)
+	}
+}
+
 func (r *UserRepository) Create(user *model.User) error {
 	return r.db.Create(user).Error
 }
@@ -28,6 +47,36 @@ func (r *UserRepository) GetByID(id uint) (*model.User, error) {
 	return &user, nil
 }
 
+// GetActiveByID 获取未被封禁的用户，封禁中的用户视为不存在
+func (r *UserRepository) GetActiveByID(id uint) (*model.User, error) {
+	var user model.User
+	err := r.db.Scopes(notSuspended(time.Now())).First(&user, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetByIDs 批量获取用户
+func (r *UserRepository) GetByIDs(ids []uint) ([]model.User, error) {
+	var users []model.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	err := r.db.Where("id IN ?", ids).Find(&users).Error
+	return users, err
+}
+
+// GetUsername 获取用户名，已删除的用户同样返回
+func (r *UserRepository) GetUsername(id uint) (string, error) {
+	var usernames []string
+	err := r.db.Unscoped().Model(&model.User{}).Where("id = ?", id).Pluck("username", &usernames).Error
+	if err != nil || len(usernames) == 0 {
+		return "", err
+	}
+	return usernames[0], nil
+}
+
 func (r *UserRepository) GetByUsername(username string) (*model.User, error) {
 	var user model.User
 	err := r.db.Where("username = ?", username).First(&user).Error
@@ -37,19 +86,49 @@ func (r *UserRepository) GetByUsername(username string) (*model.User, error) {
 	return &user, nil
 }
 
-func (r *UserRepository) Update(user *model.User) error {
-	return r.db.Save(user).Error
+// UpdateFields 只写入指定的列（包括零值），不会用读取时的旧值覆盖并发修改的其他列
+func (r *UserRepository) UpdateFields(user *model.User, columns ...string) error {
+	return r.db.Model(user).Select(columns).Updates(user).Error
 }
 
 func (r *UserRepository) GetLeaderboard(limit int) ([]model.User, error) {
 	var users []model.User
-	err := r.db.Order("streak DESC").Limit(limit).Find(&users).Error
+	err := r.db.Scopes(notSuspended(time.Now())).Order("streak DESC").Limit(limit).Find(&users).Error
+	return users, err
+}
+
+// GetGroupLeaderboard 获取小组内排行榜
+func (r *UserRepository) GetGroupLeaderboard(groupID uint, limit int) ([]model.User, error) {
+	var users []model.User
+	err := r.db.Joins("JOIN group_members ON group_members.user_id = users.id").
+		Where("group_members.group_id = ?", groupID).
+		Scopes(notSuspended(time.Now())).
+		Order("users.streak DESC").
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
+
+// GetAllIDs 获取所有用户 ID
+func (r *UserRepository) GetAllIDs() ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&model.User{}).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// GetByLastCheckinRange 获取有连续打卡记录且最后打卡时间在 [start, end) 内的用户
+func (r *UserRepository) GetByLastCheckinRange(start, end time.Time) ([]model.User, error) {
+	var users []model.User
+	err := r.db.Where("streak > 0 AND last_checkin >= ? AND last_checkin < ?", start, end).
+		Find(&users).Error
 	return users, err
 }
 
+// ExistsByUsername 判断用户名是否已被占用
+// 已删除但尚未永久清除的账号仍占用其用户名，保证账号在保留期内可以恢复
 func (r *UserRepository) ExistsByUsername(username string) (bool, error) {
 	var count int64
-	err := r.db.Model(&model.User{}).Where("username = ?", username).Count(&count).Error
+	err := r.db.Unscoped().Model(&model.User{}).Where("username = ?", username).Count(&count).Error
 	return count > 0, err
 }
 
@@ -77,7 +156,178 @@ func (r *UserRepository) GetAllUsers(page, pageSize int) ([]model.User, int64, e
 	return users, total, err
 }
 
+// SetTokensRevokedAt 设置用户令牌失效时间（包括已删除用户）
+func (r *UserRepository) SetTokensRevokedAt(id uint, t time.Time) error {
+	return r.db.Unscoped().Model(&model.User{}).Where("id = ?", id).
+		UpdateColumn("tokens_revoked_at", t).Error
+}
+
+// GetTokenCutoffs 获取所有设置了令牌失效时间的用户
+func (r *UserRepository) GetTokenCutoffs() (map[uint]time.Time, error) {
+	var users []model.User
+	err := r.db.Unscoped().Select("id, tokens_revoked_at").
+		Where("tokens_revoked_at IS NOT NULL").
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+
+	cutoffs := make(map[uint]time.Time, len(users))
+	for _, u := range users {
+		cutoffs[u.ID] = *u.TokensRevokedAt
+	}
+	return cutoffs, nil
+}
+
+// UpgradePasswordHash 将密码哈希替换为新算法或新参数计算的结果
+// 仅当哈希未被并发修改（如用户刚好修改了密码）时生效
+func (r *UserRepository) UpgradePasswordHash(id uint, oldHash, newHash string) error {
+	return r.db.Model(&model.User{}).
+		Where("id = ? AND password_hash = ?", id, oldHash).
+		UpdateColumn("password_hash", newHash).Error
+}
+
+// SetInitialPassword 为没有本地密码的用户设置密码，已有密码时不修改并返回 false
+func (r *UserRepository) SetInitialPassword(id uint, hash string) (bool, error) {
+	result := r.db.Model(&model.User{}).
+		Where("id = ? AND password_hash = ''", id).
+		UpdateColumn("password_hash", hash)
+	return result.RowsAffected > 0, result.Error
+}
+
+// UpdateTOTP 更新两步验证密钥及启用状态，重置重放保护的时间步
+func (r *UserRepository) UpdateTOTP(id uint, secret string, enabled bool) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"totp_secret":    secret,
+		"totp_enabled":   enabled,
+		"totp_last_step": 0,
+	}).Error
+}
+
+// AdvanceTOTPStep 记录已使用的时间步，仅当该时间步晚于上次使用时生效
+// 返回 false 表示验证码已被使用过
+func (r *UserRepository) AdvanceTOTPStep(id uint, step int64) (bool, error) {
+	result := r.db.Model(&model.User{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		UpdateColumn("totp_last_step", step)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Suspend 封禁用户，until 为空表示永久封禁
+func (r *UserRepository) Suspend(id uint, until *time.Time, reason string, adminID uint) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"suspended_at":    time.Now(),
+		"suspended_until": until,
+		"suspend_reason":  reason,
+		"suspended_by":    adminID,
+	}).Error
+}
+
+// Unsuspend 解除封禁，清空封禁相关字段
+func (r *UserRepository) Unsuspend(id uint) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"suspended_at":    nil,
+		"suspended_until": nil,
+		"suspend_reason":  "",
+		"suspended_by":    nil,
+	}).Error
+}
+
 // Delete 删除用户（软删除）
 func (r *UserRepository) Delete(id uint) error {
 	return r.db.Delete(&model.User{}, id).Error
 }
+
+// GetDeletedUsers 分页获取已软删除的用户，最近删除的在前
+func (r *UserRepository) GetDeletedUsers(page, pageSize int) ([]model.User, int64, error) {
+	var users []model.User
+	var total int64
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	if err := r.db.Unscoped().Model(&model.User{}).Where("deleted_at IS NOT NULL").Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := r.db.Unscoped().Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&users).Error
+	return users, total, err
+}
+
+// GetDeletedByID 获取已软删除的用户
+func (r *UserRepository) GetDeletedByID(id uint) (*model.User, error) {
+	var user model.User
+	err := r.db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetDeletedBefore 获取在指定时间之前被软删除的用户
+func (r *UserRepository) GetDeletedBefore(cutoff time.Time) ([]model.User, error) {
+	var users []model.User
+	err := r.db.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Find(&users).Error
+	return users, err
+}
+
+// Restore 恢复软删除的用户
+func (r *UserRepository) Restore(id uint) error {
+	return r.db.Unscoped().Model(&model.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		UpdateColumn("deleted_at", nil).Error
+}
+
+// Purge 在一个事务中永久删除用户及其所有关联数据
+// 用户创建的小组随之解散；管理员创建的挑战等站点内容不受影响
+func (r *UserRepository) Purge(user *model.User) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var groupIDs []uint
+		if err := tx.Unscoped().Model(&model.Group{}).Where("owner_id = ?", user.ID).Pluck("id", &groupIDs).Error; err != nil {
+			return err
+		}
+		if len(groupIDs) > 0 {
+			if err := tx.Where("group_id IN ?", groupIDs).Delete(&model.GroupMember{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Where("id IN ?", groupIDs).Delete(&model.Group{}).Error; err != nil {
+				return err
+			}
+		}
+
+		owned := []interface{}{
+			&model.Checkin{},
+			&model.GroupMember{},
+			&model.ChallengeParticipant{},
+			&model.Notification{},
+			&model.RefreshToken{},
+			&model.RevokedToken{},
+			&model.Session{},
+			&model.TwoFactorRecoveryCode{},
+			&model.APIToken{},
+			&model.UserIdentity{},
+			&model.PasswordReset{},
+			&model.PasswordRecoveryCode{},
+			&model.PasskeyCredential{},
+			&model.NameReview{},
+		}
+		for _, m := range owned {
+			if err := tx.Where("user_id = ?", user.ID).Delete(m).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("sender_id = ? OR receiver_id = ?", user.ID, user.ID).Delete(&model.Cheer{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("username = ?", user.Username).Delete(&model.LoginFailure{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&model.User{}, user.ID).Error
+	})
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"tidalcore-backend/internal/auth"
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/internal/repository"
+)
+
+var (
+	ErrAPITokenNotFound     = errors.New("api token not found")
+	ErrAPITokenLimit        = errors.New("api token limit reached")
+	ErrInvalidAPITokenScope = errors.New("invalid api token scope")
+	ErrInvalidAPIToken      = errors.New("invalid api token")
+	ErrAPITokenExpired      = errors.New("api token has expired")
+)
+
+// 个人访问令牌的授权范围
+const (
+	ScopeCheckinWrite = "checkin:write" // 提交打卡
+	ScopeStatsRead    = "stats:read"    // 读取个人资料、打卡记录和热力图
+)
+
+// APITokenScopes 所有可授予的授权范围
+var APITokenScopes = []string{ScopeCheckinWrite, ScopeStatsRead}
+
+const (
+	// APITokenPrefix 个人访问令牌的固定前缀，用于与 JWT 区分
+	APITokenPrefix = "tc_"
+
+	MaxAPITokensPerUser = 20
+	MaxAPITokenDays     = 365
+
+	// 最近使用时间的更新间隔，避免每次请求都写库
+	apiTokenTouchInterval = time.Minute
+)
+
+type APITokenService struct {
+	tokenRepo *repository.APITokenRepository
+}
+
+func NewAPITokenService() *APITokenService {
+	return &APITokenService{
+		tokenRepo: repository.NewAPITokenRepository(),
+	}
+}
+
+type CreateAPITokenRequest struct {
+	Name          string   `json:"name" binding:"required,min=1,max=50"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"min=0"` // 0 表示永不过期
+}
+
+// APITokenInfo 令牌信息（不含令牌明文）
+type APITokenInfo struct {
+	model.APIToken
+	Scopes  []string `json:"scopes"`
+	Expired bool     `json:"expired"`
+}
+
+// CreatedAPIToken 新建的令牌，明文仅在创建时返回一次
+type CreatedAPIToken struct {
+	APITokenInfo
+	Token string `json:"token"`
+}
+
+// IsAPIToken 判断凭证是否为个人访问令牌
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
+
+func (s *APITokenService) Create(userID uint, req *CreateAPITokenRequest) (*CreatedAPIToken, error) {
+	scopes, err := normalizeScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if req.ExpiresInDays > MaxAPITokenDays {
+		req.ExpiresInDays = MaxAPITokenDays
+	}
+
+	count, err := s.tokenRepo.CountByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= MaxAPITokensPerUser {
+		return nil, ErrAPITokenLimit
+	}
+
+	secret, err := auth.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	raw := APITokenPrefix + secret
+
+	token := &model.APIToken{
+		UserID:    userID,
+		Name:      strings.TrimSpace(req.Name),
+		Prefix:    raw[:len(APITokenPrefix)+6],
+		TokenHash: auth.HashToken(raw),
+		Scopes:    strings.Join(scopes, ","),
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := s.tokenRepo.Create(token); err != nil {
+		return nil, err
+	}
+
+	return &CreatedAPIToken{
+		APITokenInfo: toAPITokenInfo(*token),
+		Token:        raw,
+	}, nil
+}
+
+func (s *APITokenService) List(userID uint) ([]APITokenInfo, error) {
+	tokens, err := s.tokenRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]APITokenInfo, 0, len(tokens))
+	for _, t := range tokens {
+		result = append(result, toAPITokenInfo(t))
+	}
+	return result, nil
+}
+
+func (s *APITokenService) Revoke(userID, tokenID uint) error {
+	deleted, err := s.tokenRepo.DeleteByUser(tokenID, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrAPITokenNotFound
+	}
+	return nil
+}
+
+// Authenticate 校验个人访问令牌并记录使用情况
+func (s *APITokenService) Authenticate(raw, ip string) (*model.APIToken, error) {
+	token, err := s.tokenRepo.GetByHash(auth.HashToken(raw))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAPIToken
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if token.ExpiresAt != nil && now.After(*token.ExpiresAt) {
+		return nil, ErrAPITokenExpired
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= apiTokenTouchInterval || token.LastUsedIP != ip {
+		if err := s.tokenRepo.TouchLastUsed(token.ID, truncate(ip, 64), now); err != nil {
+			return nil, err
+		}
+	}
+	return token, nil
+}
+
+// normalizeScopes 校验并去重授权范围
+func normalizeScopes(scopes []string) ([]string, error) {
+	seen := make(map[string]bool, len(scopes))
+	result := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		valid := false
+		for _, allowed := range APITokenScopes {
+			if scope == allowed {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, ErrInvalidAPITokenScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			result = append(result, scope)
+		}
+	}
+	return result, nil
+}
+
+func toAPITokenInfo(t model.APIToken) APITokenInfo {
+	return APITokenInfo{
+		APIToken: t,
+		Scopes:   t.ScopeList(),
+		Expired:  t.ExpiresAt != nil && time.Now().After(*t.ExpiresAt),
+	}
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"tidalcore-backend/internal/auth"
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/pkg/database"
+)
+
+func TestCreateAPIToken(t *testing.T) {
+	s := NewAPITokenService()
+	user := createUser(t, false)
+
+	if _, err := s.Create(user.ID, &CreateAPITokenRequest{Name: "bad", Scopes: []string{"admin"}}); !errors.Is(err, ErrInvalidAPITokenScope) {
+		t.Fatalf("unknown scope: err = %v, want ErrInvalidAPITokenScope", err)
+	}
+
+	created, err := s.Create(user.ID, &CreateAPITokenRequest{
+		Name:   " watch ",
+		Scopes: []string{ScopeStatsRead, " " + ScopeStatsRead, ScopeCheckinWrite},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if !IsAPIToken(created.Token) || created.Name != "watch" || len(created.Scopes) != 2 {
+		t.Fatalf("created = %+v", created)
+	}
+
+	// 只保存令牌哈希
+	var stored model.APIToken
+	if err := database.Get().First(&stored, created.ID).Error; err != nil {
+		t.Fatalf("load token: %v", err)
+	}
+	if stored.TokenHash != auth.HashToken(created.Token) || stored.Scopes != ScopeStatsRead+","+ScopeCheckinWrite {
+		t.Fatalf("stored token = %+v", stored)
+	}
+
+	token, err := s.Authenticate(created.Token, "10.0.0.1")
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if !token.HasScope(ScopeCheckinWrite) || !token.HasScope(ScopeStatsRead) || token.HasScope("admin") {
+		t.Fatalf("scopes = %q", token.Scopes)
+	}
+	if err := database.Get().First(&stored, created.ID).Error; err != nil {
+		t.Fatalf("load token: %v", err)
+	}
+	if stored.LastUsedAt == nil || stored.LastUsedIP != "10.0.0.1" {
+		t.Fatalf("last used = %v from %q", stored.LastUsedAt, stored.LastUsedIP)
+	}
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"tidalcore-backend/config"
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/internal/repository"
+)
+
+var ErrInvalidAuditDate = errors.New("invalid audit date range")
+
+// MaxAuditExport 单次导出的最大记录数
+const MaxAuditExport = 10000
+
+// AuditActor 执行管理操作的用户及其来源 IP，ID 为 0 表示系统后台任务
+type AuditActor struct {
+	ID uint
+	IP string
+}
+
+// SystemActor 后台任务（如到期自动清除）使用的操作者
+var SystemActor = AuditActor{}
+
+// AuditEntry 待写入的审计记录，Before 和 After 序列化为 JSON 保存
+type AuditEntry struct {
+	Action     string
+	TargetType string
+	TargetID   string
+	Before     interface{}
+	After      interface{}
+}
+
+type AuditService struct {
+	auditRepo *repository.AuditLogRepository
+	userRepo  *repository.UserRepository
+	location  *time.Location
+}
+
+func NewAuditService() *AuditService {
+	loc := time.Local
+	cfg := config.Get()
+	if cfg != nil && cfg.Server.Timezone != "" {
+		if l, err := time.LoadLocation(cfg.Server.Timezone); err == nil {
+			loc = l
+		}
+	}
+
+	return &AuditService{
+		auditRepo: repository.NewAuditLogRepository(),
+		userRepo:  repository.NewUserRepository(),
+		location:  loc,
+	}
+}
+
+// Record 在同一事务中执行变更并写入审计记录：变更失败时不留记录，记录写入失败时变更一并回滚
+// change 执行时可以补充 entry 的 TargetID 和 After，例如新建记录的 ID；change 为空时只写入记录
+func (s *AuditService) Record(actor AuditActor, entry *AuditEntry, change func(tx *gorm.DB) error) error {
+	err := s.auditRepo.Transaction(func(tx *gorm.DB) error {
+		// 先取操作者用户名，变更（如恢复备份）可能改写用户表
+		var actorName string
+		if actor.ID != 0 {
+			name, err := s.userRepo.WithTx(tx).GetUsername(actor.ID)
+			if err != nil {
+				return err
+			}
+			actorName = name
+		}
+
+		if change != nil {
+			if err := change(tx); err != nil {
+				return err
+			}
+		}
+
+		record, err := newAuditLog(actor, actorName, entry)
+		if err != nil {
+			return err
+		}
+		return s.auditRepo.WithTx(tx).Create(record)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[AUDIT] %s actor=%d target=%s:%s ip=%s",
+		entry.Action, actor.ID, entry.TargetType, entry.TargetID, actor.IP)
+	return nil
+}
+
+func newAuditLog(actor AuditActor, actorName string, entry *AuditEntry) (*model.AuditLog, error) {
+	before, err := auditJSON(entry.Before)
+	if err != nil {
+		return nil, err
+	}
+	after, err := auditJSON(entry.After)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.AuditLog{
+		ActorID:    actor.ID,
+		ActorName:  actorName,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   truncate(entry.TargetID, 100),
+		Before:     before,
+		After:      after,
+		IP:         truncate(actor.IP, 64),
+	}, nil
+}
+
+func auditJSON(v interface{}) (model.JSONText, error) {
+	if v == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return model.JSONText(data), nil
+}
+
+// auditID 将数据库 ID 格式化为审计记录的目标 ID
+func auditID(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// AuditQuery 审计记录查询条件，日期为 YYYY-MM-DD（按服务器时区），包含起止当天
+type AuditQuery struct {
+	ActorID    *uint
+	Action     string
+	TargetType string
+	TargetID   string
+	From       string
+	To         string
+}
+
+func (s *AuditService) filter(q *AuditQuery) (*repository.AuditLogFilter, error) {
+	f := &repository.AuditLogFilter{
+		ActorID:    q.ActorID,
+		Action:     q.Action,
+		TargetType: q.TargetType,
+		TargetID:   q.TargetID,
+	}
+	if q.From != "" {
+		from, err := time.ParseInLocation(dateLayout, q.From, s.location)
+		if err != nil {
+			return nil, ErrInvalidAuditDate
+		}
+		f.From = &from
+	}
+	if q.To != "" {
+		to, err := time.ParseInLocation(dateLayout, q.To, s.location)
+		if err != nil {
+			return nil, ErrInvalidAuditDate
+		}
+		to = to.AddDate(0, 0, 1)
+		f.To = &to
+	}
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return nil, ErrInvalidAuditDate
+	}
+	return f, nil
+}
+
+// List 按条件分页获取审计记录（管理员功能）
+func (s *AuditService) List(q *AuditQuery, page, pageSize int) ([]model.AuditLog, int64, error) {
+	f, err := s.filter(q)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.auditRepo.List(f, page, pageSize)
+}
+
+// Export 按条件获取用于导出的审计记录（管理员功能）
+// 超过 MaxAuditExport 条时只返回最新的部分，truncated 为 true
+func (s *AuditService) Export(q *AuditQuery) (logs []model.AuditLog, truncated bool, err error) {
+	f, err := s.filter(q)
+	if err != nil {
+		return nil, false, err
+	}
+	logs, err = s.auditRepo.ListForExport(f, MaxAuditExport+1)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(logs) > MaxAuditExport {
+		return logs[:MaxAuditExport], true, nil
+	}
+	return logs, false, nil
+}
+
+// WriteCSV 将审计记录写为 CSV，时间按服务器时区格式化
+func (s *AuditService) WriteCSV(w io.Writer, logs []model.AuditLog) error {
+	cw := csv.NewWriter(w)
+	header := []string{"ID", "时间", "操作者ID", "操作者", "IP", "动作", "目标类型", "目标ID", "变更前", "变更后"}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, l := range logs {
+		record := []string{
+			auditID(l.ID),
+			l.CreatedAt.In(s.location).Format("2006-01-02 15:04:05"),
+			auditID(l.ActorID),
+			l.ActorName,
+			l.IP,
+			l.Action,
+			l.TargetType,
+			l.TargetID,
+			string(l.Before),
+			string(l.After),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -1,13 +1,22 @@
 package service
 
 import (
+	"context"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
 	"tidalcore-backend/internal/model"
 	"tidalcore-backend/pkg/database"
 )
@@ -26,10 +35,14 @@ type BackupInfo struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
-type BackupService struct{}
+type BackupService struct {
+	auditService *AuditService
+}
 
 func NewBackupService() *BackupService {
-	return &BackupService{}
+	return &BackupService{
+		auditService: NewAuditService(),
+	}
 }
 
 // ensureBackupDir 确保备份目录存在
@@ -38,7 +51,7 @@ func (s *BackupService) ensureBackupDir() error {
 }
 
 // CreateBackup 创建数据库备份
-func (s *BackupService) CreateBackup() (*BackupInfo, error) {
+func (s *BackupService) CreateBackup(actor AuditActor) (*BackupInfo, error) {
 	if err := s.ensureBackupDir(); err != nil {
 		return nil, fmt.Errorf("创建备份目录失败: %w", err)
 	}
@@ -62,9 +75,21 @@ func (s *BackupService) CreateBackup() (*BackupInfo, error) {
 		return nil, fmt.Errorf("生成 SQL 失败: %w", err)
 	}
 
-	// 写入文件
-	if err := os.WriteFile(filepath, []byte(sqlContent), 0644); err != nil {
-		return nil, fmt.Errorf("写入备份文件失败: %w", err)
+	// 写入文件，审计记录写入失败时删除文件
+	err = s.auditService.Record(actor, &AuditEntry{
+		Action:     "backup.create",
+		TargetType: model.AuditTargetBackup,
+		TargetID:   filename,
+		After:      backupDigest(sqlContent),
+	}, func(tx *gorm.DB) error {
+		if err := os.WriteFile(filepath, []byte(sqlContent), 0644); err != nil {
+			return fmt.Errorf("写入备份文件失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		os.Remove(filepath)
+		return nil, err
 	}
 
 	// 获取文件信息
@@ -119,126 +144,76 @@ func (s *BackupService) generateSQL() (string, error) {
 
 // exportUsersTable 导出用户表
 func (s *BackupService) exportUsersTable() (string, error) {
-	var sb strings.Builder
 	var users []model.User
 
 	// 使用 Unscoped 获取包括软删除的所有记录
 	if err := database.Get().Unscoped().Find(&users).Error; err != nil {
 		return "", fmt.Errorf("查询用户表失败: %w", err)
 	}
-
-	sb.WriteString("-- Table: users\n")
-	sb.WriteString("DELETE FROM `users`;\n")
-
-	if len(users) > 0 {
-		sb.WriteString("INSERT INTO `users` (`id`, `username`, `display_name`, `password_hash`, `is_admin`, `title`, `streak`, `max_streak`, `total_checkin`, `last_checkin`, `created_at`, `updated_at`, `deleted_at`) VALUES\n")
-
-		for i, user := range users {
-			lastCheckin := "NULL"
-			if user.LastCheckin != nil {
-				lastCheckin = fmt.Sprintf("'%s'", user.LastCheckin.Format("2006-01-02 15:04:05"))
-			}
-
-			deletedAt := "NULL"
-			if user.DeletedAt.Valid {
-				deletedAt = fmt.Sprintf("'%s'", user.DeletedAt.Time.Format("2006-01-02 15:04:05"))
-			}
-
-			isAdmin := 0
-			if user.IsAdmin {
-				isAdmin = 1
-			}
-
-			sb.WriteString(fmt.Sprintf("(%d, '%s', '%s', '%s', %d, '%s', %d, %d, %d, %s, '%s', '%s', %s)",
-				user.ID,
-				escapeString(user.Username),
-				escapeString(user.DisplayName),
-				escapeString(user.PasswordHash),
-				isAdmin,
-				escapeString(user.Title),
-				user.Streak,
-				user.MaxStreak,
-				user.TotalCheckin,
-				lastCheckin,
-				user.CreatedAt.Format("2006-01-02 15:04:05"),
-				user.UpdatedAt.Format("2006-01-02 15:04:05"),
-				deletedAt,
-			))
-
-			if i < len(users)-1 {
-				sb.WriteString(",\n")
-			} else {
-				sb.WriteString(";\n")
-			}
-		}
-	}
-
-	sb.WriteString("\n")
-	return sb.String(), nil
+	return exportTable(database.Get(), &model.User{}, users)
 }
 
 // exportCheckinsTable 导出打卡表
 func (s *BackupService) exportCheckinsTable() (string, error) {
-	var sb strings.Builder
 	var checkins []model.Checkin
 
 	if err := database.Get().Find(&checkins).Error; err != nil {
 		return "", fmt.Errorf("查询打卡表失败: %w", err)
 	}
-
-	sb.WriteString("-- Table: checkins\n")
-	sb.WriteString("DELETE FROM `checkins`;\n")
-
-	if len(checkins) > 0 {
-		sb.WriteString("INSERT INTO `checkins` (`id`, `user_id`, `duration`, `cycles`, `checked_at`, `created_at`) VALUES\n")
-
-		for i, checkin := range checkins {
-			sb.WriteString(fmt.Sprintf("(%d, %d, %d, %d, '%s', '%s')",
-				checkin.ID,
-				checkin.UserID,
-				checkin.Duration,
-				checkin.Cycles,
-				checkin.CheckedAt.Format("2006-01-02 15:04:05"),
-				checkin.CreatedAt.Format("2006-01-02 15:04:05"),
-			))
-
-			if i < len(checkins)-1 {
-				sb.WriteString(",\n")
-			} else {
-				sb.WriteString(";\n")
-			}
-		}
-	}
-
-	sb.WriteString("\n")
-	return sb.String(), nil
+	return exportTable(database.Get(), &model.Checkin{}, checkins)
 }
 
 // exportVisitsTable 导出访问表
 func (s *BackupService) exportVisitsTable() (string, error) {
-	var sb strings.Builder
 	var visits []model.Visit
 
 	if err := database.Get().Find(&visits).Error; err != nil {
 		return "", fmt.Errorf("查询访问表失败: %w", err)
 	}
+	return exportTable(database.Get(), &model.Visit{}, visits)
+}
 
-	sb.WriteString("-- Table: visits\n")
-	sb.WriteString("DELETE FROM `visits`;\n")
+// exportTable 导出一张表，列名取自模型的 GORM 定义，模型新增字段后自动包含在备份中
+// rows 为该模型的切片
+func exportTable(db *gorm.DB, m interface{}, rows interface{}) (string, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(m); err != nil {
+		return "", err
+	}
+	table := stmt.Schema.Table
 
-	if len(visits) > 0 {
-		sb.WriteString("INSERT INTO `visits` (`id`, `visitor_id`, `user_agent`, `visited_date`, `created_at`) VALUES\n")
+	var fields []*schema.Field
+	var columns []string
+	for _, field := range stmt.Schema.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		fields = append(fields, field)
+		columns = append(columns, "`"+field.DBName+"`")
+	}
 
-		for i, visit := range visits {
-			sb.WriteString(fmt.Sprintf("(%d, '%s', '%s', '%s', '%s')",
-				visit.ID,
-				escapeString(visit.VisitorID),
-				escapeString(visit.UserAgent),
-				escapeString(visit.VisitedDate),
-				visit.CreatedAt.Format("2006-01-02 15:04:05"),
-			))
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("-- Table: %s\n", table))
+	sb.WriteString(fmt.Sprintf("DELETE FROM `%s`;\n", table))
+
+	values := reflect.ValueOf(rows)
+	if values.Len() > 0 {
+		sb.WriteString(fmt.Sprintf("INSERT INTO `%s` (%s) VALUES\n", table, strings.Join(columns, ", ")))
+
+		for i := 0; i < values.Len(); i++ {
+			row := values.Index(i)
+			literals := make([]string, len(fields))
+			for j, field := range fields {
+				value, _ := field.ValueOf(context.Background(), row)
+				literal, err := sqlLiteral(value)
+				if err != nil {
+					return "", fmt.Errorf("导出 %s.%s 失败: %w", table, field.DBName, err)
+				}
+				literals[j] = literal
+			}
+			sb.WriteString("(" + strings.Join(literals, ", ") + ")")
 
-			if i < len(visits)-1 {
+			if i < values.Len()-1 {
 				sb.WriteString(",\n")
 			} else {
 				sb.WriteString(";\n")
@@ -250,6 +225,50 @@ func (s *BackupService) exportVisitsTable() (string, error) {
 	return sb.String(), nil
 }
 
+// sqlLiteral 将字段值转换为 SQL 字面量，空指针和无效的软删除时间为 NULL
+func sqlLiteral(value interface{}) (string, error) {
+	if valuer, ok := value.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return "", err
+		}
+		value = v
+	}
+
+	rv := reflect.ValueOf(value)
+	if !rv.IsValid() {
+		return "NULL", nil
+	}
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return "NULL", nil
+		}
+		return sqlLiteral(rv.Elem().Interface())
+	}
+
+	switch v := value.(type) {
+	case time.Time:
+		return "'" + v.Format("2006-01-02 15:04:05") + "'", nil
+	case string:
+		return "'" + escapeString(v) + "'", nil
+	case bool:
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.String:
+		return "'" + escapeString(rv.String()) + "'", nil
+	}
+	return "", fmt.Errorf("不支持的字段类型 %T", value)
+}
+
 // ListBackups 获取备份列表
 func (s *BackupService) ListBackups() ([]BackupInfo, error) {
 	if err := s.ensureBackupDir(); err != nil {
@@ -311,7 +330,7 @@ func (s *BackupService) GetBackupPath(filename string) (string, error) {
 }
 
 // RestoreBackup 从备份文件恢复
-func (s *BackupService) RestoreBackup(filename string) error {
+func (s *BackupService) RestoreBackup(actor AuditActor, filename string) error {
 	path, err := s.GetBackupPath(filename)
 	if err != nil {
 		return err
@@ -322,30 +341,59 @@ func (s *BackupService) RestoreBackup(filename string) error {
 		return fmt.Errorf("读取备份文件失败: %w", err)
 	}
 
-	return s.executeSQL(string(content))
+	return s.restore(actor, "backup.restore", filename, string(content))
 }
 
-// RestoreFromSQL 从 SQL 内容恢复
-func (s *BackupService) RestoreFromSQL(sqlContent string) error {
+// RestoreFromSQL 从上传的 SQL 内容恢复，filename 为上传的文件名，仅用于审计记录
+func (s *BackupService) RestoreFromSQL(actor AuditActor, filename, sqlContent string) error {
 	// 基本验证
 	if !strings.Contains(sqlContent, "TidalCore Database Backup") {
 		return fmt.Errorf("无效的备份文件：缺少 TidalCore 标识")
 	}
 
-	return s.executeSQL(sqlContent)
+	return s.restore(actor, "backup.upload_restore", filename, sqlContent)
 }
 
-// executeSQL 执行 SQL 语句
-func (s *BackupService) executeSQL(sqlContent string) error {
-	db := database.Get()
+// restore 在一个事务中替换数据并写入审计记录，任一语句失败时整体回滚
+func (s *BackupService) restore(actor AuditActor, action, filename, sqlContent string) error {
+	err := s.auditService.Record(actor, &AuditEntry{
+		Action:     action,
+		TargetType: model.AuditTargetBackup,
+		TargetID:   filename,
+		After:      backupDigest(sqlContent),
+	}, func(tx *gorm.DB) error {
+		return s.executeSQL(tx, sqlContent)
+	})
+	if err != nil {
+		return err
+	}
+
+	// 用户数据已整体替换，清空鉴权缓存
+	GetUserStateCache().Clear()
+
+	return nil
+}
 
-	// 先禁用外键检查
-	if err := db.Exec("SET FOREIGN_KEY_CHECKS = 0").Error; err != nil {
-		return fmt.Errorf("禁用外键检查失败: %w", err)
+// backupDigest 审计记录中的备份内容摘要
+func backupDigest(sqlContent string) map[string]interface{} {
+	sum := sha256.Sum256([]byte(sqlContent))
+	return map[string]interface{}{
+		"size":   len(sqlContent),
+		"sha256": hex.EncodeToString(sum[:]),
 	}
+}
 
-	// 确保最后恢复外键检查
-	defer db.Exec("SET FOREIGN_KEY_CHECKS = 1")
+// executeSQL 在事务中执行 SQL 语句
+func (s *BackupService) executeSQL(db *gorm.DB, sqlContent string) error {
+	// 先禁用外键检查（MySQL）
+	if db.Dialector.Name() == "mysql" {
+		if err := db.Exec("SET FOREIGN_KEY_CHECKS = 0").Error; err != nil {
+			return fmt.Errorf("禁用外键检查失败: %w", err)
+		}
+
+		// 确保最后恢复外键检查
+		defer db.Exec("SET FOREIGN_KEY_CHECKS = 1")
+	}
 
 	// 按顺序删除表数据（先删除有外键依赖的表）
 	if err := db.Exec("DELETE FROM `checkins`").Error; err != nil {
@@ -385,13 +433,33 @@ func (s *BackupService) executeSQL(sqlContent string) error {
 }
 
 // DeleteBackup 删除备份文件
-func (s *BackupService) DeleteBackup(filename string) error {
+func (s *BackupService) DeleteBackup(actor AuditActor, filename string) error {
 	path, err := s.GetBackupPath(filename)
 	if err != nil {
 		return err
 	}
 
-	return os.Remove(path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return s.auditService.Record(actor, &AuditEntry{
+		Action:     "backup.delete",
+		TargetType: model.AuditTargetBackup,
+		TargetID:   filename,
+		Before:     map[string]interface{}{"size": info.Size(), "created_at": info.ModTime()},
+	}, func(tx *gorm.DB) error {
+		return os.Remove(path)
+	})
+}
+
+// RecordDownload 记录备份下载，备份包含全部用户数据
+func (s *BackupService) RecordDownload(actor AuditActor, filename string) error {
+	return s.auditService.Record(actor, &AuditEntry{
+		Action:     "backup.download",
+		TargetType: model.AuditTargetBackup,
+		TargetID:   filename,
+	}, nil)
 }
 
 // escapeString 转义 SQL 字符串
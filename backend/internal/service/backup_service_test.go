@@ -0,0 +1,142 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/pkg/database"
+)
+
+func TestBackupRoundTripKeepsUserColumns(t *testing.T) {
+	db := database.Get()
+	now := time.Now().Truncate(time.Second)
+
+	user := &model.User{
+		Username:     uniqueName("backup"),
+		DisplayName:  "备份测试",
+		PasswordHash: "$argon2id$v=19$m=65536,t=3,p=2$c2FsdA$aGFzaA",
+		TOTPSecret:   "JBSWY3DPEHPK3PXP",
+		TOTPEnabled:  true,
+		TOTPLastStep: 58000000,
+		LastCheckin:  &now,
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	s := NewBackupService()
+	sqlContent, err := s.generateSQL()
+	if err != nil {
+		t.Fatalf("generateSQL: %v", err)
+	}
+
+	// 备份之后的修改应被恢复覆盖
+	err = db.Model(&model.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+		"totp_enabled":   false,
+		"totp_secret":    "",
+		"totp_last_step": 0,
+	}).Error
+	if err != nil {
+		t.Fatalf("update user: %v", err)
+	}
+
+	if err := s.RestoreFromSQL(SystemActor, "roundtrip.sql", sqlContent); err != nil {
+		t.Fatalf("RestoreFromSQL: %v", err)
+	}
+
+	var restored model.User
+	if err := db.Unscoped().First(&restored, user.ID).Error; err != nil {
+		t.Fatalf("load restored user: %v", err)
+	}
+	if !restored.TOTPEnabled || restored.TOTPSecret != user.TOTPSecret || restored.TOTPLastStep != user.TOTPLastStep {
+		t.Fatalf("two-factor columns not restored: enabled=%v secret=%q step=%d",
+			restored.TOTPEnabled, restored.TOTPSecret, restored.TOTPLastStep)
+	}
+	if restored.PasswordHash != user.PasswordHash || restored.DisplayName != user.DisplayName {
+		t.Fatalf("credentials not restored: %+v", restored)
+	}
+	if restored.LastCheckin == nil || !restored.LastCheckin.Equal(now) {
+		t.Fatalf("last_checkin = %v, want %v", restored.LastCheckin, now)
+	}
+
+	// 再次导出的内容应与恢复前一致
+	again, err := s.generateSQL()
+	if err != nil {
+		t.Fatalf("generateSQL after restore: %v", err)
+	}
+	if stripHeader(again) != stripHeader(sqlContent) {
+		t.Fatalf("backup changed after round trip")
+	}
+}
+
+func TestBackupRoundTripKeepsSuspension(t *testing.T) {
+	db := database.Get()
+	now := time.Now().Truncate(time.Second)
+	until := now.Add(48 * time.Hour)
+	adminID := uint(1)
+
+	user := &model.User{
+		Username:        uniqueName("suspended"),
+		DisplayName:     "封禁测试",
+		SuspendedAt:     &now,
+		SuspendedUntil:  &until,
+		SuspendReason:   "spam",
+		SuspendedBy:     &adminID,
+		TokensRevokedAt: &now,
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	s := NewBackupService()
+	sqlContent, err := s.generateSQL()
+	if err != nil {
+		t.Fatalf("generateSQL: %v", err)
+	}
+
+	err = db.Model(&model.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+		"suspended_at":      nil,
+		"suspended_until":   nil,
+		"suspend_reason":    "",
+		"suspended_by":      nil,
+		"tokens_revoked_at": nil,
+	}).Error
+	if err != nil {
+		t.Fatalf("update user: %v", err)
+	}
+
+	if err := s.RestoreFromSQL(SystemActor, "suspension.sql", sqlContent); err != nil {
+		t.Fatalf("RestoreFromSQL: %v", err)
+	}
+
+	var restored model.User
+	if err := db.Unscoped().First(&restored, user.ID).Error; err != nil {
+		t.Fatalf("load restored user: %v", err)
+	}
+	if restored.SuspendedAt == nil || !restored.SuspendedAt.Equal(now) {
+		t.Errorf("suspended_at = %v, want %v", restored.SuspendedAt, now)
+	}
+	if restored.SuspendedUntil == nil || !restored.SuspendedUntil.Equal(until) {
+		t.Errorf("suspended_until = %v, want %v", restored.SuspendedUntil, until)
+	}
+	if restored.SuspendReason != user.SuspendReason {
+		t.Errorf("suspend_reason = %q, want %q", restored.SuspendReason, user.SuspendReason)
+	}
+	if restored.SuspendedBy == nil || *restored.SuspendedBy != adminID {
+		t.Errorf("suspended_by = %v, want %d", restored.SuspendedBy, adminID)
+	}
+	if restored.TokensRevokedAt == nil || !restored.TokensRevokedAt.Equal(now) {
+		t.Errorf("tokens_revoked_at = %v, want %v", restored.TokensRevokedAt, now)
+	}
+	if !restored.IsSuspended(time.Now()) {
+		t.Error("restored user is not suspended")
+	}
+}
+
+// stripHeader 去掉含生成时间的三行头部注释
+func stripHeader(sqlContent string) string {
+	parts := strings.SplitN(sqlContent, "\n", 4)
+	return parts[len(parts)-1]
+}
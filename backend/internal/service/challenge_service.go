@@ -0,0 +1,622 @@
+package service
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"tidalcore-backend/config"
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/internal/repository"
+)
+
+var (
+	ErrChallengeNotFound    = errors.New("challenge not found")
+	ErrInvalidChallengeGoal = errors.New("invalid challenge goal")
+	ErrInvalidChallengeDate = errors.New("invalid challenge date range")
+	ErrChallengeEnded       = errors.New("challenge has ended")
+	ErrChallengeNotEnded    = errors.New("challenge has not ended yet")
+	ErrChallengeStarted     = errors.New("challenge has started")
+	ErrAlreadyJoined        = errors.New("already joined this challenge")
+	ErrNotJoined            = errors.New("not joined this challenge")
+)
+
+const dateLayout = "2006-01-02"
+
+type ChallengeService struct {
+	challengeRepo *repository.ChallengeRepository
+	checkinRepo   *repository.CheckinRepository
+	userRepo      *repository.UserRepository
+	auditService  *AuditService
+	location      *time.Location
+}
+
+func NewChallengeService() *ChallengeService {
+	loc := time.Local
+	cfg := config.Get()
+	if cfg != nil && cfg.Server.Timezone != "" {
+		if l, err := time.LoadLocation(cfg.Server.Timezone); err == nil {
+			loc = l
+		}
+	}
+
+	return &ChallengeService{
+		challengeRepo: repository.NewChallengeRepository(),
+		checkinRepo:   repository.NewCheckinRepository(),
+		userRepo:      repository.NewUserRepository(),
+		auditService:  NewAuditService(),
+		location:      loc,
+	}
+}
+
+// ChallengeRequest 创建/更新挑战请求
+type ChallengeRequest struct {
+	Title       string `json:"title" binding:"required,min=1,max=100"`
+	Description string `json:"description" binding:"max=500"`
+	GoalType    string `json:"goal_type" binding:"required"`
+	GoalValue   int    `json:"goal_value" binding:"required,min=1,max=100000"`
+	StartDate   string `json:"start_date" binding:"required"`
+	EndDate     string `json:"end_date" binding:"required"`
+}
+
+// ChallengeInfo 挑战列表项
+type ChallengeInfo struct {
+	*model.Challenge
+	Status           string `json:"status"` // upcoming / active / ended
+	ParticipantCount int64  `json:"participant_count"`
+	Joined           bool   `json:"joined"`
+}
+
+// ParticipantProgress 参与者进度
+type ParticipantProgress struct {
+	UserID      uint       `json:"user_id"`
+	Username    string     `json:"username"`
+	DisplayName string     `json:"display_name"`
+	Progress    int        `json:"progress"`
+	Percent     int        `json:"percent"`
+	Completed   bool       `json:"completed"`
+	CompletedAt *time.Time `json:"completed_at"`
+	Rank        int        `json:"rank"`
+}
+
+// ChallengeProgressResponse 挑战进度响应
+type ChallengeProgressResponse struct {
+	Challenge    *ChallengeInfo        `json:"challenge"`
+	Mine         *ParticipantProgress  `json:"mine"`
+	Participants []ParticipantProgress `json:"participants"`
+}
+
+// ChallengeRankingResponse 挑战结束后的完成排名
+type ChallengeRankingResponse struct {
+	Challenge *ChallengeInfo        `json:"challenge"`
+	Finishers []ParticipantProgress `json:"finishers"`
+	Others    []ParticipantProgress `json:"others"`
+}
+
+func isValidGoalType(goalType string) bool {
+	switch goalType {
+	case model.ChallengeGoalSessions, model.ChallengeGoalMinutes, model.ChallengeGoalStreak:
+		return true
+	}
+	return false
+}
+
+func (s *ChallengeService) today() string {
+	return time.Now().In(s.location).Format(dateLayout)
+}
+
+// bounds 返回挑战的时间区间 [start, end)
+func (s *ChallengeService) bounds(c *model.Challenge) (time.Time, time.Time) {
+	start, _ := time.ParseInLocation(dateLayout, c.StartDate, s.location)
+	end, _ := time.ParseInLocation(dateLayout, c.EndDate, s.location)
+	return start, end.AddDate(0, 0, 1)
+}
+
+func (s *ChallengeService) status(c *model.Challenge) string {
+	today := s.today()
+	switch {
+	case today < c.StartDate:
+		return "upcoming"
+	case today > c.EndDate:
+		return "ended"
+	default:
+		return "active"
+	}
+}
+
+func (s *ChallengeService) validate(req *ChallengeRequest) error {
+	if !isValidGoalType(req.GoalType) {
+		return ErrInvalidChallengeGoal
+	}
+	start, err := time.ParseInLocation(dateLayout, req.StartDate, s.location)
+	if err != nil {
+		return ErrInvalidChallengeDate
+	}
+	end, err := time.ParseInLocation(dateLayout, req.EndDate, s.location)
+	if err != nil || end.Before(start) {
+		return ErrInvalidChallengeDate
+	}
+	if end.Sub(start) > 366*24*time.Hour {
+		return ErrInvalidChallengeDate
+	}
+	return nil
+}
+
+func (s *ChallengeService) getChallenge(id uint) (*model.Challenge, error) {
+	challenge, err := s.challengeRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrChallengeNotFound
+		}
+		return nil, err
+	}
+	return challenge, nil
+}
+
+func (s *ChallengeService) buildInfo(c *model.Challenge, joined bool) (*ChallengeInfo, error) {
+	count, err := s.challengeRepo.CountParticipants(c.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &ChallengeInfo{
+		Challenge:        c,
+		Status:           s.status(c),
+		ParticipantCount: count,
+		Joined:           joined,
+	}, nil
+}
+
+// CreateChallenge 创建挑战（管理员功能）
+func (s *ChallengeService) CreateChallenge(actor AuditActor, req *ChallengeRequest) (*model.Challenge, error) {
+	if err := s.validate(req); err != nil {
+		return nil, err
+	}
+
+	challenge := &model.Challenge{
+		Title:       strings.TrimSpace(req.Title),
+		Description: strings.TrimSpace(req.Description),
+		GoalType:    req.GoalType,
+		GoalValue:   req.GoalValue,
+		StartDate:   req.StartDate,
+		EndDate:     req.EndDate,
+		CreatorID:   actor.ID,
+	}
+	entry := &AuditEntry{
+		Action:     "challenge.create",
+		TargetType: model.AuditTargetChallenge,
+		After:      challenge,
+	}
+	err := s.auditService.Record(actor, entry, func(tx *gorm.DB) error {
+		if err := s.challengeRepo.WithTx(tx).Create(challenge); err != nil {
+			return err
+		}
+		entry.TargetID = auditID(challenge.ID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// UpdateChallenge 更新挑战（管理员功能），已结算的挑战不可修改
+// 开始后只能修改标题和描述，日期和目标不再变化，避免参与者的进度和排名失去依据
+func (s *ChallengeService) UpdateChallenge(actor AuditActor, id uint, req *ChallengeRequest) (*model.Challenge, error) {
+	if err := s.validate(req); err != nil {
+		return nil, err
+	}
+
+	challenge, err := s.getChallenge(id)
+	if err != nil {
+		return nil, err
+	}
+	if challenge.FinalizedAt != nil {
+		return nil, ErrChallengeEnded
+	}
+	if s.status(challenge) != "upcoming" && (req.GoalType != challenge.GoalType || req.GoalValue != challenge.GoalValue ||
+		req.StartDate != challenge.StartDate || req.EndDate != challenge.EndDate) {
+		return nil, ErrChallengeStarted
+	}
+	before := *challenge
+
+	challenge.Title = strings.TrimSpace(req.Title)
+	challenge.Description = strings.TrimSpace(req.Description)
+	challenge.GoalType = req.GoalType
+	challenge.GoalValue = req.GoalValue
+	challenge.StartDate = req.StartDate
+	challenge.EndDate = req.EndDate
+
+	err = s.auditService.Record(actor, &AuditEntry{
+		Action:     "challenge.update",
+		TargetType: model.AuditTargetChallenge,
+		TargetID:   auditID(id),
+		Before:     before,
+		After:      challenge,
+	}, func(tx *gorm.DB) error {
+		return s.challengeRepo.WithTx(tx).Update(challenge)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// DeleteChallenge 删除挑战（管理员功能）
+func (s *ChallengeService) DeleteChallenge(actor AuditActor, id uint) error {
+	challenge, err := s.getChallenge(id)
+	if err != nil {
+		return err
+	}
+	return s.auditService.Record(actor, &AuditEntry{
+		Action:     "challenge.delete",
+		TargetType: model.AuditTargetChallenge,
+		TargetID:   auditID(id),
+		Before:     challenge,
+	}, func(tx *gorm.DB) error {
+		return s.challengeRepo.WithTx(tx).Delete(id)
+	})
+}
+
+// ListChallenges 获取挑战列表
+func (s *ChallengeService) ListChallenges(userID uint, status string) ([]ChallengeInfo, error) {
+	challenges, err := s.challengeRepo.List(status, s.today())
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, len(challenges))
+	for i, c := range challenges {
+		ids[i] = c.ID
+	}
+	joined, err := s.challengeRepo.GetJoinedChallengeIDs(userID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ChallengeInfo, 0, len(challenges))
+	for i := range challenges {
+		info, err := s.buildInfo(&challenges[i], joined[challenges[i].ID])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *info)
+	}
+	return result, nil
+}
+
+// JoinChallenge 参加挑战，已结束的挑战不可参加
+func (s *ChallengeService) JoinChallenge(userID, challengeID uint) error {
+	challenge, err := s.getChallenge(challengeID)
+	if err != nil {
+		return err
+	}
+	if s.status(challenge) == "ended" {
+		return ErrChallengeEnded
+	}
+
+	if _, err := s.challengeRepo.GetParticipant(challengeID, userID); err == nil {
+		return ErrAlreadyJoined
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	return s.challengeRepo.AddParticipant(&model.ChallengeParticipant{
+		ChallengeID: challengeID,
+		UserID:      userID,
+	})
+}
+
+// LeaveChallenge 退出挑战，已结束的挑战不可退出
+func (s *ChallengeService) LeaveChallenge(userID, challengeID uint) error {
+	challenge, err := s.getChallenge(challengeID)
+	if err != nil {
+		return err
+	}
+	if s.status(challenge) == "ended" {
+		return ErrChallengeEnded
+	}
+
+	if _, err := s.challengeRepo.GetParticipant(challengeID, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotJoined
+		}
+		return err
+	}
+
+	return s.challengeRepo.RemoveParticipant(challengeID, userID)
+}
+
+// GetProgress 根据打卡记录实时计算每位参与者的进度
+func (s *ChallengeService) GetProgress(userID, challengeID uint) (*ChallengeProgressResponse, error) {
+	challenge, err := s.getChallenge(challengeID)
+	if err != nil {
+		return nil, err
+	}
+
+	participants, err := s.challengeRepo.GetVisibleParticipants(challengeID)
+	if err != nil {
+		return nil, err
+	}
+
+	progress, err := s.evaluate(challenge, participants)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &ChallengeProgressResponse{Participants: progress}
+	joined := false
+	for i := range progress {
+		if progress[i].UserID == userID {
+			resp.Mine = &progress[i]
+			joined = true
+			break
+		}
+	}
+
+	resp.Challenge, err = s.buildInfo(challenge, joined)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetRanking 获取挑战结束后的完成排名
+// 首次查询时按打卡记录结算并持久化，之后直接返回已保存的结果
+// 结算计入所有参与者，封禁中的用户只是不展示，解封后按保存的名次重新出现
+func (s *ChallengeService) GetRanking(userID, challengeID uint) (*ChallengeRankingResponse, error) {
+	challenge, err := s.getChallenge(challengeID)
+	if err != nil {
+		return nil, err
+	}
+	if s.status(challenge) != "ended" {
+		return nil, ErrChallengeNotEnded
+	}
+
+	if challenge.FinalizedAt == nil {
+		if err := s.finalize(challenge); err != nil {
+			return nil, err
+		}
+	}
+
+	participants, err := s.challengeRepo.GetVisibleParticipants(challengeID)
+	if err != nil {
+		return nil, err
+	}
+	progress, err := s.loadResults(challenge, participants)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &ChallengeRankingResponse{
+		Finishers: []ParticipantProgress{},
+		Others:    []ParticipantProgress{},
+	}
+	joined := false
+	for _, p := range progress {
+		if p.UserID == userID {
+			joined = true
+		}
+		if p.Completed {
+			resp.Finishers = append(resp.Finishers, p)
+		} else {
+			resp.Others = append(resp.Others, p)
+		}
+	}
+
+	resp.Challenge, err = s.buildInfo(challenge, joined)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// finalize 结算挑战：计算全部参与者的最终进度和完成排名并保存
+func (s *ChallengeService) finalize(challenge *model.Challenge) error {
+	participants, err := s.challengeRepo.GetParticipants(challenge.ID)
+	if err != nil {
+		return err
+	}
+	progress, err := s.evaluate(challenge, participants)
+	if err != nil {
+		return err
+	}
+
+	byUser := make(map[uint]ParticipantProgress, len(progress))
+	for _, p := range progress {
+		byUser[p.UserID] = p
+	}
+	for i := range participants {
+		p := byUser[participants[i].UserID]
+		participants[i].Progress = p.Progress
+		participants[i].CompletedAt = p.CompletedAt
+		participants[i].Rank = p.Rank
+	}
+
+	now := time.Now()
+	challenge.FinalizedAt = &now
+	saved, err := s.challengeRepo.SaveResults(challenge, participants)
+	if err != nil {
+		return err
+	}
+	if !saved {
+		// 已由并发请求结算，以保存的结果为准
+		current, err := s.getChallenge(challenge.ID)
+		if err != nil {
+			return err
+		}
+		*challenge = *current
+	}
+	return nil
+}
+
+// loadResults 读取已结算的结果
+func (s *ChallengeService) loadResults(challenge *model.Challenge, participants []model.ChallengeParticipant) ([]ParticipantProgress, error) {
+	users, err := s.participantUsers(participants)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := make([]ParticipantProgress, 0, len(participants))
+	for _, p := range participants {
+		user := users[p.UserID]
+		progress = append(progress, ParticipantProgress{
+			UserID:      p.UserID,
+			Username:    user.Username,
+			DisplayName: user.DisplayName,
+			Progress:    p.Progress,
+			Percent:     percentOf(p.Progress, challenge.GoalValue),
+			Completed:   p.CompletedAt != nil,
+			CompletedAt: p.CompletedAt,
+			Rank:        p.Rank,
+		})
+	}
+	sortProgress(progress)
+	return progress, nil
+}
+
+// evaluate 根据挑战期内的打卡记录计算参与者进度，并按完成情况排序
+func (s *ChallengeService) evaluate(challenge *model.Challenge, participants []model.ChallengeParticipant) ([]ParticipantProgress, error) {
+	users, err := s.participantUsers(participants)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, len(participants))
+	for i, p := range participants {
+		ids[i] = p.UserID
+	}
+
+	start, end := s.bounds(challenge)
+	checkins, err := s.checkinRepo.GetByUserIDsAndDateRange(ids, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	byUser := make(map[uint][]model.Checkin)
+	for _, c := range checkins {
+		byUser[c.UserID] = append(byUser[c.UserID], c)
+	}
+
+	progress := make([]ParticipantProgress, 0, len(participants))
+	for _, p := range participants {
+		value, completedAt := s.evaluateCheckins(challenge, byUser[p.UserID])
+		user := users[p.UserID]
+		progress = append(progress, ParticipantProgress{
+			UserID:      p.UserID,
+			Username:    user.Username,
+			DisplayName: user.DisplayName,
+			Progress:    value,
+			Percent:     percentOf(value, challenge.GoalValue),
+			Completed:   completedAt != nil,
+			CompletedAt: completedAt,
+		})
+	}
+
+	sortProgress(progress)
+	rank := 0
+	for i := range progress {
+		if progress[i].Completed {
+			rank++
+			progress[i].Rank = rank
+		}
+	}
+	return progress, nil
+}
+
+// evaluateCheckins 计算单个用户的进度值及达成目标的时间
+// checkins 需按打卡时间升序排列
+func (s *ChallengeService) evaluateCheckins(challenge *model.Challenge, checkins []model.Checkin) (int, *time.Time) {
+	var completedAt *time.Time
+	markCompleted := func(value int, at time.Time) {
+		if completedAt == nil && value >= challenge.GoalValue {
+			t := at
+			completedAt = &t
+		}
+	}
+
+	switch challenge.GoalType {
+	case model.ChallengeGoalSessions:
+		for i, c := range checkins {
+			markCompleted(i+1, c.CheckedAt)
+		}
+		return len(checkins), completedAt
+
+	case model.ChallengeGoalMinutes:
+		seconds := 0
+		for _, c := range checkins {
+			seconds += c.Duration
+			markCompleted(seconds/60, c.CheckedAt)
+		}
+		return seconds / 60, completedAt
+
+	case model.ChallengeGoalStreak:
+		longest, current := 0, 0
+		var lastDate time.Time
+		for _, c := range checkins {
+			local := c.CheckedAt.In(s.location)
+			date := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, s.location)
+			switch {
+			case current == 0:
+				current = 1
+			case date.Equal(lastDate):
+				continue
+			case date.Equal(lastDate.AddDate(0, 0, 1)):
+				current++
+			default:
+				current = 1
+			}
+			lastDate = date
+			if current > longest {
+				longest = current
+			}
+			markCompleted(current, c.CheckedAt)
+		}
+		return longest, completedAt
+	}
+
+	return 0, nil
+}
+
+func (s *ChallengeService) participantUsers(participants []model.ChallengeParticipant) (map[uint]model.User, error) {
+	ids := make([]uint, len(participants))
+	for i, p := range participants {
+		ids[i] = p.UserID
+	}
+	users, err := s.userRepo.GetByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]model.User, len(users))
+	for _, u := range users {
+		byID[u.ID] = u
+	}
+	return byID, nil
+}
+
+// sortProgress 完成者按完成时间升序在前，未完成者按进度降序在后
+func sortProgress(progress []ParticipantProgress) {
+	sort.SliceStable(progress, func(i, j int) bool {
+		a, b := progress[i], progress[j]
+		if a.Completed != b.Completed {
+			return a.Completed
+		}
+		if a.Completed {
+			return a.CompletedAt.Before(*b.CompletedAt)
+		}
+		return a.Progress > b.Progress
+	})
+}
+
+func percentOf(value, goal int) int {
+	if goal <= 0 {
+		return 0
+	}
+	percent := value * 100 / goal
+	if percent > 100 {
+		percent = 100
+	}
+	return percent
+}
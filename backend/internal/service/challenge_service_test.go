@@ -0,0 +1,165 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/pkg/database"
+)
+
+// createUser 创建普通用户，suspended 为 true 时处于永久封禁
+func createUser(t *testing.T, suspended bool) *model.User {
+	t.Helper()
+	user := &model.User{
+		Username:    uniqueName("user"),
+		DisplayName: "用户",
+	}
+	if suspended {
+		now := time.Now()
+		user.SuspendedAt = &now
+	}
+	if err := database.Get().Create(user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user
+}
+
+func TestChallengeProgressHidesSuspendedParticipants(t *testing.T) {
+	active := createUser(t, false)
+	suspended := createUser(t, true)
+
+	today := time.Now()
+	challenge := &model.Challenge{
+		Title:     uniqueName("challenge"),
+		GoalType:  model.ChallengeGoalSessions,
+		GoalValue: 3,
+		StartDate: today.AddDate(0, 0, -1).Format(dateLayout),
+		EndDate:   today.AddDate(0, 0, 1).Format(dateLayout),
+	}
+	if err := database.Get().Create(challenge).Error; err != nil {
+		t.Fatalf("create challenge: %v", err)
+	}
+	for _, u := range []*model.User{active, suspended} {
+		p := &model.ChallengeParticipant{ChallengeID: challenge.ID, UserID: u.ID}
+		if err := database.Get().Create(p).Error; err != nil {
+			t.Fatalf("create participant: %v", err)
+		}
+	}
+
+	resp, err := NewChallengeService().GetProgress(suspended.ID, challenge.ID)
+	if err != nil {
+		t.Fatalf("GetProgress: %v", err)
+	}
+	if len(resp.Participants) != 1 || resp.Participants[0].UserID != active.ID {
+		t.Fatalf("participants = %+v, want only user %d", resp.Participants, active.ID)
+	}
+	if resp.Mine != nil {
+		t.Fatal("suspended participant returned as Mine")
+	}
+}
+
+func TestChallengeFinalizeIncludesSuspendedParticipants(t *testing.T) {
+	s := NewChallengeService()
+	active := createUser(t, false)
+	suspended := createUser(t, true)
+
+	today := time.Now()
+	challenge := &model.Challenge{
+		Title:     uniqueName("challenge"),
+		GoalType:  model.ChallengeGoalSessions,
+		GoalValue: 1,
+		StartDate: today.AddDate(0, 0, -5).Format(dateLayout),
+		EndDate:   today.AddDate(0, 0, -2).Format(dateLayout),
+	}
+	if err := database.Get().Create(challenge).Error; err != nil {
+		t.Fatalf("create challenge: %v", err)
+	}
+	// 封禁用户先完成挑战
+	for i, u := range []*model.User{suspended, active} {
+		p := &model.ChallengeParticipant{ChallengeID: challenge.ID, UserID: u.ID}
+		if err := database.Get().Create(p).Error; err != nil {
+			t.Fatalf("create participant: %v", err)
+		}
+		checkin := &model.Checkin{UserID: u.ID, Duration: 600, Cycles: 1, CheckedAt: today.AddDate(0, 0, -4).Add(time.Duration(i) * time.Hour)}
+		if err := database.Get().Create(checkin).Error; err != nil {
+			t.Fatalf("create checkin: %v", err)
+		}
+	}
+
+	resp, err := s.GetRanking(active.ID, challenge.ID)
+	if err != nil {
+		t.Fatalf("GetRanking: %v", err)
+	}
+	if len(resp.Finishers) != 1 || resp.Finishers[0].UserID != active.ID || resp.Finishers[0].Rank != 2 {
+		t.Fatalf("finishers = %+v, want only user %d with rank 2", resp.Finishers, active.ID)
+	}
+
+	// 封禁中的参与者同样结算，解封后按保存的名次显示
+	if err := database.Get().Model(suspended).Update("suspended_at", nil).Error; err != nil {
+		t.Fatalf("unsuspend: %v", err)
+	}
+	resp, err = s.GetRanking(active.ID, challenge.ID)
+	if err != nil {
+		t.Fatalf("GetRanking: %v", err)
+	}
+	if len(resp.Finishers) != 2 || resp.Finishers[0].UserID != suspended.ID || resp.Finishers[0].Rank != 1 {
+		t.Fatalf("finishers after unsuspend = %+v, want user %d first", resp.Finishers, suspended.ID)
+	}
+}
+
+func TestUpdateChallengeAfterStart(t *testing.T) {
+	s := NewChallengeService()
+	admin := createUser(t, false)
+
+	today := time.Now()
+	challenge := &model.Challenge{
+		Title:     uniqueName("challenge"),
+		GoalType:  model.ChallengeGoalSessions,
+		GoalValue: 3,
+		StartDate: today.AddDate(0, 0, -1).Format(dateLayout),
+		EndDate:   today.AddDate(0, 0, 5).Format(dateLayout),
+	}
+	if err := database.Get().Create(challenge).Error; err != nil {
+		t.Fatalf("create challenge: %v", err)
+	}
+	req := func(change func(r *ChallengeRequest)) *ChallengeRequest {
+		r := &ChallengeRequest{
+			Title:     challenge.Title,
+			GoalType:  challenge.GoalType,
+			GoalValue: challenge.GoalValue,
+			StartDate: challenge.StartDate,
+			EndDate:   challenge.EndDate,
+		}
+		change(r)
+		return r
+	}
+
+	tests := []struct {
+		name   string
+		change func(r *ChallengeRequest)
+	}{
+		{"goal value", func(r *ChallengeRequest) { r.GoalValue = 5 }},
+		{"goal type", func(r *ChallengeRequest) { r.GoalType = model.ChallengeGoalMinutes }},
+		{"start date", func(r *ChallengeRequest) { r.StartDate = today.Format(dateLayout) }},
+		{"end date", func(r *ChallengeRequest) { r.EndDate = today.AddDate(0, 0, 10).Format(dateLayout) }},
+	}
+	for _, tt := range tests {
+		if _, err := s.UpdateChallenge(AuditActor{ID: admin.ID}, challenge.ID, req(tt.change)); !errors.Is(err, ErrChallengeStarted) {
+			t.Errorf("%s: err = %v, want ErrChallengeStarted", tt.name, err)
+		}
+	}
+
+	// 标题和描述仍可修改
+	updated, err := s.UpdateChallenge(AuditActor{ID: admin.ID}, challenge.ID, req(func(r *ChallengeRequest) {
+		r.Title = "新标题"
+		r.Description = "新描述"
+	}))
+	if err != nil {
+		t.Fatalf("UpdateChallenge: %v", err)
+	}
+	if updated.Title != "新标题" || updated.GoalValue != 3 {
+		t.Fatalf("updated challenge = %+v", updated)
+	}
+}
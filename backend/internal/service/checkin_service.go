@@ -2,10 +2,12 @@ package service
 
 import (
 	"errors"
+	"log"
 	"time"
 
 	"tidalcore-backend/config"
 	"tidalcore-backend/internal/model"
+	"tidalcore-backend/internal/realtime"
 	"tidalcore-backend/internal/repository"
 )
 
@@ -14,9 +16,10 @@ var (
 )
 
 type CheckinService struct {
-	checkinRepo *repository.CheckinRepository
-	userRepo    *repository.UserRepository
-	location    *time.Location
+	checkinRepo   *repository.CheckinRepository
+	userRepo      *repository.UserRepository
+	notifications *NotificationService
+	location      *time.Location
 }
 
 func NewCheckinService() *CheckinService {
@@ -29,9 +32,10 @@ func NewCheckinService() *CheckinService {
 	}
 
 	return &CheckinService{
-		checkinRepo: repository.NewCheckinRepository(),
-		userRepo:    repository.NewUserRepository(),
-		location:    loc,
+		checkinRepo:   repository.NewCheckinRepository(),
+		userRepo:      repository.NewUserRepository(),
+		notifications: NewNotificationService(),
+		location:      loc,
 	}
 }
 
@@ -77,10 +81,13 @@ func (s *CheckinService) Checkin(userID uint, req *CheckinRequest) (*CheckinResp
 	user.TotalCheckin++
 	user.LastCheckin = &now
 
-	if err := s.userRepo.Update(user); err != nil {
+	if err := s.userRepo.UpdateFields(user, "streak", "max_streak", "total_checkin", "last_checkin"); err != nil {
 		return nil, err
 	}
 
+	s.notifications.NotifyStreakMilestone(userID, user.Streak)
+	s.publishStats()
+
 	return &CheckinResponse{
 		Checkin:       checkin,
 		CurrentStreak: user.Streak,
@@ -89,6 +96,19 @@ func (s *CheckinService) Checkin(userID uint, req *CheckinRequest) (*CheckinResp
 	}, nil
 }
 
+// publishStats 推送全站今日打卡人数
+func (s *CheckinService) publishStats() {
+	count, err := s.checkinRepo.CountTodayUsers(s.location)
+	if err != nil {
+		log.Printf("Warning: Failed to count today's checkins: %v", err)
+		return
+	}
+	realtime.Default().Publish(realtime.EventCheckinStats, map[string]interface{}{
+		"date":           time.Now().In(s.location).Format("2006-01-02"),
+		"today_checkins": count,
+	})
+}
+
 func (s *CheckinService) updateStreak(user *model.User, now time.Time) {
 	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.location)
 
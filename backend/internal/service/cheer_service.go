@@ -0,0 +1,141 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"tidalcore-backend/config"
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/internal/repository"
+)
+
+var (
+	ErrInvalidCheerKind  = errors.New("invalid cheer kind")
+	ErrCheerSelf         = errors.New("cannot cheer yourself")
+	ErrCheerNotCheckedIn = errors.New("receiver has not checked in today")
+	ErrCheerAlreadySent  = errors.New("already cheered this user today")
+	ErrCheerDailyLimit   = errors.New("daily cheer limit reached")
+	ErrCheerUserNotFound = errors.New("receiver not found")
+)
+
+// MaxCheersPerDay 每位用户每天最多发出的鼓励数
+const MaxCheersPerDay = 20
+
+type CheerService struct {
+	cheerRepo     *repository.CheerRepository
+	userRepo      *repository.UserRepository
+	checkinRepo   *repository.CheckinRepository
+	notifications *NotificationService
+	location      *time.Location
+}
+
+func NewCheerService() *CheerService {
+	loc := time.Local
+	cfg := config.Get()
+	if cfg != nil && cfg.Server.Timezone != "" {
+		if l, err := time.LoadLocation(cfg.Server.Timezone); err == nil {
+			loc = l
+		}
+	}
+
+	return &CheerService{
+		cheerRepo:     repository.NewCheerRepository(),
+		userRepo:      repository.NewUserRepository(),
+		checkinRepo:   repository.NewCheckinRepository(),
+		notifications: NewNotificationService(),
+		location:      loc,
+	}
+}
+
+type SendCheerRequest struct {
+	ReceiverID uint   `json:"receiver_id" binding:"required"`
+	Kind       string `json:"kind" binding:"required"`
+}
+
+func isValidCheerKind(kind string) bool {
+	switch kind {
+	case model.CheerKindClap, model.CheerKindFire, model.CheerKindWave, model.CheerKindStrong:
+		return true
+	}
+	return false
+}
+
+// SendCheer 给今日已打卡的用户发送鼓励
+// 每天对同一用户只能鼓励一次，且每天发出的总数有上限
+func (s *CheerService) SendCheer(senderID uint, req *SendCheerRequest) (*model.Cheer, error) {
+	if !isValidCheerKind(req.Kind) {
+		return nil, ErrInvalidCheerKind
+	}
+	if req.ReceiverID == senderID {
+		return nil, ErrCheerSelf
+	}
+
+	// 封禁中的用户不能接收鼓励
+	if _, err := s.userRepo.GetActiveByID(req.ReceiverID); err != nil {
+		return nil, ErrCheerUserNotFound
+	}
+
+	sender, err := s.userRepo.GetByID(senderID)
+	if err != nil {
+		return nil, err
+	}
+
+	checkedIn, err := s.checkinRepo.HasCheckedToday(req.ReceiverID, s.location)
+	if err != nil {
+		return nil, err
+	}
+	if !checkedIn {
+		return nil, ErrCheerNotCheckedIn
+	}
+
+	today := time.Now().In(s.location).Format("2006-01-02")
+
+	sent, err := s.cheerRepo.CountSentOnDate(senderID, today)
+	if err != nil {
+		return nil, err
+	}
+	if sent >= MaxCheersPerDay {
+		return nil, ErrCheerDailyLimit
+	}
+
+	exists, err := s.cheerRepo.ExistsOnDate(senderID, req.ReceiverID, today)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrCheerAlreadySent
+	}
+
+	cheer := &model.Cheer{
+		SenderID:   senderID,
+		ReceiverID: req.ReceiverID,
+		Kind:       req.Kind,
+		SentDate:   today,
+	}
+	if err := s.cheerRepo.Create(cheer); err != nil {
+		// 并发请求在唯一索引上冲突
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrCheerAlreadySent
+		}
+		return nil, err
+	}
+
+	title := fmt.Sprintf("%s 为你的打卡送来了鼓励", sender.DisplayName)
+	if err := s.notifications.Notify(req.ReceiverID, model.NotificationCheer, title, ""); err != nil {
+		log.Printf("Warning: Failed to send cheer notification to user %d: %v", req.ReceiverID, err)
+	}
+
+	return cheer, nil
+}
+
+// GetReceived 获取收到的鼓励
+func (s *CheerService) GetReceived(userID uint, limit int) ([]repository.ReceivedCheer, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 30
+	}
+	return s.cheerRepo.GetReceived(userID, limit)
+}
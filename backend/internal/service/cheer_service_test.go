@@ -0,0 +1,26 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"tidalcore-backend/internal/model"
+)
+
+func TestSendCheerRejectsSuspendedReceiver(t *testing.T) {
+	sender := createUser(t, false)
+	suspended := createUser(t, true)
+	active := createUser(t, false)
+	s := NewCheerService()
+
+	_, err := s.SendCheer(sender.ID, &SendCheerRequest{ReceiverID: suspended.ID, Kind: model.CheerKindClap})
+	if !errors.Is(err, ErrCheerUserNotFound) {
+		t.Fatalf("cheer suspended user: err = %v, want ErrCheerUserNotFound", err)
+	}
+
+	// 正常用户能通过查找，因今日未打卡被拒绝
+	_, err = s.SendCheer(sender.ID, &SendCheerRequest{ReceiverID: active.ID, Kind: model.CheerKindClap})
+	if !errors.Is(err, ErrCheerNotCheckedIn) {
+		t.Fatalf("cheer active user: err = %v, want ErrCheerNotCheckedIn", err)
+	}
+}
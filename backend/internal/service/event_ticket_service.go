@@ -0,0 +1,82 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"tidalcore-backend/internal/auth"
+)
+
+var ErrInvalidEventTicket = errors.New("invalid or expired event ticket")
+
+// eventTicketTTL 事件流票据有效期，票据签发后应立即用于建立连接
+const eventTicketTTL = 30 * time.Second
+
+type eventTicket struct {
+	claims    *auth.Claims
+	expiresAt time.Time
+}
+
+// EventTicketService 事件流票据
+// EventSource 无法设置请求头，访问令牌放在查询参数中会被写入访问日志，
+// 因此先用访问令牌换取短期、一次性的票据，再通过 ticket 查询参数建立连接
+type EventTicketService struct {
+	mu      sync.Mutex
+	tickets map[string]*eventTicket // 键为票据摘要
+}
+
+var (
+	eventTicketService     *EventTicketService
+	eventTicketServiceOnce sync.Once
+)
+
+// GetEventTicketService 获取全局事件流票据服务，票据需在所有请求间共享
+func GetEventTicketService() *EventTicketService {
+	eventTicketServiceOnce.Do(func() {
+		eventTicketService = &EventTicketService{
+			tickets: make(map[string]*eventTicket),
+		}
+	})
+	return eventTicketService
+}
+
+// Issue 为访问令牌签发票据，票据继承令牌的用户和会话
+func (s *EventTicketService) Issue(claims *auth.Claims) (string, time.Time, error) {
+	ticket, err := auth.GenerateRandomToken(32)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(eventTicketTTL)
+	s.mu.Lock()
+	for key, t := range s.tickets {
+		if now.After(t.expiresAt) {
+			delete(s.tickets, key)
+		}
+	}
+	s.tickets[auth.HashToken(ticket)] = &eventTicket{claims: claims, expiresAt: expiresAt}
+	s.mu.Unlock()
+
+	return ticket, expiresAt, nil
+}
+
+// Redeem 使用票据，返回签发票据的访问令牌信息；票据只能使用一次
+func (s *EventTicketService) Redeem(ticket string) (*auth.Claims, error) {
+	key := auth.HashToken(ticket)
+
+	s.mu.Lock()
+	t, ok := s.tickets[key]
+	delete(s.tickets, key)
+	s.mu.Unlock()
+
+	if !ok || time.Now().After(t.expiresAt) {
+		return nil, ErrInvalidEventTicket
+	}
+	// 签发票据后令牌可能已被吊销
+	if GetRevocationService().IsRevoked(t.claims) {
+		return nil, ErrInvalidEventTicket
+	}
+	return t.claims, nil
+}
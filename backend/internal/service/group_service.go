@@ -0,0 +1,321 @@
+package service
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"tidalcore-backend/config"
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/internal/repository"
+)
+
+var (
+	ErrGroupNotFound      = errors.New("group not found")
+	ErrInvalidInviteCode  = errors.New("invalid invite code")
+	ErrAlreadyGroupMember = errors.New("already a member of this group")
+	ErrNotGroupMember     = errors.New("not a member of this group")
+	ErrNotGroupOwner      = errors.New("only the group owner can do this")
+	ErrOwnerCannotLeave   = errors.New("group owner cannot leave, dissolve the group instead")
+	ErrTooManyGroups      = errors.New("group limit reached")
+)
+
+const (
+	MaxGroupsPerUser = 20
+	inviteCodeLength = 8
+	// 去除易混淆字符 0/O、1/I/L
+	inviteCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+)
+
+type GroupService struct {
+	groupRepo   *repository.GroupRepository
+	userRepo    *repository.UserRepository
+	checkinRepo *repository.CheckinRepository
+	location    *time.Location
+}
+
+func NewGroupService() *GroupService {
+	loc := time.Local
+	cfg := config.Get()
+	if cfg != nil && cfg.Server.Timezone != "" {
+		if l, err := time.LoadLocation(cfg.Server.Timezone); err == nil {
+			loc = l
+		}
+	}
+
+	return &GroupService{
+		groupRepo:   repository.NewGroupRepository(),
+		userRepo:    repository.NewUserRepository(),
+		checkinRepo: repository.NewCheckinRepository(),
+		location:    loc,
+	}
+}
+
+type CreateGroupRequest struct {
+	Name        string `json:"name" binding:"required,min=1,max=50"`
+	Description string `json:"description" binding:"max=255"`
+}
+
+type JoinGroupRequest struct {
+	InviteCode string `json:"invite_code" binding:"required"`
+}
+
+type GroupDetail struct {
+	*model.Group
+	MyRole  string                       `json:"my_role"`
+	Members []repository.GroupMemberInfo `json:"members"`
+}
+
+// generateCode 生成指定长度的随机码，不含易混淆字符，用于邀请码、重置码等需要人工转述的场景
+func generateCode(length int) (string, error) {
+	var sb strings.Builder
+	alphabetSize := big.NewInt(int64(len(inviteCodeAlphabet)))
+	for i := 0; i < length; i++ {
+		n, err := rand.Int(rand.Reader, alphabetSize)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteByte(inviteCodeAlphabet[n.Int64()])
+	}
+	return sb.String(), nil
+}
+
+// CreateGroup 创建小组，创建者自动成为组长
+func (s *GroupService) CreateGroup(userID uint, req *CreateGroupRequest) (*model.Group, error) {
+	count, err := s.groupRepo.CountByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= MaxGroupsPerUser {
+		return nil, ErrTooManyGroups
+	}
+
+	code, err := generateCode(inviteCodeLength)
+	if err != nil {
+		return nil, err
+	}
+
+	group := &model.Group{
+		Name:        strings.TrimSpace(req.Name),
+		Description: strings.TrimSpace(req.Description),
+		OwnerID:     userID,
+		InviteCode:  code,
+		MemberCount: 1,
+	}
+
+	if err := s.groupRepo.CreateWithOwner(group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// JoinGroup 通过邀请码加入小组
+func (s *GroupService) JoinGroup(userID uint, req *JoinGroupRequest) (*model.Group, error) {
+	code := strings.ToUpper(strings.TrimSpace(req.InviteCode))
+	group, err := s.groupRepo.GetByInviteCode(code)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidInviteCode
+		}
+		return nil, err
+	}
+
+	if _, err := s.groupRepo.GetMember(group.ID, userID); err == nil {
+		return nil, ErrAlreadyGroupMember
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	count, err := s.groupRepo.CountByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= MaxGroupsPerUser {
+		return nil, ErrTooManyGroups
+	}
+
+	member := &model.GroupMember{
+		GroupID: group.ID,
+		UserID:  userID,
+		Role:    model.GroupRoleMember,
+	}
+	if err := s.groupRepo.AddMember(member); err != nil {
+		return nil, err
+	}
+
+	if err := s.fillMemberCount(group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// LeaveGroup 退出小组
+func (s *GroupService) LeaveGroup(userID, groupID uint) error {
+	member, err := s.requireMember(userID, groupID)
+	if err != nil {
+		return err
+	}
+	if member.Role == model.GroupRoleOwner {
+		return ErrOwnerCannotLeave
+	}
+	return s.groupRepo.RemoveMember(groupID, userID)
+}
+
+// DeleteGroup 解散小组（仅组长）
+func (s *GroupService) DeleteGroup(userID, groupID uint) error {
+	if _, err := s.requireOwner(userID, groupID); err != nil {
+		return err
+	}
+	return s.groupRepo.Delete(groupID)
+}
+
+// RemoveMember 移除成员（仅组长）
+func (s *GroupService) RemoveMember(userID, groupID, targetUserID uint) error {
+	if _, err := s.requireOwner(userID, groupID); err != nil {
+		return err
+	}
+	if targetUserID == userID {
+		return ErrOwnerCannotLeave
+	}
+	if _, err := s.groupRepo.GetMember(groupID, targetUserID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotGroupMember
+		}
+		return err
+	}
+	return s.groupRepo.RemoveMember(groupID, targetUserID)
+}
+
+// RegenerateInviteCode 重新生成邀请码，旧邀请码立即失效（仅组长）
+func (s *GroupService) RegenerateInviteCode(userID, groupID uint) (*model.Group, error) {
+	group, err := s.requireOwner(userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := generateCode(inviteCodeLength)
+	if err != nil {
+		return nil, err
+	}
+	group.InviteCode = code
+	if err := s.groupRepo.Update(group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// GetMyGroups 获取当前用户加入的小组
+func (s *GroupService) GetMyGroups(userID uint) ([]model.Group, error) {
+	groups, err := s.groupRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, len(groups))
+	for i := range groups {
+		ids[i] = groups[i].ID
+	}
+	counts, err := s.groupRepo.CountMembersByGroupIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range groups {
+		groups[i].MemberCount = int(counts[groups[i].ID])
+	}
+	return groups, nil
+}
+
+// GetGroupDetail 获取小组详情及成员列表（仅成员可见）
+func (s *GroupService) GetGroupDetail(userID, groupID uint) (*GroupDetail, error) {
+	member, err := s.requireMember(userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := s.getGroup(groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := s.groupRepo.GetMembers(groupID)
+	if err != nil {
+		return nil, err
+	}
+	group.MemberCount = len(members)
+
+	return &GroupDetail{
+		Group:   group,
+		MyRole:  member.Role,
+		Members: members,
+	}, nil
+}
+
+// GetGroupLeaderboard 获取小组排行榜（仅成员可见）
+func (s *GroupService) GetGroupLeaderboard(userID, groupID uint, limit int) ([]model.User, error) {
+	if _, err := s.requireMember(userID, groupID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return s.userRepo.GetGroupLeaderboard(groupID, limit)
+}
+
+// GetGroupHeatmap 获取小组热力图（仅成员可见）
+func (s *GroupService) GetGroupHeatmap(userID, groupID uint, days int) (map[string]int, error) {
+	if _, err := s.requireMember(userID, groupID); err != nil {
+		return nil, err
+	}
+	if days <= 0 || days > 365 {
+		days = 365
+	}
+	return s.checkinRepo.GetGroupHeatmap(groupID, days, s.location)
+}
+
+func (s *GroupService) getGroup(groupID uint) (*model.Group, error) {
+	group, err := s.groupRepo.GetByID(groupID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGroupNotFound
+		}
+		return nil, err
+	}
+	return group, nil
+}
+
+func (s *GroupService) requireMember(userID, groupID uint) (*model.GroupMember, error) {
+	if _, err := s.getGroup(groupID); err != nil {
+		return nil, err
+	}
+	member, err := s.groupRepo.GetMember(groupID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotGroupMember
+		}
+		return nil, err
+	}
+	return member, nil
+}
+
+func (s *GroupService) requireOwner(userID, groupID uint) (*model.Group, error) {
+	group, err := s.getGroup(groupID)
+	if err != nil {
+		return nil, err
+	}
+	if group.OwnerID != userID {
+		return nil, ErrNotGroupOwner
+	}
+	return group, nil
+}
+
+func (s *GroupService) fillMemberCount(group *model.Group) error {
+	count, err := s.groupRepo.CountMembers(group.ID)
+	if err != nil {
+		return err
+	}
+	group.MemberCount = int(count)
+	return nil
+}
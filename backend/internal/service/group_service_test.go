@@ -0,0 +1,71 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/pkg/database"
+)
+
+func TestGroupHeatmap(t *testing.T) {
+	s := NewGroupService()
+	loc := time.FixedZone("UTC+8", 8*60*60)
+	s.location = loc
+	db := database.Get()
+
+	owner := createUser(t, false)
+	member := createUser(t, false)
+	suspended := createUser(t, true)
+	deleted := createUser(t, false)
+	outsider := createUser(t, false)
+
+	group := &model.Group{Name: uniqueName("group"), OwnerID: owner.ID, InviteCode: uniqueName("code")}
+	if err := db.Create(group).Error; err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	for _, u := range []*model.User{owner, member, suspended, deleted} {
+		if err := db.Create(&model.GroupMember{GroupID: group.ID, UserID: u.ID}).Error; err != nil {
+			t.Fatalf("create member: %v", err)
+		}
+	}
+
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	checkins := []struct {
+		user *model.User
+		at   time.Time
+	}{
+		{owner, today.Add(30 * time.Minute)},
+		{member, today.Add(-30 * time.Minute)}, // 配置时区的昨天 23:30
+		{member, today.Add(-20 * time.Minute)},
+		{suspended, today.Add(30 * time.Minute)},
+		{deleted, today.Add(30 * time.Minute)},
+		{outsider, today.Add(30 * time.Minute)},
+	}
+	for _, c := range checkins {
+		if err := db.Create(&model.Checkin{UserID: c.user.ID, Duration: 600, Cycles: 1, CheckedAt: c.at}).Error; err != nil {
+			t.Fatalf("create checkin: %v", err)
+		}
+	}
+	if err := db.Delete(deleted).Error; err != nil {
+		t.Fatalf("delete user: %v", err)
+	}
+
+	heatmap, err := s.GetGroupHeatmap(owner.ID, group.ID, 7)
+	if err != nil {
+		t.Fatalf("GetGroupHeatmap: %v", err)
+	}
+	want := map[string]int{
+		today.Format(dateLayout):                   1,
+		today.AddDate(0, 0, -1).Format(dateLayout): 1,
+	}
+	if len(heatmap) != len(want) {
+		t.Fatalf("heatmap = %v, want %v", heatmap, want)
+	}
+	for date, count := range want {
+		if heatmap[date] != count {
+			t.Fatalf("heatmap = %v, want %v", heatmap, want)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"tidalcore-backend/config"
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/internal/repository"
+	"tidalcore-backend/pkg/ratelimit"
+)
+
+var (
+	ErrTooManyAttempts = errors.New("too many login attempts")
+	ErrLockoutNotFound = errors.New("lockout record not found")
+)
+
+// 登录失败记录在最后一次失败（及锁定结束）后保留的时长，之后由后台任务清除
+// 不存在的用户名同样会产生记录，不清除会一直累积
+const loginFailureRetention = 24 * time.Hour
+
+// AccountLockedError 账号因连续登录失败被临时锁定
+type AccountLockedError struct {
+	Until time.Time
+}
+
+func (e *AccountLockedError) Error() string {
+	return "account is temporarily locked"
+}
+
+// LoginGuardService 登录防爆破：按用户名限流，并在连续失败后渐进式锁定账号
+type LoginGuardService struct {
+	failureRepo  *repository.LoginFailureRepository
+	auditService *AuditService
+	limiter      *ratelimit.Limiter
+	cfg          config.SecurityConfig
+}
+
+var (
+	loginGuard     *LoginGuardService
+	loginGuardOnce sync.Once
+)
+
+// GetLoginGuard 获取全局登录防护服务，限流状态需在所有请求间共享
+func GetLoginGuard() *LoginGuardService {
+	loginGuardOnce.Do(func() {
+		cfg := config.Get().Security
+		loginGuard = &LoginGuardService{
+			failureRepo:  repository.NewLoginFailureRepository(),
+			auditService: NewAuditService(),
+			limiter:      ratelimit.New(cfg.LoginRatePerMinute, time.Minute, cfg.LoginRatePerMinute),
+			cfg:          cfg,
+		}
+	})
+	return loginGuard
+}
+
+func normalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// Check 登录前检查：用户名限流及锁定状态
+func (s *LoginGuardService) Check(username string) error {
+	if err := s.checkLocked(username); err != nil {
+		return err
+	}
+
+	if allowed, _ := s.limiter.Allow(normalizeUsername(username)); !allowed {
+		return ErrTooManyAttempts
+	}
+	return nil
+}
+
+// checkLocked 检查账号是否处于锁定中，不消耗限流额度
+func (s *LoginGuardService) checkLocked(username string) error {
+	failure, err := s.failureRepo.GetByUsername(normalizeUsername(username))
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if failure != nil && failure.LockedUntil != nil && time.Now().Before(*failure.LockedUntil) {
+		return &AccountLockedError{Until: *failure.LockedUntil}
+	}
+	return nil
+}
+
+// RecordFailure 记录一次登录失败，连续失败达到阈值时锁定账号
+// 每次锁定的时长是上一次的两倍，直到上限
+func (s *LoginGuardService) RecordFailure(username, ip string) error {
+	now := time.Now()
+	return s.failureRepo.Update(normalizeUsername(username), func(failure *model.LoginFailure) {
+		failure.FailedCount++
+		failure.LastFailedAt = &now
+		failure.LastFailedIP = truncate(ip, 64)
+
+		if failure.FailedCount >= s.cfg.MaxFailedLogins {
+			until := now.Add(s.lockoutDuration(failure.LockCount))
+			failure.LockedUntil = &until
+			failure.LockCount++
+			failure.FailedCount = 0
+		}
+	})
+}
+
+// RecordSuccess 登录成功后清除失败记录
+func (s *LoginGuardService) RecordSuccess(username string) error {
+	return s.failureRepo.DeleteByUsername(normalizeUsername(username))
+}
+
+// PurgeStale 清除超过保留时长的登录失败记录，返回清除数量
+func (s *LoginGuardService) PurgeStale() (int64, error) {
+	now := time.Now()
+	return s.failureRepo.DeleteStale(now.Add(-loginFailureRetention), now)
+}
+
+func (s *LoginGuardService) lockoutDuration(lockCount int) time.Duration {
+	base := time.Duration(s.cfg.LockoutBaseMinutes) * time.Minute
+	max := time.Duration(s.cfg.LockoutMaxMinutes) * time.Minute
+
+	d := base
+	for i := 0; i < lockCount && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d
+}
+
+// LockoutInfo 账号锁定信息（管理员查看）
+type LockoutInfo struct {
+	model.LoginFailure
+	Locked bool `json:"locked"`
+}
+
+// ListLockouts 获取登录失败及锁定记录（管理员功能）
+func (s *LoginGuardService) ListLockouts(lockedOnly bool, page, pageSize int) ([]LockoutInfo, int64, error) {
+	var failures []model.LoginFailure
+	var total int64
+	var err error
+
+	now := time.Now()
+	if lockedOnly {
+		failures, total, err = s.failureRepo.GetLocked(now, page, pageSize)
+	} else {
+		failures, total, err = s.failureRepo.GetRecent(page, pageSize)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result := make([]LockoutInfo, 0, len(failures))
+	for _, f := range failures {
+		result = append(result, LockoutInfo{
+			LoginFailure: f,
+			Locked:       f.LockedUntil != nil && now.Before(*f.LockedUntil),
+		})
+	}
+	return result, total, nil
+}
+
+// Unlock 解除账号锁定（管理员功能）
+func (s *LoginGuardService) Unlock(actor AuditActor, username string) error {
+	key := normalizeUsername(username)
+	failure, err := s.failureRepo.GetByUsername(key)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrLockoutNotFound
+		}
+		return err
+	}
+	return s.auditService.Record(actor, &AuditEntry{
+		Action:     "lockout.unlock",
+		TargetType: model.AuditTargetLockout,
+		TargetID:   key,
+		Before:     failure,
+	}, func(tx *gorm.DB) error {
+		return s.failureRepo.WithTx(tx).DeleteByUsername(key)
+	})
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/internal/repository"
+	"tidalcore-backend/pkg/database"
+)
+
+func TestRecordFailureLocksAfterThreshold(t *testing.T) {
+	guard := GetLoginGuard()
+	username := uniqueName("lockout")
+
+	for i := 0; i < guard.cfg.MaxFailedLogins-1; i++ {
+		if err := guard.RecordFailure(username, "127.0.0.1"); err != nil {
+			t.Fatalf("RecordFailure: %v", err)
+		}
+	}
+	if err := guard.Check(username); err != nil {
+		t.Fatalf("Check before threshold: %v", err)
+	}
+
+	if err := guard.RecordFailure(username, "127.0.0.1"); err != nil {
+		t.Fatalf("RecordFailure: %v", err)
+	}
+	var locked *AccountLockedError
+	if err := guard.Check(username); !errors.As(err, &locked) {
+		t.Fatalf("Check after threshold = %v, want AccountLockedError", err)
+	}
+
+	// 用户名不区分大小写
+	if err := guard.Check(" " + username + " "); !errors.As(err, &locked) {
+		t.Fatalf("Check with different spacing = %v, want AccountLockedError", err)
+	}
+
+	if err := guard.RecordSuccess(username); err != nil {
+		t.Fatalf("RecordSuccess: %v", err)
+	}
+	if err := guard.Check(username); err != nil {
+		t.Fatalf("Check after success: %v", err)
+	}
+}
+
+func TestRecordFailureConcurrent(t *testing.T) {
+	guard := GetLoginGuard()
+	username := uniqueName("concurrent")
+	n := guard.cfg.MaxFailedLogins - 1
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- guard.RecordFailure(username, "127.0.0.1")
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent RecordFailure: %v", err)
+		}
+	}
+
+	failure, err := repository.NewLoginFailureRepository().GetByUsername(username)
+	if err != nil {
+		t.Fatalf("GetByUsername: %v", err)
+	}
+	if failure.FailedCount != n {
+		t.Fatalf("FailedCount = %d, want %d", failure.FailedCount, n)
+	}
+}
+
+func TestListLockoutsLockedOnlyPaginates(t *testing.T) {
+	guard := GetLoginGuard()
+	for i := 0; i < 3; i++ {
+		username := uniqueName("paged")
+		for j := 0; j < guard.cfg.MaxFailedLogins; j++ {
+			if err := guard.RecordFailure(username, "127.0.0.1"); err != nil {
+				t.Fatalf("RecordFailure: %v", err)
+			}
+		}
+	}
+
+	lockouts, total, err := guard.ListLockouts(true, 1, 2)
+	if err != nil {
+		t.Fatalf("ListLockouts: %v", err)
+	}
+	if len(lockouts) != 2 {
+		t.Fatalf("len(lockouts) = %d, want 2", len(lockouts))
+	}
+	if total < 3 {
+		t.Fatalf("total = %d, want at least 3", total)
+	}
+	for _, l := range lockouts {
+		if !l.Locked {
+			t.Fatalf("lockout %q not marked locked", l.Username)
+		}
+	}
+}
+
+func TestPurgeStaleLoginFailures(t *testing.T) {
+	guard := GetLoginGuard()
+	db := database.Get()
+	stale := uniqueName("nosuchuser")
+	locked := uniqueName("nosuchuser")
+	recent := uniqueName("nosuchuser")
+
+	for _, username := range []string{stale, locked, recent} {
+		if err := guard.RecordFailure(username, "127.0.0.1"); err != nil {
+			t.Fatalf("RecordFailure: %v", err)
+		}
+	}
+	old := time.Now().Add(-loginFailureRetention - time.Hour)
+	until := time.Now().Add(time.Hour)
+	if err := db.Model(&model.LoginFailure{}).Where("username IN ?", []string{stale, locked}).
+		UpdateColumn("updated_at", old).Error; err != nil {
+		t.Fatalf("age failures: %v", err)
+	}
+	if err := db.Model(&model.LoginFailure{}).Where("username = ?", locked).
+		UpdateColumn("locked_until", until).Error; err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+
+	if _, err := guard.PurgeStale(); err != nil {
+		t.Fatalf("PurgeStale: %v", err)
+	}
+	tests := []struct {
+		username string
+		kept     bool
+	}{
+		{stale, false},
+		{locked, true}, // 仍在锁定中
+		{recent, true},
+	}
+	for _, tt := range tests {
+		_, err := guard.failureRepo.GetByUsername(tt.username)
+		if kept := err == nil; kept != tt.kept {
+			t.Errorf("%s kept = %v, want %v (err = %v)", tt.username, kept, tt.kept, err)
+		}
+	}
+}
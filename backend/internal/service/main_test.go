@@ -0,0 +1,92 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"tidalcore-backend/config"
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/pkg/database"
+)
+
+// 服务层测试共用一个 SQLite 数据库：全局服务（登录防护、吊销等）在首次使用时绑定数据库连接，
+// 因此各测试通过 uniqueName 生成互不冲突的用户名等数据，而不是每个测试重建数据库
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "tidalcore-test")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	code := func() int {
+		defer os.RemoveAll(dir)
+
+		if err := config.Load(""); err != nil {
+			fmt.Println(err)
+			return 1
+		}
+		config.Get().JWT.Secret = "tidalcore-test-secret"
+
+		dsn := filepath.Join(dir, "test.db") + "?_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)"
+		db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+			Logger:         logger.Default.LogMode(logger.Silent),
+			TranslateError: true,
+		})
+		if err != nil {
+			fmt.Println(err)
+			return 1
+		}
+		if err := migrateTestDB(db); err != nil {
+			fmt.Println(err)
+			return 1
+		}
+		database.DB = db
+
+		if err := GetRevocationService().Load(); err != nil {
+			fmt.Println(err)
+			return 1
+		}
+		return m.Run()
+	}()
+	os.Exit(code)
+}
+
+func migrateTestDB(db *gorm.DB) error {
+	return db.AutoMigrate(
+		&model.User{},
+		&model.Checkin{},
+		&model.Visit{},
+		&model.Group{},
+		&model.GroupMember{},
+		&model.Challenge{},
+		&model.ChallengeParticipant{},
+		&model.Cheer{},
+		&model.Notification{},
+		&model.RefreshToken{},
+		&model.RevokedToken{},
+		&model.Session{},
+		&model.LoginFailure{},
+		&model.TwoFactorRecoveryCode{},
+		&model.APIToken{},
+		&model.UserIdentity{},
+		&model.PasswordReset{},
+		&model.PasswordRecoveryCode{},
+		&model.PasskeyCredential{},
+		&model.NameReview{},
+		&model.AuditLog{},
+	)
+}
+
+var nameSeq atomic.Int64
+
+// uniqueName 生成测试内唯一的名称
+func uniqueName(prefix string) string {
+	return fmt.Sprintf("%s%d", prefix, nameSeq.Add(1))
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/internal/moderation"
+	"tidalcore-backend/internal/repository"
+)
+
+var (
+	ErrUsernameNotAllowed = errors.New("username is reserved")
+	ErrNameReviewNotFound = errors.New("name review not found")
+	ErrNameReviewHandled  = errors.New("name review has already been handled")
+)
+
+// checkUsername 用户名不能是保留用户名，包含屏蔽词的用户名由 ScreenUsername 提交审核
+func checkUsername(username string) error {
+	if !moderation.IsUsernameAllowed(username) {
+		return ErrUsernameNotAllowed
+	}
+	return nil
+}
+
+// NameReviewService 名称审核：命中屏蔽词或保留名称的显示名称需管理员审核后才公开显示，
+// 包含屏蔽词的用户名先行生效，由管理员事后审核
+type NameReviewService struct {
+	reviewRepo          *repository.NameReviewRepository
+	userRepo            *repository.UserRepository
+	notificationService *NotificationService
+	auditService        *AuditService
+}
+
+func NewNameReviewService() *NameReviewService {
+	return &NameReviewService{
+		reviewRepo:          repository.NewNameReviewRepository(),
+		userRepo:            repository.NewUserRepository(),
+		notificationService: NewNotificationService(),
+		auditService:        NewAuditService(),
+	}
+}
+
+// NameReviewItem 审核记录，附带用户当前的用户名和显示名称
+type NameReviewItem struct {
+	model.NameReview
+	Username           string `json:"username"`
+	CurrentDisplayName string `json:"current_display_name"`
+}
+
+// RejectNameRequest 驳回名称请求
+type RejectNameRequest struct {
+	Reason string `json:"reason" binding:"max=255"`
+}
+
+// Submit 提交待审核的显示名称或用户名，取代该用户之前同类名称未处理的申请，操作者记为用户本人
+func (s *NameReviewService) Submit(userID uint, field, name string, matched []string) error {
+	review := &model.NameReview{
+		UserID:       userID,
+		Field:        field,
+		DisplayName:  name,
+		MatchedTerms: truncate(strings.Join(matched, ","), 255),
+		Status:       model.NameReviewPending,
+	}
+	entry := &AuditEntry{
+		Action:     "name_review.submit",
+		TargetType: model.AuditTargetNameReview,
+		After:      map[string]interface{}{field: name, "matched_terms": review.MatchedTerms},
+	}
+	return s.auditService.Record(AuditActor{ID: userID}, entry, func(tx *gorm.DB) error {
+		if err := s.reviewRepo.WithTx(tx).CreateReplacing(review); err != nil {
+			return err
+		}
+		entry.TargetID = auditID(review.ID)
+		return nil
+	})
+}
+
+// Screen 检查用户新设置的显示名称，命中时提交审核并返回 true，此时调用方不应修改显示名称
+// 未命中时取消该用户之前未处理的申请，避免稍后通过的旧申请覆盖新名称
+func (s *NameReviewService) Screen(userID uint, displayName string) (bool, error) {
+	if matched := moderation.CheckDisplayName(displayName); len(matched) > 0 {
+		return true, s.Submit(userID, model.NameFieldDisplayName, displayName, matched)
+	}
+	return false, s.reviewRepo.CancelPending(userID, model.NameFieldDisplayName)
+}
+
+// ScreenUsername 检查用户已改用的用户名，包含屏蔽词时提交审核，否则取消之前未处理的用户名申请
+func (s *NameReviewService) ScreenUsername(userID uint, username string) error {
+	if matched := moderation.CheckUsername(username); len(matched) > 0 {
+		return s.Submit(userID, model.NameFieldUsername, username, matched)
+	}
+	return s.reviewRepo.CancelPending(userID, model.NameFieldUsername)
+}
+
+// PendingDisplayName 获取用户待审核的显示名称，没有时返回空字符串
+func (s *NameReviewService) PendingDisplayName(userID uint) (string, error) {
+	review, err := s.reviewRepo.GetPendingByUserID(userID, model.NameFieldDisplayName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	return review.DisplayName, nil
+}
+
+// List 按状态分页获取审核记录（管理员功能），默认返回待审核的记录
+func (s *NameReviewService) List(status string, page, pageSize int) ([]NameReviewItem, int64, error) {
+	switch status {
+	case model.NameReviewApproved, model.NameReviewRejected, model.NameReviewCancelled:
+	default:
+		status = model.NameReviewPending
+	}
+
+	reviews, total, err := s.reviewRepo.List(status, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	userIDs := make([]uint, 0, len(reviews))
+	for _, r := range reviews {
+		userIDs = append(userIDs, r.UserID)
+	}
+	users, err := s.userRepo.GetByIDs(userIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	byID := make(map[uint]model.User, len(users))
+	for _, u := range users {
+		byID[u.ID] = u
+	}
+
+	items := make([]NameReviewItem, 0, len(reviews))
+	for _, r := range reviews {
+		u := byID[r.UserID]
+		items = append(items, NameReviewItem{
+			NameReview:         r,
+			Username:           u.Username,
+			CurrentDisplayName: u.DisplayName,
+		})
+	}
+	return items, total, nil
+}
+
+// CountPending 统计待审核数量
+func (s *NameReviewService) CountPending() (int64, error) {
+	return s.reviewRepo.CountPending()
+}
+
+// Approve 通过审核（管理员功能）：显示名称随即改为申请的名称，用户名保持提交时已生效的名称
+func (s *NameReviewService) Approve(actor AuditActor, id uint) error {
+	review, err := s.getReview(id)
+	if err != nil {
+		return err
+	}
+	user, err := s.userRepo.GetByID(review.UserID)
+	if err != nil {
+		return err
+	}
+
+	current := user.DisplayName
+	if review.Field == model.NameFieldUsername {
+		current = user.Username
+	}
+	err = s.auditService.Record(actor, &AuditEntry{
+		Action:     "name_review.approve",
+		TargetType: model.AuditTargetNameReview,
+		TargetID:   auditID(review.ID),
+		Before:     map[string]interface{}{"user_id": review.UserID, review.Field: current},
+		After:      map[string]string{review.Field: review.DisplayName},
+	}, func(tx *gorm.DB) error {
+		ok, err := s.reviewRepo.WithTx(tx).Approve(review, actor.ID)
+		if err == nil && !ok {
+			err = ErrNameReviewHandled
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// 用户名通过审核时用户看不到任何变化，无需通知
+	if review.Field == model.NameFieldUsername {
+		return nil
+	}
+	if err := s.notificationService.Notify(review.UserID, model.NotificationSystem,
+		"显示名称已通过审核", "你的显示名称已更新为「"+review.DisplayName+"」"); err != nil {
+		log.Printf("Warning: Failed to notify user %d of name review: %v", review.UserID, err)
+	}
+	return nil
+}
+
+// Reject 驳回申请，用户的名称保持不变，通知用户更换（管理员功能）
+func (s *NameReviewService) Reject(actor AuditActor, id uint, req *RejectNameRequest) error {
+	review, err := s.getReview(id)
+	if err != nil {
+		return err
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	err = s.auditService.Record(actor, &AuditEntry{
+		Action:     "name_review.reject",
+		TargetType: model.AuditTargetNameReview,
+		TargetID:   auditID(review.ID),
+		Before:     map[string]interface{}{"user_id": review.UserID, review.Field: review.DisplayName},
+		After:      map[string]string{"reject_reason": reason},
+	}, func(tx *gorm.DB) error {
+		ok, err := s.reviewRepo.WithTx(tx).Reject(review.ID, actor.ID, reason)
+		if err == nil && !ok {
+			err = ErrNameReviewHandled
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	label := "显示名称"
+	if review.Field == model.NameFieldUsername {
+		label = "用户名"
+	}
+	content := label + "「" + review.DisplayName + "」未通过审核，请在个人设置中更换其他名称"
+	if reason != "" {
+		content += "。原因：" + reason
+	}
+	if err := s.notificationService.Notify(review.UserID, model.NotificationSystem, label+"未通过审核", content); err != nil {
+		log.Printf("Warning: Failed to notify user %d of name review: %v", review.UserID, err)
+	}
+	return nil
+}
+
+func (s *NameReviewService) getReview(id uint) (*model.NameReview, error) {
+	review, err := s.reviewRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNameReviewNotFound
+		}
+		return nil, err
+	}
+	return review, nil
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/pkg/database"
+)
+
+func TestNameReviewSubmitRecordsAudit(t *testing.T) {
+	s := NewNameReviewService()
+	user := createUser(t, false)
+
+	if err := s.Submit(user.ID, model.NameFieldDisplayName, "管理员", []string{"管理员"}); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	review, err := s.reviewRepo.GetPendingByUserID(user.ID, model.NameFieldDisplayName)
+	if err != nil {
+		t.Fatalf("GetPendingByUserID: %v", err)
+	}
+	if n := countAudit(t, "name_review.submit", auditID(review.ID)); n != 1 {
+		t.Fatalf("name_review.submit audit logs = %d, want 1", n)
+	}
+}
+
+func TestRegisterRejectsOnlyReservedUsernames(t *testing.T) {
+	s := NewUserService()
+
+	_, err := s.Register(&RegisterRequest{Username: "Adm1n", DisplayName: "管理", Password: testPassword}, ClientInfo{})
+	if !errors.Is(err, ErrUsernameNotAllowed) {
+		t.Fatalf("reserved username: err = %v, want ErrUsernameNotAllowed", err)
+	}
+
+	// 包含屏蔽词的用户名照常注册，提交用户名审核
+	username := uniqueName("scunthorpe_")
+	resp, err := s.Register(&RegisterRequest{Username: username, DisplayName: username, Password: testPassword}, ClientInfo{})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	userID := resp.User.ID
+
+	reviews := NewNameReviewService()
+	review, err := reviews.reviewRepo.GetPendingByUserID(userID, model.NameFieldUsername)
+	if err != nil {
+		t.Fatalf("username review not submitted: %v", err)
+	}
+	if review.DisplayName != username || review.MatchedTerms != "cunt" {
+		t.Fatalf("unexpected review: %+v", review)
+	}
+	// 显示名称与用户名相同，不重复提交显示名称审核
+	if pending, err := reviews.PendingDisplayName(userID); err != nil || pending != "" {
+		t.Fatalf("PendingDisplayName = %q, %v; want no display name review", pending, err)
+	}
+
+	admin := createUser(t, false)
+	if err := reviews.Approve(AuditActor{ID: admin.ID}, review.ID); err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+	var stored model.User
+	if err := database.Get().First(&stored, userID).Error; err != nil {
+		t.Fatalf("load user: %v", err)
+	}
+	if stored.Username != username || stored.DisplayName != username {
+		t.Fatalf("approving a username review changed the user: %+v", stored)
+	}
+}
+
+func TestUpdateUsernameScreensBlockedTerms(t *testing.T) {
+	s := NewUserService()
+	reviews := NewNameReviewService()
+	user := createUser(t, false)
+
+	if _, err := s.UpdateUsername(user.ID, &UpdateUsernameRequest{Username: uniqueName("shitake_")}); err != nil {
+		t.Fatalf("UpdateUsername: %v", err)
+	}
+	if _, err := reviews.reviewRepo.GetPendingByUserID(user.ID, model.NameFieldUsername); err != nil {
+		t.Fatalf("username review not submitted: %v", err)
+	}
+
+	// 改用正常的用户名后，未处理的申请随即取消
+	if _, err := s.UpdateUsername(user.ID, &UpdateUsernameRequest{Username: uniqueName("mushroom_")}); err != nil {
+		t.Fatalf("UpdateUsername: %v", err)
+	}
+	if _, err := reviews.reviewRepo.GetPendingByUserID(user.ID, model.NameFieldUsername); err == nil {
+		t.Fatal("pending username review not cancelled after rename")
+	}
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"tidalcore-backend/config"
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/internal/realtime"
+	"tidalcore-backend/internal/repository"
+)
+
+var (
+	ErrNotificationNotFound = errors.New("notification not found")
+)
+
+// streakMilestones 连续打卡成就里程碑
+var streakMilestones = []int{3, 7, 14, 30, 60, 100, 180, 365}
+
+// NotificationService 站内通知服务
+// 其他服务通过 Notify / NotifyMany 投递通知，管理员公告通过 Announce 发布
+type NotificationService struct {
+	notificationRepo *repository.NotificationRepository
+	userRepo         *repository.UserRepository
+	auditService     *AuditService
+	location         *time.Location
+}
+
+func NewNotificationService() *NotificationService {
+	loc := time.Local
+	cfg := config.Get()
+	if cfg != nil && cfg.Server.Timezone != "" {
+		if l, err := time.LoadLocation(cfg.Server.Timezone); err == nil {
+			loc = l
+		}
+	}
+
+	return &NotificationService{
+		notificationRepo: repository.NewNotificationRepository(),
+		userRepo:         repository.NewUserRepository(),
+		auditService:     NewAuditService(),
+		location:         loc,
+	}
+}
+
+// AnnouncementRequest 管理员公告请求
+type AnnouncementRequest struct {
+	Title   string `json:"title" binding:"required,min=1,max=100"`
+	Content string `json:"content" binding:"max=500"`
+}
+
+// NotificationList 通知列表及未读数
+type NotificationList struct {
+	Notifications []model.Notification `json:"notifications"`
+	UnreadCount   int64                `json:"unread_count"`
+}
+
+// Notify 向单个用户投递通知
+func (s *NotificationService) Notify(userID uint, notificationType, title, content string) error {
+	notification := &model.Notification{
+		UserID:  userID,
+		Type:    notificationType,
+		Title:   title,
+		Content: content,
+	}
+	if err := s.notificationRepo.Create(notification); err != nil {
+		return err
+	}
+	s.publish(notification)
+	return nil
+}
+
+// publish 实时推送通知及最新未读数
+func (s *NotificationService) publish(notification *model.Notification) {
+	unread, err := s.notificationRepo.CountUnread(notification.UserID)
+	if err != nil {
+		log.Printf("Warning: Failed to count unread notifications for user %d: %v", notification.UserID, err)
+		return
+	}
+	realtime.Default().PublishTo(notification.UserID, realtime.EventNotification, map[string]interface{}{
+		"notification": notification,
+		"unread_count": unread,
+	})
+}
+
+// NotifyMany 向多个用户投递相同通知
+func (s *NotificationService) NotifyMany(userIDs []uint, notificationType, title, content string) error {
+	notifications := buildNotifications(userIDs, notificationType, title, content)
+	if err := s.notificationRepo.CreateBatch(notifications); err != nil {
+		return err
+	}
+	s.publishOnline(notifications)
+	return nil
+}
+
+func buildNotifications(userIDs []uint, notificationType, title, content string) []model.Notification {
+	notifications := make([]model.Notification, 0, len(userIDs))
+	for _, id := range userIDs {
+		notifications = append(notifications, model.Notification{
+			UserID:  id,
+			Type:    notificationType,
+			Title:   title,
+			Content: content,
+		})
+	}
+	return notifications
+}
+
+// publishOnline 批量投递时只推送在线用户，避免为离线用户查询未读数
+func (s *NotificationService) publishOnline(notifications []model.Notification) {
+	hub := realtime.Default()
+	for i := range notifications {
+		if hub.IsOnline(notifications[i].UserID) {
+			s.publish(&notifications[i])
+		}
+	}
+}
+
+// Announce 发布管理员公告，公告与审计记录在同一事务中写入，提交后再实时推送
+func (s *NotificationService) Announce(actor AuditActor, req *AnnouncementRequest) (int, error) {
+	ids, err := s.userRepo.GetAllIDs()
+	if err != nil {
+		return 0, err
+	}
+
+	notifications := buildNotifications(ids, model.NotificationAnnouncement, req.Title, req.Content)
+	err = s.auditService.Record(actor, &AuditEntry{
+		Action:     "notification.announce",
+		TargetType: model.AuditTargetSystem,
+		After: map[string]interface{}{
+			"title":      req.Title,
+			"content":    req.Content,
+			"recipients": len(ids),
+		},
+	}, func(tx *gorm.DB) error {
+		return s.notificationRepo.WithTx(tx).CreateBatch(notifications)
+	})
+	if err != nil {
+		return 0, err
+	}
+	s.publishOnline(notifications)
+	return len(ids), nil
+}
+
+// List 获取用户通知列表
+func (s *NotificationService) List(userID uint, unreadOnly bool, limit int) (*NotificationList, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 30
+	}
+
+	notifications, err := s.notificationRepo.GetByUserID(userID, unreadOnly, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	unread, err := s.notificationRepo.CountUnread(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NotificationList{
+		Notifications: notifications,
+		UnreadCount:   unread,
+	}, nil
+}
+
+// UnreadCount 获取未读通知数
+func (s *NotificationService) UnreadCount(userID uint) (int64, error) {
+	return s.notificationRepo.CountUnread(userID)
+}
+
+// MarkRead 标记单条通知为已读
+func (s *NotificationService) MarkRead(userID, id uint) error {
+	affected, err := s.notificationRepo.MarkRead(userID, id)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		// 已读的通知不会被更新，需区分是否存在
+		exists, err := s.notificationRepo.Exists(userID, id)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrNotificationNotFound
+		}
+	}
+	return nil
+}
+
+// MarkAllRead 标记所有通知为已读，返回标记数量
+func (s *NotificationService) MarkAllRead(userID uint) (int64, error) {
+	return s.notificationRepo.MarkAllRead(userID)
+}
+
+// NotifyStreakMilestone 连续打卡达到里程碑时发送成就通知
+func (s *NotificationService) NotifyStreakMilestone(userID uint, streak int) {
+	for _, m := range streakMilestones {
+		if streak == m {
+			title := fmt.Sprintf("成就解锁：连续打卡 %d 天", m)
+			if err := s.Notify(userID, model.NotificationAchievement, title, "坚持就是力量，继续保持！"); err != nil {
+				log.Printf("Warning: Failed to send milestone notification to user %d: %v", userID, err)
+			}
+			return
+		}
+	}
+}
+
+// NotifyStreakAtRisk 提醒昨天打卡但今天尚未打卡的用户，返回提醒人数
+// 同一天内不会重复提醒
+func (s *NotificationService) NotifyStreakAtRisk() (int, error) {
+	now := time.Now().In(s.location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.location)
+	yesterday := today.AddDate(0, 0, -1)
+
+	users, err := s.userRepo.GetByLastCheckinRange(yesterday, today)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, user := range users {
+		exists, err := s.notificationRepo.ExistsSince(user.ID, model.NotificationStreakRisk, today)
+		if err != nil {
+			return sent, err
+		}
+		if exists {
+			continue
+		}
+
+		content := fmt.Sprintf("你已连续打卡 %d 天，今天还没有打卡哦，别让连胜中断！", user.Streak)
+		if err := s.Notify(user.ID, model.NotificationStreakRisk, "连续打卡即将中断", content); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// StartStreakRiskReminder 启动后台任务，每天在指定整点提醒连续打卡即将中断的用户
+func (s *NotificationService) StartStreakRiskReminder(hour int) {
+	go func() {
+		for {
+			now := time.Now().In(s.location)
+			next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, s.location)
+			if !next.After(now) {
+				next = next.AddDate(0, 0, 1)
+			}
+			time.Sleep(time.Until(next))
+
+			sent, err := s.NotifyStreakAtRisk()
+			if err != nil {
+				log.Printf("Warning: Streak risk reminder failed: %v", err)
+				continue
+			}
+			log.Printf("Streak risk reminder sent to %d users", sent)
+		}
+	}()
+}
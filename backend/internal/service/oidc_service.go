@@ -0,0 +1,417 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"tidalcore-backend/config"
+	"tidalcore-backend/internal/auth"
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/internal/moderation"
+	"tidalcore-backend/internal/oidc"
+	"tidalcore-backend/internal/repository"
+)
+
+var (
+	ErrOIDCDisabled         = errors.New("oidc login is not enabled")
+	ErrOIDCInvalidState     = errors.New("invalid or expired oidc state")
+	ErrOIDCSignupDisabled   = errors.New("oidc signup is disabled")
+	ErrOIDCIdentityLinked   = errors.New("identity is already linked to another user")
+	ErrOIDCInvalidLoginCode = errors.New("invalid or expired oidc login code")
+	ErrIdentityNotFound     = errors.New("identity not found")
+	ErrOIDCNoUsername       = errors.New("no available username for oidc signup")
+)
+
+const (
+	// 跳转到身份提供方后需在有效期内完成授权
+	oidcStateTTL = 10 * time.Minute
+	// 回调后前端需立即用登录码换取令牌
+	oidcLoginCodeTTL = time.Minute
+)
+
+var nonUsernameChars = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+type oidcPendingAuth struct {
+	nonce        string
+	codeVerifier string
+	redirect     string // 登录后前端跳转的页面
+	linkUserID   uint   // 非零表示为已登录用户绑定外部身份
+	expiresAt    time.Time
+}
+
+type oidcLoginCode struct {
+	userID    uint
+	expiresAt time.Time
+}
+
+// OIDCService OpenID Connect 单点登录（授权码 + PKCE）
+// 授权中的 state 和回调生成的一次性登录码保存在内存中
+type OIDCService struct {
+	provider     *oidc.Provider
+	cfg          config.OIDCConfig
+	userRepo     *repository.UserRepository
+	identityRepo *repository.UserIdentityRepository
+
+	mu         sync.Mutex
+	pending    map[string]*oidcPendingAuth
+	loginCodes map[string]*oidcLoginCode
+}
+
+var (
+	oidcService     *OIDCService
+	oidcServiceOnce sync.Once
+)
+
+// GetOIDCService 获取全局 OIDC 服务
+func GetOIDCService() *OIDCService {
+	oidcServiceOnce.Do(func() {
+		oidcService = newOIDCService(config.Get().OIDC)
+	})
+	return oidcService
+}
+
+func newOIDCService(cfg config.OIDCConfig) *OIDCService {
+	return &OIDCService{
+		provider:     oidc.NewProvider(cfg),
+		cfg:          cfg,
+		userRepo:     repository.NewUserRepository(),
+		identityRepo: repository.NewUserIdentityRepository(),
+		pending:      make(map[string]*oidcPendingAuth),
+		loginCodes:   make(map[string]*oidcLoginCode),
+	}
+}
+
+// OIDCProviderInfo 前端展示登录按钮所需的信息
+type OIDCProviderInfo struct {
+	Enabled      bool   `json:"enabled"`
+	ProviderName string `json:"provider_name,omitempty"`
+}
+
+type OIDCExchangeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type UnlinkIdentityRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+func (s *OIDCService) Info() *OIDCProviderInfo {
+	if !s.cfg.Enabled {
+		return &OIDCProviderInfo{Enabled: false}
+	}
+	return &OIDCProviderInfo{Enabled: true, ProviderName: s.cfg.ProviderName}
+}
+
+// BeginAuth 生成授权地址，linkUserID 非零时授权完成后绑定到该用户
+func (s *OIDCService) BeginAuth(ctx context.Context, redirect string, linkUserID uint) (string, error) {
+	if !s.cfg.Enabled {
+		return "", ErrOIDCDisabled
+	}
+
+	state, err := auth.GenerateRandomToken(24)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := auth.GenerateRandomToken(24)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := oidc.GenerateCodeVerifier()
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := s.provider.AuthCodeURL(ctx, state, nonce, oidc.CodeChallengeS256(verifier))
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	for key, p := range s.pending {
+		if now.After(p.expiresAt) {
+			delete(s.pending, key)
+		}
+	}
+	s.pending[state] = &oidcPendingAuth{
+		nonce:        nonce,
+		codeVerifier: verifier,
+		redirect:     sanitizeRedirect(redirect),
+		linkUserID:   linkUserID,
+		expiresAt:    now.Add(oidcStateTTL),
+	}
+	s.mu.Unlock()
+
+	return authURL, nil
+}
+
+// HandleCallback 处理身份提供方回调，返回前端跳转地址
+// 登录时跳转地址携带一次性登录码，由前端换取令牌，避免令牌出现在 URL 中
+func (s *OIDCService) HandleCallback(ctx context.Context, state, code string) (string, error) {
+	if !s.cfg.Enabled {
+		return "", ErrOIDCDisabled
+	}
+
+	s.mu.Lock()
+	pending, ok := s.pending[state]
+	delete(s.pending, state)
+	s.mu.Unlock()
+	if !ok || time.Now().After(pending.expiresAt) {
+		return "", ErrOIDCInvalidState
+	}
+
+	token, err := s.provider.Exchange(ctx, code, pending.codeVerifier)
+	if err != nil {
+		return "", err
+	}
+	claims, err := s.provider.VerifyIDToken(ctx, token.IDToken, pending.nonce)
+	if err != nil {
+		return "", err
+	}
+	issuer, err := s.provider.Issuer(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if pending.linkUserID != 0 {
+		if err := s.link(pending.linkUserID, issuer, claims); err != nil {
+			return "", err
+		}
+		return s.frontendURL("/dashboard", url.Values{"oidc_linked": {"1"}}), nil
+	}
+
+	user, err := s.findOrCreateUser(issuer, claims)
+	if err != nil {
+		return "", err
+	}
+
+	loginCode, err := auth.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	s.loginCodes[auth.HashToken(loginCode)] = &oidcLoginCode{
+		userID:    user.ID,
+		expiresAt: time.Now().Add(oidcLoginCodeTTL),
+	}
+	s.mu.Unlock()
+
+	params := url.Values{"oidc_code": {loginCode}}
+	if pending.redirect != "" {
+		params.Set("redirect", pending.redirect)
+	}
+	return s.frontendURL("/login", params), nil
+}
+
+// ErrorRedirect 回调失败时返回前端的跳转地址
+func (s *OIDCService) ErrorRedirect(reason string) string {
+	return s.frontendURL("/login", url.Values{"oidc_error": {reason}})
+}
+
+// ConsumeLoginCode 使用一次性登录码，返回对应的用户
+func (s *OIDCService) ConsumeLoginCode(code string) (*model.User, error) {
+	key := auth.HashToken(code)
+
+	now := time.Now()
+	s.mu.Lock()
+	entry, ok := s.loginCodes[key]
+	delete(s.loginCodes, key)
+	for k, c := range s.loginCodes {
+		if now.After(c.expiresAt) {
+			delete(s.loginCodes, k)
+		}
+	}
+	s.mu.Unlock()
+	if !ok || now.After(entry.expiresAt) {
+		return nil, ErrOIDCInvalidLoginCode
+	}
+
+	user, err := s.userRepo.GetByID(entry.userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOIDCInvalidLoginCode
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// ListIdentities 获取用户绑定的外部身份
+func (s *OIDCService) ListIdentities(userID uint) ([]model.UserIdentity, error) {
+	return s.identityRepo.GetByUserID(userID)
+}
+
+// Unlink 解除外部身份绑定，需验证密码以确保解绑后仍能登录
+// 没有本地密码的账号需先设置初始密码
+func (s *OIDCService) Unlink(userID, identityID uint, req *UnlinkIdentityRequest) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.PasswordHash == "" {
+		return ErrPasswordNotSet
+	}
+	if !auth.CheckPassword(req.Password, user.PasswordHash) {
+		return ErrOldPasswordWrong
+	}
+
+	deleted, err := s.identityRepo.DeleteByUser(identityID, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrIdentityNotFound
+	}
+	return nil
+}
+
+func (s *OIDCService) link(userID uint, issuer string, claims *oidc.IDTokenClaims) error {
+	existing, err := s.identityRepo.GetBySubject(issuer, claims.Subject)
+	if err == nil {
+		if existing.UserID != userID {
+			return ErrOIDCIdentityLinked
+		}
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	return s.identityRepo.Create(&model.UserIdentity{
+		UserID:  userID,
+		Issuer:  issuer,
+		Subject: claims.Subject,
+	})
+}
+
+// findOrCreateUser 按 issuer + sub 查找已绑定的用户，未绑定时自动创建账号
+func (s *OIDCService) findOrCreateUser(issuer string, claims *oidc.IDTokenClaims) (*model.User, error) {
+	identity, err := s.identityRepo.GetBySubject(issuer, claims.Subject)
+	if err == nil {
+		user, err := s.userRepo.GetByID(identity.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.identityRepo.TouchLogin(identity.ID, time.Now()); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if s.cfg.DisableSignup {
+		return nil, ErrOIDCSignupDisabled
+	}
+
+	username, err := s.availableUsername(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	// 身份提供方返回的名称同样需要审核，命中屏蔽词时先以用户名作为显示名称
+	displayName := truncate(strings.TrimSpace(claims.Name), 50)
+	matched := moderation.CheckDisplayName(displayName)
+	if displayName == "" || len(matched) > 0 {
+		displayName = username
+	}
+
+	// 单点登录创建的账号没有本地密码，无法用密码登录，可在个人设置中设置初始密码
+	now := time.Now()
+	user := &model.User{
+		Username:    username,
+		DisplayName: displayName,
+	}
+	identity = &model.UserIdentity{
+		Issuer:      issuer,
+		Subject:     claims.Subject,
+		LastLoginAt: &now,
+	}
+	if err := s.identityRepo.CreateWithUser(user, identity); err != nil {
+		return nil, err
+	}
+	if len(matched) > 0 {
+		if err := NewNameReviewService().Submit(user.ID, model.NameFieldDisplayName, truncate(strings.TrimSpace(claims.Name), 50), matched); err != nil {
+			log.Printf("Warning: Failed to submit display name review for user %d: %v", user.ID, err)
+		}
+	}
+	return user, nil
+}
+
+// availableUsername 根据 preferred_username 或邮箱前缀生成可用的用户名，重名时追加序号
+// 邮箱只用于生成用户名，不会保存
+func (s *OIDCService) availableUsername(claims *oidc.IDTokenClaims) (string, error) {
+	base := claims.PreferredUsername
+	if base == "" {
+		base, _, _ = strings.Cut(claims.Email, "@")
+	}
+	base = nonUsernameChars.ReplaceAllString(base, "")
+	if len(base) > 40 {
+		base = base[:40]
+	}
+	if len(base) < 3 {
+		base = "user"
+	}
+
+	for i := 1; i <= 100; i++ {
+		candidate := base
+		if i > 1 {
+			candidate = fmt.Sprintf("%s_%d", base, i)
+		}
+		ok, err := s.usernameAvailable(candidate)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return candidate, nil
+		}
+	}
+
+	// 序号用尽时改用随机后缀，同样需要通过检查
+	for i := 0; i < 10; i++ {
+		suffix, err := auth.GenerateRandomID(4)
+		if err != nil {
+			return "", err
+		}
+		candidate := base + "_" + suffix
+		ok, err := s.usernameAvailable(candidate)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return candidate, nil
+		}
+	}
+	return "", ErrOIDCNoUsername
+}
+
+// usernameAvailable 自动生成的用户名须未被占用，并避开保留用户名和屏蔽词，无需审核
+func (s *OIDCService) usernameAvailable(username string) (bool, error) {
+	if checkUsername(username) != nil || len(moderation.CheckUsername(username)) > 0 {
+		return false, nil
+	}
+	exists, err := s.userRepo.ExistsByUsername(username)
+	return !exists, err
+}
+
+func (s *OIDCService) frontendURL(path string, params url.Values) string {
+	return strings.TrimSuffix(s.cfg.FrontendURL, "/") + path + "?" + params.Encode()
+}
+
+// sanitizeRedirect 仅允许站内相对路径，防止开放重定向
+func sanitizeRedirect(redirect string) string {
+	if !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") || strings.HasPrefix(redirect, "/\\") {
+		return ""
+	}
+	return redirect
+}
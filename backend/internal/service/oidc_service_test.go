@@ -0,0 +1,378 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"tidalcore-backend/config"
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/internal/oidc"
+	"tidalcore-backend/pkg/database"
+)
+
+const (
+	testClientID    = "tidalcore"
+	testRedirectURL = "http://localhost:8080/api/v1/auth/oidc/callback"
+	testFrontendURL = "http://localhost:5173"
+)
+
+type fakeAuthCode struct {
+	challenge string
+	nonce     string
+	username  string
+}
+
+// fakeIdP 测试用身份提供方，授权端点直接跳回回调地址，令牌端点校验 PKCE
+type fakeIdP struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *ecdsa.PrivateKey
+
+	mu        sync.Mutex
+	codes     map[string]*fakeAuthCode
+	nonce     string // 非空时 ID Token 使用该 nonce，模拟被替换的令牌
+	exchanges int
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	idp := &fakeIdP{t: t, key: key, codes: make(map[string]*fakeAuthCode)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("/authorize", idp.authorize)
+	mux.HandleFunc("/token", idp.token)
+	mux.HandleFunc("/jwks", idp.jwks)
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (p *fakeIdP) discovery(w http.ResponseWriter, r *http.Request) {
+	issuer := p.server.URL
+	writeTestJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 issuer,
+		"authorization_endpoint": issuer + "/authorize",
+		"token_endpoint":         issuer + "/token",
+		"jwks_uri":               issuer + "/jwks",
+	})
+}
+
+func (p *fakeIdP) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != testClientID || q.Get("redirect_uri") != testRedirectURL ||
+		q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+
+	code := randomTestString(p.t)
+	p.mu.Lock()
+	p.codes[code] = &fakeAuthCode{
+		challenge: q.Get("code_challenge"),
+		nonce:     q.Get("nonce"),
+		username:  q.Get("login_hint"),
+	}
+	p.mu.Unlock()
+
+	redirect, _ := url.Parse(q.Get("redirect_uri"))
+	redirect.RawQuery = url.Values{"code": {code}, "state": {q.Get("state")}}.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (p *fakeIdP) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeTestJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	p.mu.Lock()
+	p.exchanges++
+	entry, ok := p.codes[r.PostForm.Get("code")]
+	delete(p.codes, r.PostForm.Get("code"))
+	nonce := p.nonce
+	p.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != entry.challenge {
+		writeTestJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+	if nonce == "" {
+		nonce = entry.nonce
+	}
+
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss":                p.server.URL,
+		"sub":                "fake|" + entry.username,
+		"aud":                testClientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(5 * time.Minute).Unix(),
+		"nonce":              nonce,
+		"preferred_username": entry.username,
+		"name":               "单点登录用户",
+		"email":              entry.username + "@example.com",
+	})
+	idToken.Header["kid"] = "test-key"
+	signed, err := idToken.SignedString(p.key)
+	if err != nil {
+		writeTestJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeTestJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomTestString(p.t),
+		"token_type":   "Bearer",
+		"id_token":     signed,
+	})
+}
+
+func (p *fakeIdP) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := p.key.PublicKey
+	writeTestJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "EC",
+			"kid": "test-key",
+			"use": "sig",
+			"crv": "P-256",
+			"x":   base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, 32))),
+			"y":   base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, 32))),
+		}},
+	})
+}
+
+// login 模拟浏览器打开授权地址并完成登录，返回回调中的 state 和 code
+func (p *fakeIdP) login(authURL, username string) (state, code string) {
+	p.t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		p.t.Fatalf("parse auth url: %v", err)
+	}
+	q := u.Query()
+	q.Set("login_hint", username)
+	u.RawQuery = q.Encode()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(u.String())
+	if err != nil {
+		p.t.Fatalf("authorize: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		p.t.Fatalf("authorize status = %d, want 302", resp.StatusCode)
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		p.t.Fatalf("parse callback: %v", err)
+	}
+	return location.Query().Get("state"), location.Query().Get("code")
+}
+
+func (p *fakeIdP) service() *OIDCService {
+	return newOIDCService(config.OIDCConfig{
+		Enabled:     true,
+		Issuer:      p.server.URL,
+		ClientID:    testClientID,
+		RedirectURL: testRedirectURL,
+		FrontendURL: testFrontendURL,
+		Scopes:      []string{"openid", "profile", "email"},
+	})
+}
+
+func writeTestJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func randomTestString(t *testing.T) string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatalf("rand: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func TestOIDCLoginCreatesUser(t *testing.T) {
+	idp := newFakeIdP(t)
+	s := idp.service()
+	ctx := context.Background()
+	username := uniqueName("sso")
+
+	authURL, err := s.BeginAuth(ctx, "/groups", 0)
+	if err != nil {
+		t.Fatalf("BeginAuth: %v", err)
+	}
+	u, _ := url.Parse(authURL)
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("state") == "" || q.Get("nonce") == "" {
+		t.Fatalf("authorization url missing PKCE, state or nonce: %s", authURL)
+	}
+
+	state, code := idp.login(authURL, username)
+	redirect, err := s.HandleCallback(ctx, state, code)
+	if err != nil {
+		t.Fatalf("HandleCallback: %v", err)
+	}
+	r, _ := url.Parse(redirect)
+	if r.Path != "/login" || r.Query().Get("redirect") != "/groups" || r.Query().Get("oidc_code") == "" {
+		t.Fatalf("unexpected frontend redirect %s", redirect)
+	}
+
+	// 同一个 state 不能再次使用
+	if _, err := s.HandleCallback(ctx, state, code); !errors.Is(err, ErrOIDCInvalidState) {
+		t.Fatalf("replayed state: err = %v, want ErrOIDCInvalidState", err)
+	}
+
+	loginCode := r.Query().Get("oidc_code")
+	user, err := s.ConsumeLoginCode(loginCode)
+	if err != nil {
+		t.Fatalf("ConsumeLoginCode: %v", err)
+	}
+	if user.Username != username || user.PasswordHash != "" {
+		t.Fatalf("created user = %q (password set: %v), want %q without password",
+			user.Username, user.PasswordHash != "", username)
+	}
+	if _, err := s.ConsumeLoginCode(loginCode); !errors.Is(err, ErrOIDCInvalidLoginCode) {
+		t.Fatalf("reused login code: err = %v, want ErrOIDCInvalidLoginCode", err)
+	}
+
+	var identity model.UserIdentity
+	if err := database.Get().Where("user_id = ?", user.ID).First(&identity).Error; err != nil {
+		t.Fatalf("load identity: %v", err)
+	}
+	if identity.Issuer != idp.server.URL || identity.Subject != "fake|"+username {
+		t.Fatalf("identity = %s %s", identity.Issuer, identity.Subject)
+	}
+
+	// 再次登录使用已绑定的账号
+	authURL, err = s.BeginAuth(ctx, "", 0)
+	if err != nil {
+		t.Fatalf("BeginAuth: %v", err)
+	}
+	state, code = idp.login(authURL, username)
+	redirect, err = s.HandleCallback(ctx, state, code)
+	if err != nil {
+		t.Fatalf("second HandleCallback: %v", err)
+	}
+	r, _ = url.Parse(redirect)
+	again, err := s.ConsumeLoginCode(r.Query().Get("oidc_code"))
+	if err != nil {
+		t.Fatalf("second ConsumeLoginCode: %v", err)
+	}
+	if again.ID != user.ID {
+		t.Fatalf("second login user = %d, want %d", again.ID, user.ID)
+	}
+}
+
+func TestOIDCCallbackRejectsUnknownState(t *testing.T) {
+	idp := newFakeIdP(t)
+	s := idp.service()
+	ctx := context.Background()
+
+	authURL, err := s.BeginAuth(ctx, "", 0)
+	if err != nil {
+		t.Fatalf("BeginAuth: %v", err)
+	}
+	_, code := idp.login(authURL, uniqueName("sso"))
+
+	if _, err := s.HandleCallback(ctx, "forged-state", code); !errors.Is(err, ErrOIDCInvalidState) {
+		t.Fatalf("forged state: err = %v, want ErrOIDCInvalidState", err)
+	}
+	idp.mu.Lock()
+	exchanges := idp.exchanges
+	idp.mu.Unlock()
+	if exchanges != 0 {
+		t.Fatalf("token endpoint called %d times for a forged state", exchanges)
+	}
+}
+
+func TestOIDCCallbackRequiresMatchingPKCEVerifier(t *testing.T) {
+	idp := newFakeIdP(t)
+	s := idp.service()
+	ctx := context.Background()
+
+	first, err := s.BeginAuth(ctx, "", 0)
+	if err != nil {
+		t.Fatalf("BeginAuth: %v", err)
+	}
+	second, err := s.BeginAuth(ctx, "", 0)
+	if err != nil {
+		t.Fatalf("BeginAuth: %v", err)
+	}
+	_, code := idp.login(first, uniqueName("sso"))
+	secondState, _ := idp.login(second, uniqueName("sso"))
+
+	// 用另一次授权的 state 兑换授权码，code_verifier 与 code_challenge 不匹配
+	_, err = s.HandleCallback(ctx, secondState, code)
+	if err == nil || errors.Is(err, ErrOIDCInvalidState) {
+		t.Fatalf("mismatched verifier: err = %v, want token exchange failure", err)
+	}
+}
+
+func TestOIDCCallbackRejectsNonceMismatch(t *testing.T) {
+	idp := newFakeIdP(t)
+	idp.nonce = "replayed-nonce"
+	s := idp.service()
+	ctx := context.Background()
+	username := uniqueName("sso")
+
+	authURL, err := s.BeginAuth(ctx, "", 0)
+	if err != nil {
+		t.Fatalf("BeginAuth: %v", err)
+	}
+	state, code := idp.login(authURL, username)
+	if _, err := s.HandleCallback(ctx, state, code); !errors.Is(err, oidc.ErrNonceMismatch) {
+		t.Fatalf("nonce mismatch: err = %v, want ErrNonceMismatch", err)
+	}
+
+	var count int64
+	database.Get().Model(&model.User{}).Where("username = ?", username).Count(&count)
+	if count != 0 {
+		t.Fatal("user created from an id token with a mismatched nonce")
+	}
+}
+
+func TestOIDCAvailableUsername(t *testing.T) {
+	s := newOIDCService(config.OIDCConfig{})
+
+	// 没有 preferred_username 时取邮箱前缀，重名时追加序号
+	base := uniqueName("mailuser")
+	taken := &model.User{Username: base, DisplayName: base}
+	if err := database.Get().Create(taken).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	got, err := s.availableUsername(&oidc.IDTokenClaims{Email: base + "@example.com"})
+	if err != nil {
+		t.Fatalf("availableUsername: %v", err)
+	}
+	if got != base+"_2" {
+		t.Fatalf("username = %q, want %q", got, base+"_2")
+	}
+
+	// 含屏蔽词的名称无论追加什么后缀都不可用，最终返回错误而不是绕过检查
+	if got, err := s.availableUsername(&oidc.IDTokenClaims{PreferredUsername: "scunthorpe"}); !errors.Is(err, ErrOIDCNoUsername) {
+		t.Fatalf("blocked username: got %q, err = %v, want ErrOIDCNoUsername", got, err)
+	}
+}
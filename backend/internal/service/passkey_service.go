@@ -0,0 +1,456 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"gorm.io/gorm"
+
+	"tidalcore-backend/config"
+	"tidalcore-backend/internal/auth"
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/internal/repository"
+)
+
+var (
+	ErrPasskeyNotFound       = errors.New("passkey not found")
+	ErrTooManyPasskeys       = errors.New("too many passkeys")
+	ErrInvalidPasskeySession = errors.New("invalid or expired passkey session")
+	ErrPasskeyVerification   = errors.New("passkey verification failed")
+)
+
+const (
+	MaxPasskeysPerUser = 10
+
+	// 浏览器弹出系统对话框后，用户需在有效期内完成验证
+	passkeyCeremonyTTL = 5 * time.Minute
+
+	// 用户句柄长度，规范上限为 64 字节
+	passkeyUserHandleSize = 32
+)
+
+// passkeyCeremony 进行中的注册或登录流程
+type passkeyCeremony struct {
+	session    webauthn.SessionData
+	userHandle []byte // 仅注册流程使用
+	expiresAt  time.Time
+}
+
+// PasskeyService 通行密钥 (WebAuthn) 注册与无密码登录
+// 进行中的注册和登录流程保存在内存中，登录使用可发现凭据，无需先输入用户名
+type PasskeyService struct {
+	webAuthn     *webauthn.WebAuthn
+	userRepo     *repository.UserRepository
+	passkeyRepo  *repository.PasskeyRepository
+	auditService *AuditService
+
+	mu            sync.Mutex
+	registrations map[uint]*passkeyCeremony   // 键为用户 ID
+	logins        map[string]*passkeyCeremony // 键为登录会话令牌摘要
+}
+
+var (
+	passkeyService     *PasskeyService
+	passkeyServiceOnce sync.Once
+	passkeyServiceErr  error
+)
+
+// InitPasskeyService 按配置初始化通行密钥服务，依赖方配置无效时返回错误
+func InitPasskeyService() error {
+	passkeyServiceOnce.Do(func() {
+		cfg := config.Get().WebAuthn
+		w, err := webauthn.New(&webauthn.Config{
+			RPID:          cfg.RPID,
+			RPDisplayName: cfg.RPDisplayName,
+			RPOrigins:     cfg.RPOrigins,
+			AuthenticatorSelection: protocol.AuthenticatorSelection{
+				ResidentKey:      protocol.ResidentKeyRequirementRequired,
+				UserVerification: protocol.VerificationRequired,
+			},
+			AttestationPreference: protocol.PreferNoAttestation,
+		})
+		if err != nil {
+			passkeyServiceErr = fmt.Errorf("invalid webauthn config: %w", err)
+			return
+		}
+
+		passkeyService = &PasskeyService{
+			webAuthn:      w,
+			userRepo:      repository.NewUserRepository(),
+			passkeyRepo:   repository.NewPasskeyRepository(),
+			auditService:  NewAuditService(),
+			registrations: make(map[uint]*passkeyCeremony),
+			logins:        make(map[string]*passkeyCeremony),
+		}
+	})
+	return passkeyServiceErr
+}
+
+// GetPasskeyService 获取全局通行密钥服务，需先调用 InitPasskeyService
+func GetPasskeyService() *PasskeyService {
+	return passkeyService
+}
+
+type PasskeyRegisterRequest struct {
+	Name       string          `json:"name" binding:"max=50"`
+	Credential json.RawMessage `json:"credential" binding:"required"` // navigator.credentials.create() 的结果
+}
+
+type PasskeyLoginBeginResponse struct {
+	SessionID string                        `json:"session_id"`
+	Options   *protocol.CredentialAssertion `json:"options"`
+}
+
+type PasskeyLoginRequest struct {
+	SessionID  string          `json:"session_id" binding:"required"`
+	Credential json.RawMessage `json:"credential" binding:"required"` // navigator.credentials.get() 的结果
+}
+
+type RenamePasskeyRequest struct {
+	Name string `json:"name" binding:"required,min=1,max=50"`
+}
+
+// passkeyUser 适配 webauthn.User 接口
+// 用户句柄是随机值，用户名和显示名称仅用于系统弹窗中区分账号
+type passkeyUser struct {
+	handle      []byte
+	user        *model.User
+	credentials []webauthn.Credential
+}
+
+func (u *passkeyUser) WebAuthnID() []byte                         { return u.handle }
+func (u *passkeyUser) WebAuthnName() string                       { return u.user.Username }
+func (u *passkeyUser) WebAuthnDisplayName() string                { return u.user.DisplayName }
+func (u *passkeyUser) WebAuthnCredentials() []webauthn.Credential { return u.credentials }
+
+// BeginRegistration 为当前用户生成通行密钥注册参数，交由浏览器调用 navigator.credentials.create()
+func (s *PasskeyService) BeginRegistration(userID uint) (*protocol.CredentialCreation, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := s.passkeyRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) >= MaxPasskeysPerUser {
+		return nil, ErrTooManyPasskeys
+	}
+
+	// 同一用户的所有通行密钥共用一个用户句柄，首次注册时随机生成
+	var handle []byte
+	if len(records) > 0 {
+		if handle, err = base64.RawURLEncoding.DecodeString(records[0].UserHandle); err != nil {
+			return nil, err
+		}
+	} else {
+		handle = make([]byte, passkeyUserHandleSize)
+		if _, err := rand.Read(handle); err != nil {
+			return nil, err
+		}
+	}
+
+	pu := &passkeyUser{handle: handle, user: user, credentials: toWebAuthnCredentials(records)}
+	creation, session, err := s.webAuthn.BeginRegistration(pu,
+		webauthn.WithExclusions(webauthn.Credentials(pu.credentials).CredentialDescriptors()),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	s.purgeExpiredLocked(now)
+	s.registrations[userID] = &passkeyCeremony{
+		session:    *session,
+		userHandle: handle,
+		expiresAt:  now.Add(passkeyCeremonyTTL),
+	}
+	s.mu.Unlock()
+
+	return creation, nil
+}
+
+// FinishRegistration 校验浏览器返回的注册结果并保存通行密钥，actor 为注册的用户本人
+func (s *PasskeyService) FinishRegistration(actor AuditActor, req *PasskeyRegisterRequest) (*model.PasskeyCredential, error) {
+	userID := actor.ID
+	s.mu.Lock()
+	ceremony, ok := s.registrations[userID]
+	delete(s.registrations, userID)
+	s.mu.Unlock()
+	if !ok || time.Now().After(ceremony.expiresAt) {
+		return nil, ErrInvalidPasskeySession
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(req.Credential)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPasskeyVerification, err)
+	}
+
+	pu := &passkeyUser{handle: ceremony.userHandle, user: user}
+	credential, err := s.webAuthn.CreateCredential(pu, ceremony.session, parsed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPasskeyVerification, err)
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = "通行密钥"
+	}
+
+	transports := make([]string, 0, len(credential.Transport))
+	for _, t := range credential.Transport {
+		transports = append(transports, string(t))
+	}
+
+	record := &model.PasskeyCredential{
+		UserID:          userID,
+		Name:            name,
+		CredentialID:    base64.RawURLEncoding.EncodeToString(credential.ID),
+		UserHandle:      base64.RawURLEncoding.EncodeToString(ceremony.userHandle),
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transports:      strings.Join(transports, ","),
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		Flags:           uint8(credential.Flags.ProtocolValue()),
+		BackupEligible:  credential.Flags.BackupEligible,
+	}
+	entry := &AuditEntry{
+		Action:     "passkey.register",
+		TargetType: model.AuditTargetPasskey,
+		After:      passkeyAuditOf(record),
+	}
+	err = s.auditService.Record(actor, entry, func(tx *gorm.DB) error {
+		if err := s.passkeyRepo.WithTx(tx).Create(record); err != nil {
+			return err
+		}
+		entry.TargetID = auditID(record.ID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// BeginLogin 生成无密码登录参数，交由浏览器调用 navigator.credentials.get()
+func (s *PasskeyService) BeginLogin() (*PasskeyLoginBeginResponse, error) {
+	assertion, session, err := s.webAuthn.BeginDiscoverableLogin(
+		webauthn.WithUserVerification(protocol.VerificationRequired),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := auth.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	s.purgeExpiredLocked(now)
+	s.logins[auth.HashToken(token)] = &passkeyCeremony{
+		session:   *session,
+		expiresAt: now.Add(passkeyCeremonyTTL),
+	}
+	s.mu.Unlock()
+
+	return &PasskeyLoginBeginResponse{SessionID: token, Options: assertion}, nil
+}
+
+// FinishLogin 校验浏览器返回的签名，成功后返回登录用户，ip 用于审计记录
+// 每个登录会话只能提交一次，失败后需重新获取登录参数
+func (s *PasskeyService) FinishLogin(req *PasskeyLoginRequest, ip string) (*model.User, error) {
+	key := auth.HashToken(req.SessionID)
+	s.mu.Lock()
+	ceremony, ok := s.logins[key]
+	delete(s.logins, key)
+	s.mu.Unlock()
+	if !ok || time.Now().After(ceremony.expiresAt) {
+		return nil, ErrInvalidPasskeySession
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(req.Credential)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPasskeyVerification, err)
+	}
+
+	var records []model.PasskeyCredential
+	lookup := func(rawID, userHandle []byte) (webauthn.User, error) {
+		found, err := s.passkeyRepo.GetByUserHandle(base64.RawURLEncoding.EncodeToString(userHandle))
+		if err != nil {
+			return nil, err
+		}
+		if len(found) == 0 {
+			return nil, ErrPasskeyNotFound
+		}
+		records = found
+
+		user, err := s.userRepo.GetByID(records[0].UserID)
+		if err != nil {
+			return nil, err
+		}
+		return &passkeyUser{handle: userHandle, user: user, credentials: toWebAuthnCredentials(records)}, nil
+	}
+
+	webAuthnUser, credential, err := s.webAuthn.ValidatePasskeyLogin(lookup, ceremony.session, parsed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPasskeyVerification, err)
+	}
+	user := webAuthnUser.(*passkeyUser).user
+
+	var used *model.PasskeyCredential
+	credentialID := base64.RawURLEncoding.EncodeToString(credential.ID)
+	for i := range records {
+		if records[i].CredentialID == credentialID {
+			used = &records[i]
+			break
+		}
+	}
+
+	// 签名计数未递增说明凭据可能被复制
+	if credential.Authenticator.CloneWarning {
+		entry := &AuditEntry{
+			Action:     "passkey.clone_warning",
+			TargetType: model.AuditTargetPasskey,
+			After:      map[string]uint32{"sign_count": credential.Authenticator.SignCount},
+		}
+		if used != nil {
+			entry.TargetID = auditID(used.ID)
+			entry.Before = passkeyAuditOf(used)
+		}
+		if err := s.auditService.Record(AuditActor{ID: user.ID, IP: ip}, entry, nil); err != nil {
+			log.Printf("Warning: Failed to record passkey clone warning for user %d: %v", user.ID, err)
+		}
+		return nil, ErrPasskeyVerification
+	}
+
+	if used != nil {
+		if err := s.passkeyRepo.RecordUse(used.ID, credential.Authenticator.SignCount, time.Now()); err != nil {
+			log.Printf("Warning: Failed to record passkey use %d: %v", used.ID, err)
+		}
+	}
+
+	return user, nil
+}
+
+// List 获取用户已注册的通行密钥
+func (s *PasskeyService) List(userID uint) ([]model.PasskeyCredential, error) {
+	return s.passkeyRepo.GetByUserID(userID)
+}
+
+// Rename 修改通行密钥名称
+func (s *PasskeyService) Rename(userID, id uint, req *RenamePasskeyRequest) error {
+	if _, err := s.passkeyRepo.GetByUser(id, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPasskeyNotFound
+		}
+		return err
+	}
+	return s.passkeyRepo.UpdateName(id, strings.TrimSpace(req.Name))
+}
+
+// Delete 删除通行密钥，actor 为通行密钥所属的用户本人
+func (s *PasskeyService) Delete(actor AuditActor, id uint) error {
+	record, err := s.passkeyRepo.GetByUser(id, actor.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPasskeyNotFound
+		}
+		return err
+	}
+	return s.auditService.Record(actor, &AuditEntry{
+		Action:     "passkey.delete",
+		TargetType: model.AuditTargetPasskey,
+		TargetID:   auditID(id),
+		Before:     passkeyAuditOf(record),
+	}, func(tx *gorm.DB) error {
+		deleted, err := s.passkeyRepo.WithTx(tx).DeleteByUser(id, actor.ID)
+		if err != nil {
+			return err
+		}
+		if !deleted {
+			return ErrPasskeyNotFound
+		}
+		return nil
+	})
+}
+
+// passkeyAudit 审计记录中的通行密钥信息，不含公钥
+type passkeyAudit struct {
+	UserID       uint   `json:"user_id"`
+	Name         string `json:"name"`
+	CredentialID string `json:"credential_id"`
+	SignCount    uint32 `json:"sign_count"`
+}
+
+func passkeyAuditOf(record *model.PasskeyCredential) passkeyAudit {
+	return passkeyAudit{
+		UserID:       record.UserID,
+		Name:         record.Name,
+		CredentialID: record.CredentialID,
+		SignCount:    record.SignCount,
+	}
+}
+
+// purgeExpiredLocked 清理过期的注册和登录流程，调用方需持有 s.mu
+func (s *PasskeyService) purgeExpiredLocked(now time.Time) {
+	for key, c := range s.registrations {
+		if now.After(c.expiresAt) {
+			delete(s.registrations, key)
+		}
+	}
+	for key, c := range s.logins {
+		if now.After(c.expiresAt) {
+			delete(s.logins, key)
+		}
+	}
+}
+
+// toWebAuthnCredentials 将数据库记录转换为 webauthn 库使用的凭据
+func toWebAuthnCredentials(records []model.PasskeyCredential) []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(records))
+	for _, r := range records {
+		id, err := base64.RawURLEncoding.DecodeString(r.CredentialID)
+		if err != nil {
+			continue
+		}
+
+		var transports []protocol.AuthenticatorTransport
+		if r.Transports != "" {
+			for _, t := range strings.Split(r.Transports, ",") {
+				transports = append(transports, protocol.AuthenticatorTransport(t))
+			}
+		}
+
+		credentials = append(credentials, webauthn.Credential{
+			ID:              id,
+			PublicKey:       r.PublicKey,
+			AttestationType: r.AttestationType,
+			Transport:       transports,
+			Flags:           webauthn.NewCredentialFlags(protocol.AuthenticatorFlags(r.Flags)),
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    r.AAGUID,
+				SignCount: r.SignCount,
+			},
+		})
+	}
+	return credentials
+}
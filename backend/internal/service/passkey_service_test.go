@@ -0,0 +1,320 @@
+package service
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+
+	"tidalcore-backend/config"
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/pkg/database"
+)
+
+const (
+	testRPID   = "localhost"
+	testOrigin = "http://localhost:5173"
+)
+
+// softAuthenticator 软件实现的通行密钥验证器，按浏览器的格式生成注册和登录结果
+type softAuthenticator struct {
+	t            *testing.T
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	signCount    uint32
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		t.Fatalf("rand: %v", err)
+	}
+	return &softAuthenticator{t: t, key: key, credentialID: id}
+}
+
+// ceremonyOptions 从注册或登录参数中读取验证器需要的字段
+type ceremonyOptions struct {
+	PublicKey struct {
+		Challenge string `json:"challenge"`
+		User      struct {
+			ID string `json:"id"`
+		} `json:"user"`
+	} `json:"publicKey"`
+}
+
+func (a *softAuthenticator) parseOptions(options interface{}) ceremonyOptions {
+	a.t.Helper()
+	data, err := json.Marshal(options)
+	if err != nil {
+		a.t.Fatalf("marshal options: %v", err)
+	}
+	var parsed ceremonyOptions
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		a.t.Fatalf("unmarshal options: %v", err)
+	}
+	return parsed
+}
+
+func (a *softAuthenticator) clientData(typ, challenge string) []byte {
+	data, _ := json.Marshal(map[string]string{
+		"type":      typ,
+		"challenge": challenge,
+		"origin":    testOrigin,
+	})
+	return data
+}
+
+// authData 生成验证器数据，attested 为 true 时附带凭据公钥
+func (a *softAuthenticator) authData(attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(testRPID))
+	flags := protocol.FlagUserPresent | protocol.FlagUserVerified
+	if attested {
+		flags |= protocol.FlagAttestedCredentialData
+	}
+
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, byte(flags))
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	if !attested {
+		return data
+	}
+
+	publicKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  int64(webauthncose.P256),
+		XCoord: a.key.PublicKey.X.FillBytes(make([]byte, 32)),
+		YCoord: a.key.PublicKey.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		a.t.Fatalf("marshal public key: %v", err)
+	}
+
+	data = append(data, make([]byte, 16)...) // AAGUID
+	data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+	data = append(data, a.credentialID...)
+	return append(data, publicKey...)
+}
+
+// create 模拟 navigator.credentials.create()
+func (a *softAuthenticator) create(options interface{}) json.RawMessage {
+	a.t.Helper()
+	opts := a.parseOptions(options)
+	handle, err := base64.RawURLEncoding.DecodeString(opts.PublicKey.User.ID)
+	if err != nil {
+		a.t.Fatalf("decode user handle: %v", err)
+	}
+	a.userHandle = handle
+
+	attestation, err := webauthncbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": a.authData(true),
+	})
+	if err != nil {
+		a.t.Fatalf("marshal attestation: %v", err)
+	}
+
+	return a.credential(map[string]interface{}{
+		"clientDataJSON":    b64(a.clientData("webauthn.create", opts.PublicKey.Challenge)),
+		"attestationObject": b64(attestation),
+		"transports":        []string{"internal"},
+	})
+}
+
+// get 模拟 navigator.credentials.get()，每次调用签名计数加一
+func (a *softAuthenticator) get(options interface{}) json.RawMessage {
+	a.t.Helper()
+	opts := a.parseOptions(options)
+	a.signCount++
+
+	clientData := a.clientData("webauthn.get", opts.PublicKey.Challenge)
+	authData := a.authData(false)
+	clientHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		a.t.Fatalf("sign: %v", err)
+	}
+
+	return a.credential(map[string]interface{}{
+		"clientDataJSON":    b64(clientData),
+		"authenticatorData": b64(authData),
+		"signature":         b64(signature),
+		"userHandle":        b64(a.userHandle),
+	})
+}
+
+func (a *softAuthenticator) credential(response map[string]interface{}) json.RawMessage {
+	data, err := json.Marshal(map[string]interface{}{
+		"id":       b64(a.credentialID),
+		"rawId":    b64(a.credentialID),
+		"type":     "public-key",
+		"response": response,
+	})
+	if err != nil {
+		a.t.Fatalf("marshal credential: %v", err)
+	}
+	return data
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func testPasskeyService(t *testing.T) *PasskeyService {
+	t.Helper()
+	cfg := config.Get()
+	cfg.WebAuthn.RPID = testRPID
+	cfg.WebAuthn.RPOrigins = []string{testOrigin}
+	if err := InitPasskeyService(); err != nil {
+		t.Fatalf("InitPasskeyService: %v", err)
+	}
+	return GetPasskeyService()
+}
+
+// countAudit 统计指定动作和目标的审计记录数
+func countAudit(t *testing.T, action, targetID string) int64 {
+	t.Helper()
+	var count int64
+	err := database.Get().Model(&model.AuditLog{}).
+		Where("action = ? AND target_id = ?", action, targetID).
+		Count(&count).Error
+	if err != nil {
+		t.Fatalf("count audit logs: %v", err)
+	}
+	return count
+}
+
+// registerPasskey 为用户注册软件验证器
+func registerPasskey(t *testing.T, s *PasskeyService, user *model.User) (*softAuthenticator, *model.PasskeyCredential) {
+	t.Helper()
+	authenticator := newSoftAuthenticator(t)
+
+	creation, err := s.BeginRegistration(user.ID)
+	if err != nil {
+		t.Fatalf("BeginRegistration: %v", err)
+	}
+	record, err := s.FinishRegistration(AuditActor{ID: user.ID, IP: "127.0.0.1"}, &PasskeyRegisterRequest{
+		Name:       "测试密钥",
+		Credential: authenticator.create(creation),
+	})
+	if err != nil {
+		t.Fatalf("FinishRegistration: %v", err)
+	}
+	return authenticator, record
+}
+
+func TestPasskeyRegisterAndLogin(t *testing.T) {
+	s := testPasskeyService(t)
+	user := createUser(t, false)
+
+	authenticator, record := registerPasskey(t, s, user)
+	if record.UserID != user.ID || record.CredentialID != b64(authenticator.credentialID) {
+		t.Fatalf("unexpected passkey record: %+v", record)
+	}
+	if n := countAudit(t, "passkey.register", auditID(record.ID)); n != 1 {
+		t.Fatalf("passkey.register audit logs = %d, want 1", n)
+	}
+
+	begin, err := s.BeginLogin()
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+	req := &PasskeyLoginRequest{SessionID: begin.SessionID, Credential: authenticator.get(begin.Options)}
+	loggedIn, err := s.FinishLogin(req, "127.0.0.1")
+	if err != nil {
+		t.Fatalf("FinishLogin: %v", err)
+	}
+	if loggedIn.ID != user.ID {
+		t.Fatalf("logged in as user %d, want %d", loggedIn.ID, user.ID)
+	}
+
+	// 登录会话只能使用一次
+	if _, err := s.FinishLogin(req, "127.0.0.1"); !errors.Is(err, ErrInvalidPasskeySession) {
+		t.Fatalf("reused session: err = %v, want ErrInvalidPasskeySession", err)
+	}
+
+	var stored model.PasskeyCredential
+	if err := database.Get().First(&stored, record.ID).Error; err != nil {
+		t.Fatalf("load passkey: %v", err)
+	}
+	if stored.SignCount != authenticator.signCount || stored.LastUsedAt == nil {
+		t.Fatalf("sign count = %d, last used = %v; want %d and a timestamp",
+			stored.SignCount, stored.LastUsedAt, authenticator.signCount)
+	}
+}
+
+func TestPasskeyLoginRejectsClonedCredential(t *testing.T) {
+	s := testPasskeyService(t)
+	user := createUser(t, false)
+	authenticator, record := registerPasskey(t, s, user)
+
+	login := func() error {
+		begin, err := s.BeginLogin()
+		if err != nil {
+			t.Fatalf("BeginLogin: %v", err)
+		}
+		_, err = s.FinishLogin(&PasskeyLoginRequest{
+			SessionID:  begin.SessionID,
+			Credential: authenticator.get(begin.Options),
+		}, "127.0.0.1")
+		return err
+	}
+
+	authenticator.signCount = 9
+	if err := login(); err != nil {
+		t.Fatalf("first login: %v", err)
+	}
+
+	// 复制出的凭据签名计数落后于已保存的值
+	authenticator.signCount = 4
+	if err := login(); !errors.Is(err, ErrPasskeyVerification) {
+		t.Fatalf("cloned login: err = %v, want ErrPasskeyVerification", err)
+	}
+	if n := countAudit(t, "passkey.clone_warning", auditID(record.ID)); n != 1 {
+		t.Fatalf("passkey.clone_warning audit logs = %d, want 1", n)
+	}
+}
+
+func TestPasskeyDeleteRecordsAudit(t *testing.T) {
+	s := testPasskeyService(t)
+	owner := createUser(t, false)
+	other := createUser(t, false)
+	_, record := registerPasskey(t, s, owner)
+
+	if err := s.Delete(AuditActor{ID: other.ID}, record.ID); !errors.Is(err, ErrPasskeyNotFound) {
+		t.Fatalf("delete by other user: err = %v, want ErrPasskeyNotFound", err)
+	}
+	if err := s.Delete(AuditActor{ID: owner.ID}, record.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if n := countAudit(t, "passkey.delete", auditID(record.ID)); n != 1 {
+		t.Fatalf("passkey.delete audit logs = %d, want 1", n)
+	}
+
+	passkeys, err := s.List(owner.ID)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(passkeys) != 0 {
+		t.Fatalf("passkeys after delete = %d, want 0", len(passkeys))
+	}
+}
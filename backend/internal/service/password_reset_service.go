@@ -0,0 +1,375 @@
+package service
+
+import (
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"tidalcore-backend/config"
+	"tidalcore-backend/internal/auth"
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/internal/repository"
+)
+
+var (
+	ErrInvalidResetCode    = errors.New("invalid or expired reset code")
+	ErrInvalidRecoveryCode = errors.New("invalid recovery code")
+)
+
+const (
+	resetCodeLength = 12
+
+	// 恢复码可在公开接口中直接重置密码，长度保证即使哈希泄露也无法离线穷举
+	recoveryCodeLength       = 16
+	passwordRecoveryCodeSize = 8
+)
+
+// PasswordResetService 忘记密码时的找回方式：本站不收集邮箱，
+// 用户可使用自己保存的恢复码，或联系管理员签发一次性重置码
+type PasswordResetService struct {
+	userRepo     *repository.UserRepository
+	resetRepo    *repository.PasswordResetRepository
+	recoveryRepo *repository.PasswordRecoveryRepository
+	apiTokenRepo *repository.APITokenRepository
+	auditService *AuditService
+}
+
+func NewPasswordResetService() *PasswordResetService {
+	return &PasswordResetService{
+		userRepo:     repository.NewUserRepository(),
+		resetRepo:    repository.NewPasswordResetRepository(),
+		recoveryRepo: repository.NewPasswordRecoveryRepository(),
+		apiTokenRepo: repository.NewAPITokenRepository(),
+		auditService: NewAuditService(),
+	}
+}
+
+// IssuedResetCode 新签发的重置码，明文仅返回这一次
+type IssuedResetCode struct {
+	Username  string    `json:"username"`
+	Code      string    `json:"code"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type ResetPasswordRequest struct {
+	Username    string `json:"username" binding:"required,max=50"`
+	Code        string `json:"code" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+type RegenerateRecoveryCodesRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// RecoveryCodeStatus 恢复码使用情况
+type RecoveryCodeStatus struct {
+	Remaining   int64      `json:"remaining"`
+	GeneratedAt *time.Time `json:"generated_at"`
+}
+
+// IssueResetCode 为用户签发重置码，之前未使用的重置码随即作废（管理员功能）
+func (s *PasswordResetService) IssueResetCode(actor AuditActor, userID uint) (*IssuedResetCode, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	raw, err := generateCode(resetCodeLength)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := time.Duration(config.Get().Security.ResetCodeMinutes) * time.Minute
+	reset := &model.PasswordReset{
+		UserID:    user.ID,
+		CodeHash:  auth.HashToken(raw),
+		IssuedBy:  actor.ID,
+		IssuedIP:  truncate(actor.IP, 64),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	// 审计记录只保存有效期，不含重置码
+	err = s.auditService.Record(actor, &AuditEntry{
+		Action:     "password_reset.issue",
+		TargetType: model.AuditTargetUser,
+		TargetID:   auditID(user.ID),
+		After:      map[string]interface{}{"expires_at": reset.ExpiresAt},
+	}, func(tx *gorm.DB) error {
+		return s.resetRepo.WithTx(tx).CreateReplacing(reset)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &IssuedResetCode{
+		Username:  user.Username,
+		Code:      formatResetCode(raw),
+		ExpiresAt: reset.ExpiresAt,
+	}, nil
+}
+
+// ListResetCodes 获取用户的重置码签发和使用记录（管理员功能）
+func (s *PasswordResetService) ListResetCodes(userID uint) ([]model.PasswordReset, error) {
+	return s.resetRepo.GetByUserID(userID, 20)
+}
+
+// ResetPassword 凭重置码设置新密码，成功后该用户所有登录会话失效
+// 重置码输错达到上限即作废，需要管理员重新签发；成功和失败都写入审计日志
+func (s *PasswordResetService) ResetPassword(req *ResetPasswordRequest, ip string) error {
+	username := strings.TrimSpace(req.Username)
+
+	// 先校验新密码，避免重置码已使用却因密码不合规而白白作废
+	if err := checkPasswordPolicy(req.NewPassword, username); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.GetByUsername(username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return s.recordFailure("password_reset.fail", ip, username, nil, "unknown_user", ErrInvalidResetCode, nil)
+		}
+		return err
+	}
+
+	reset, err := s.resetRepo.GetActiveByUserID(user.ID, time.Now())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return s.recordFailure("password_reset.fail", ip, username, user, "no_active_code", ErrInvalidResetCode, nil)
+		}
+		return err
+	}
+
+	hash := auth.HashToken(normalizeResetCode(req.Code))
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(reset.CodeHash)) != 1 {
+		maxAttempts := config.Get().Security.MaxResetAttempts
+		return s.recordFailure("password_reset.fail", ip, username, user, "wrong_code", ErrInvalidResetCode,
+			func(tx *gorm.DB) error {
+				return s.resetRepo.WithTx(tx).RecordFailedAttempt(reset.ID, maxAttempts)
+			})
+	}
+
+	hashedPassword, err := auth.HashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	// 重置码作废与密码修改在同一事务中，并发使用同一重置码时只有一个请求成功
+	var cutoff time.Time
+	err = s.auditService.Record(AuditActor{ID: user.ID, IP: ip}, &AuditEntry{
+		Action:     "password_reset.success",
+		TargetType: model.AuditTargetUser,
+		TargetID:   auditID(user.ID),
+		After:      map[string]interface{}{"reset_id": reset.ID, "issued_by": reset.IssuedBy},
+	}, func(tx *gorm.DB) error {
+		used, err := s.resetRepo.WithTx(tx).MarkUsed(reset.ID, truncate(ip, 64))
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidResetCode
+		}
+		cutoff, err = s.setPasswordTx(tx, user, hashedPassword)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return s.afterPasswordChange(user, cutoff)
+}
+
+// recordFailure 写入重置或找回密码失败的审计记录后返回 failErr
+// 请求未登录，操作者 ID 为 0；user 为空表示用户名不存在，此时记录尝试的用户名
+func (s *PasswordResetService) recordFailure(action, ip, username string, user *model.User, reason string,
+	failErr error, change func(tx *gorm.DB) error) error {
+	entry := &AuditEntry{
+		Action:     action,
+		TargetType: model.AuditTargetUser,
+		After:      map[string]interface{}{"reason": reason},
+	}
+	if user != nil {
+		entry.TargetID = auditID(user.ID)
+	} else {
+		entry.After = map[string]interface{}{"reason": reason, "username": truncate(username, 50)}
+	}
+	if err := s.auditService.Record(AuditActor{IP: ip}, entry, change); err != nil {
+		return err
+	}
+	return failErr
+}
+
+// GenerateRecoveryCodes 生成一组新的恢复码，旧的一组随即作废，明文仅返回这一次
+func (s *PasswordResetService) GenerateRecoveryCodes(userID uint) ([]string, error) {
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.recoveryRepo.Replace(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// RegenerateRecoveryCodes 验证密码后重新生成恢复码，记录在审计日志中
+func (s *PasswordResetService) RegenerateRecoveryCodes(actor AuditActor, req *RegenerateRecoveryCodesRequest) ([]string, error) {
+	user, err := s.userRepo.GetByID(actor.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !auth.CheckPassword(req.Password, user.PasswordHash) {
+		return nil, ErrOldPasswordWrong
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	err = s.auditService.Record(actor, &AuditEntry{
+		Action:     "recovery_codes.regenerate",
+		TargetType: model.AuditTargetUser,
+		TargetID:   auditID(user.ID),
+	}, func(tx *gorm.DB) error {
+		return s.recoveryRepo.WithTx(tx).Replace(user.ID, hashes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// newRecoveryCodes 生成一组恢复码，返回明文和对应的哈希
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, passwordRecoveryCodeSize)
+	hashes := make([]string, 0, passwordRecoveryCodeSize)
+	for i := 0; i < passwordRecoveryCodeSize; i++ {
+		raw, err := generateCode(recoveryCodeLength)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, formatResetCode(raw))
+		hashes = append(hashes, auth.HashToken(raw))
+	}
+	return codes, hashes, nil
+}
+
+func (s *PasswordResetService) GetRecoveryCodeStatus(userID uint) (*RecoveryCodeStatus, error) {
+	remaining, generatedAt, err := s.recoveryRepo.GetStatus(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &RecoveryCodeStatus{Remaining: remaining, GeneratedAt: generatedAt}, nil
+}
+
+// RecoverPassword 使用恢复码重置密码，无需管理员参与，成功和失败都写入审计日志
+// 恢复码输错计入登录失败次数，连续失败会触发账号锁定
+func (s *PasswordResetService) RecoverPassword(req *ResetPasswordRequest, ip string) error {
+	username := strings.TrimSpace(req.Username)
+
+	if err := checkPasswordPolicy(req.NewPassword, username); err != nil {
+		return err
+	}
+
+	guard := GetLoginGuard()
+	if err := guard.Check(username); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.GetByUsername(username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := guard.RecordFailure(username, ip); err != nil {
+				return err
+			}
+			return s.recordFailure("password_recover.fail", ip, username, nil, "unknown_user", ErrInvalidRecoveryCode, nil)
+		}
+		return err
+	}
+
+	codeHash := auth.HashToken(normalizeResetCode(req.Code))
+	usable, err := s.recoveryRepo.IsUsable(user.ID, codeHash)
+	if err != nil {
+		return err
+	}
+	if !usable {
+		if err := guard.RecordFailure(username, ip); err != nil {
+			return err
+		}
+		return s.recordFailure("password_recover.fail", ip, username, user, "wrong_code", ErrInvalidRecoveryCode, nil)
+	}
+
+	hashedPassword, err := auth.HashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	// 恢复码在修改密码的事务中作废，并发使用同一恢复码时只有一个请求成功
+	var cutoff time.Time
+	err = s.auditService.Record(AuditActor{ID: user.ID, IP: ip}, &AuditEntry{
+		Action:     "password_recover.success",
+		TargetType: model.AuditTargetUser,
+		TargetID:   auditID(user.ID),
+	}, func(tx *gorm.DB) error {
+		consumed, err := s.recoveryRepo.WithTx(tx).Consume(user.ID, codeHash)
+		if err != nil {
+			return err
+		}
+		if !consumed {
+			return ErrInvalidRecoveryCode
+		}
+		cutoff, err = s.setPasswordTx(tx, user, hashedPassword)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return s.afterPasswordChange(user, cutoff)
+}
+
+// setPasswordTx 在事务中保存新密码哈希并吊销该用户已签发的令牌，个人访问令牌一并删除
+func (s *PasswordResetService) setPasswordTx(tx *gorm.DB, user *model.User, hashedPassword string) (time.Time, error) {
+	cutoff, err := GetRevocationService().RevokeAllForUserTx(tx, user.ID)
+	if err != nil {
+		return cutoff, err
+	}
+	if err := s.apiTokenRepo.WithTx(tx).DeleteByUserID(user.ID); err != nil {
+		return cutoff, err
+	}
+	user.PasswordHash = hashedPassword
+	user.TokensRevokedAt = &cutoff
+	return cutoff, s.userRepo.WithTx(tx).UpdateFields(user, "password_hash")
+}
+
+// afterPasswordChange 事务提交后使旧访问令牌立即失效
+func (s *PasswordResetService) afterPasswordChange(user *model.User, cutoff time.Time) error {
+	GetRevocationService().ApplyUserCutoff(user.ID, cutoff)
+	GetUserStateCache().Invalidate(user.ID)
+
+	// 重置成功后解除因忘记密码反复尝试导致的登录锁定
+	return GetLoginGuard().RecordSuccess(user.Username)
+}
+
+// formatResetCode 每 4 位加分隔符，便于口头或手写转述
+func formatResetCode(code string) string {
+	var sb strings.Builder
+	for i := 0; i < len(code); i += 4 {
+		if i > 0 {
+			sb.WriteByte('-')
+		}
+		end := i + 4
+		if end > len(code) {
+			end = len(code)
+		}
+		sb.WriteString(code[i:end])
+	}
+	return sb.String()
+}
+
+// normalizeResetCode 忽略大小写、空格和分隔符
+func normalizeResetCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"tidalcore-backend/internal/auth"
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/pkg/database"
+)
+
+func TestResetPasswordRecordsAudit(t *testing.T) {
+	s := NewPasswordResetService()
+	admin := createUser(t, false)
+	user := createUser(t, false)
+	target := auditID(user.ID)
+
+	issued, err := s.IssueResetCode(AuditActor{ID: admin.ID, IP: "10.0.0.1"}, user.ID)
+	if err != nil {
+		t.Fatalf("IssueResetCode: %v", err)
+	}
+
+	err = s.ResetPassword(&ResetPasswordRequest{Username: user.Username, Code: "AAAA-BBBB-CCCC", NewPassword: testPassword}, "10.0.0.2")
+	if !errors.Is(err, ErrInvalidResetCode) {
+		t.Fatalf("wrong code: err = %v, want ErrInvalidResetCode", err)
+	}
+	if n := countAudit(t, "password_reset.fail", target); n != 1 {
+		t.Fatalf("password_reset.fail audit logs = %d, want 1", n)
+	}
+
+	req := &ResetPasswordRequest{Username: user.Username, Code: issued.Code, NewPassword: testPassword}
+	if err := s.ResetPassword(req, "10.0.0.2"); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+	if n := countAudit(t, "password_reset.success", target); n != 1 {
+		t.Fatalf("password_reset.success audit logs = %d, want 1", n)
+	}
+
+	var stored model.User
+	if err := database.Get().First(&stored, user.ID).Error; err != nil {
+		t.Fatalf("load user: %v", err)
+	}
+	if !auth.CheckPassword(testPassword, stored.PasswordHash) || stored.TokensRevokedAt == nil {
+		t.Fatalf("password not reset or tokens not revoked: revoked_at=%v", stored.TokensRevokedAt)
+	}
+
+	// 重置码只能使用一次，再次提交按无有效重置码记录失败
+	if err := s.ResetPassword(req, "10.0.0.2"); !errors.Is(err, ErrInvalidResetCode) {
+		t.Fatalf("reused code: err = %v, want ErrInvalidResetCode", err)
+	}
+	if n := countAudit(t, "password_reset.fail", target); n != 2 {
+		t.Fatalf("password_reset.fail audit logs = %d, want 2", n)
+	}
+}
+
+func TestResetPasswordRollsBackWithoutAudit(t *testing.T) {
+	s := NewPasswordResetService()
+	admin := createUser(t, false)
+	user := createUser(t, false)
+
+	issued, err := s.IssueResetCode(AuditActor{ID: admin.ID}, user.ID)
+	if err != nil {
+		t.Fatalf("IssueResetCode: %v", err)
+	}
+
+	// 审计记录写入失败时重置码不作废，密码也不修改
+	req := &ResetPasswordRequest{Username: user.Username, Code: issued.Code, NewPassword: testPassword}
+	failAuditWrites(t, func() {
+		if err := s.ResetPassword(req, ""); err == nil {
+			t.Fatal("ResetPassword succeeded although the audit log could not be written")
+		}
+	})
+
+	var stored model.User
+	if err := database.Get().First(&stored, user.ID).Error; err != nil {
+		t.Fatalf("load user: %v", err)
+	}
+	if stored.PasswordHash != "" || stored.TokensRevokedAt != nil {
+		t.Fatalf("password changed although the reset was rolled back")
+	}
+	if err := s.ResetPassword(req, ""); err != nil {
+		t.Fatalf("ResetPassword after rollback: %v", err)
+	}
+}
+
+func TestRecoverPasswordRecordsAudit(t *testing.T) {
+	s := NewPasswordResetService()
+	hash, err := auth.HashPassword(testPassword)
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	user := &model.User{Username: uniqueName("recover"), DisplayName: "找回密码", PasswordHash: hash}
+	if err := database.Get().Create(user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	target := auditID(user.ID)
+
+	codes, err := s.RegenerateRecoveryCodes(AuditActor{ID: user.ID, IP: "10.0.0.3"}, &RegenerateRecoveryCodesRequest{Password: testPassword})
+	if err != nil {
+		t.Fatalf("RegenerateRecoveryCodes: %v", err)
+	}
+	if n := countAudit(t, "recovery_codes.regenerate", target); n != 1 {
+		t.Fatalf("recovery_codes.regenerate audit logs = %d, want 1", n)
+	}
+
+	newPassword := testPassword + "2"
+	err = s.RecoverPassword(&ResetPasswordRequest{Username: user.Username, Code: "WRONG", NewPassword: newPassword}, "10.0.0.3")
+	if !errors.Is(err, ErrInvalidRecoveryCode) {
+		t.Fatalf("wrong code: err = %v, want ErrInvalidRecoveryCode", err)
+	}
+	if n := countAudit(t, "password_recover.fail", target); n != 1 {
+		t.Fatalf("password_recover.fail audit logs = %d, want 1", n)
+	}
+
+	req := &ResetPasswordRequest{Username: user.Username, Code: codes[0], NewPassword: newPassword}
+	if err := s.RecoverPassword(req, "10.0.0.3"); err != nil {
+		t.Fatalf("RecoverPassword: %v", err)
+	}
+	if n := countAudit(t, "password_recover.success", target); n != 1 {
+		t.Fatalf("password_recover.success audit logs = %d, want 1", n)
+	}
+	if err := s.RecoverPassword(req, "10.0.0.3"); !errors.Is(err, ErrInvalidRecoveryCode) {
+		t.Fatalf("reused code: err = %v, want ErrInvalidRecoveryCode", err)
+	}
+
+	var remaining int64
+	err = database.Get().Model(&model.PasswordRecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", user.ID).
+		Count(&remaining).Error
+	if err != nil {
+		t.Fatalf("count recovery codes: %v", err)
+	}
+	if remaining != int64(len(codes)-1) {
+		t.Fatalf("remaining recovery codes = %d, want %d", remaining, len(codes)-1)
+	}
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"tidalcore-backend/internal/auth"
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/internal/repository"
+	"tidalcore-backend/pkg/database"
+)
+
+// RevocationService 访问令牌吊销
+// 单个令牌按 jti 吊销；登录会话按会话 ID 吊销；
+// 修改密码、删除用户等场景按用户设置失效时间，之前签发的令牌全部失效
+// 吊销数据常驻内存，写入时同步落库，启动时从数据库加载
+type RevocationService struct {
+	revokedRepo *repository.RevokedTokenRepository
+	userRepo    *repository.UserRepository
+	refreshRepo *repository.RefreshTokenRepository
+	sessionRepo *repository.SessionRepository
+
+	mu       sync.RWMutex
+	jtis     map[string]time.Time // jti -> 令牌过期时间
+	cutoffs  map[uint]time.Time   // 用户 ID -> 令牌失效时间
+	sessions map[uint]time.Time   // 会话 ID -> 吊销时间
+}
+
+var (
+	revocationService     *RevocationService
+	revocationServiceOnce sync.Once
+)
+
+// GetRevocationService 获取全局吊销服务
+func GetRevocationService() *RevocationService {
+	revocationServiceOnce.Do(func() {
+		revocationService = &RevocationService{
+			revokedRepo: repository.NewRevokedTokenRepository(),
+			userRepo:    repository.NewUserRepository(),
+			refreshRepo: repository.NewRefreshTokenRepository(),
+			sessionRepo: repository.NewSessionRepository(),
+			jtis:        make(map[string]time.Time),
+			cutoffs:     make(map[uint]time.Time),
+			sessions:    make(map[uint]time.Time),
+		}
+	})
+	return revocationService
+}
+
+// Load 从数据库加载吊销数据并注册到令牌解析流程
+func (s *RevocationService) Load() error {
+	tokens, err := s.revokedRepo.GetActive(time.Now())
+	if err != nil {
+		return err
+	}
+	cutoffs, err := s.userRepo.GetTokenCutoffs()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	sessions, err := s.sessionRepo.GetRevokedSince(now.Add(-auth.AccessTokenTTL()))
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	for _, t := range tokens {
+		s.jtis[t.JTI] = t.ExpiresAt
+	}
+	for id, t := range cutoffs {
+		if now.Sub(t) <= auth.AccessTokenTTL() {
+			s.cutoffs[id] = t
+		}
+	}
+	for _, session := range sessions {
+		s.sessions[session.ID] = *session.RevokedAt
+	}
+	s.mu.Unlock()
+
+	auth.SetRevocationChecker(s.IsRevoked)
+	return nil
+}
+
+// IsRevoked 判断令牌是否已被吊销
+func (s *RevocationService) IsRevoked(claims *auth.Claims) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if claims.ID != "" {
+		if _, ok := s.jtis[claims.ID]; ok {
+			return true
+		}
+	}
+	if claims.SessionID != 0 {
+		if _, ok := s.sessions[claims.SessionID]; ok {
+			return true
+		}
+	}
+	if cutoff, ok := s.cutoffs[claims.UserID]; ok {
+		// 与失效时间处于同一毫秒的令牌无法判断先后，一律视为已吊销
+		if claims.IssuedAt == nil || !claims.IssuedAt.Time.After(cutoff) {
+			return true
+		}
+	}
+	return false
+}
+
+// RevokeToken 吊销单个访问令牌
+func (s *RevocationService) RevokeToken(jti string, userID uint, expiresAt time.Time) error {
+	if jti == "" {
+		return nil
+	}
+	err := s.revokedRepo.Create(&model.RevokedToken{
+		JTI:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.jtis[jti] = expiresAt
+	s.mu.Unlock()
+	return nil
+}
+
+// RevokeSessions 吊销登录会话及其刷新令牌和访问令牌
+func (s *RevocationService) RevokeSessions(ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := s.sessionRepo.Revoke(ids); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		session, err := s.sessionRepo.GetByID(id)
+		if err != nil {
+			return err
+		}
+		if err := s.refreshRepo.RevokeFamily(session.FamilyID); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	for _, id := range ids {
+		s.sessions[id] = now
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// RevokeAllForUser 吊销用户当前所有的会话、访问令牌和刷新令牌
+// 返回的时间之后签发的令牌不受影响
+func (s *RevocationService) RevokeAllForUser(userID uint) (time.Time, error) {
+	cutoff, err := s.RevokeAllForUserTx(database.Get(), userID)
+	if err != nil {
+		return cutoff, err
+	}
+	s.ApplyUserCutoff(userID, cutoff)
+	return cutoff, nil
+}
+
+// RevokeAllForUserTx 在调用方的事务中写入用户的令牌失效时间并吊销其会话和刷新令牌
+// 事务提交后须调用 ApplyUserCutoff，已签发的访问令牌才会失效；事务回滚时不影响现有令牌
+func (s *RevocationService) RevokeAllForUserTx(tx *gorm.DB, userID uint) (time.Time, error) {
+	// 精确到毫秒以便数据库 datetime(3) 完整保存；向上取整，吊销前签发的令牌都不晚于失效时间
+	cutoff := time.Now().Truncate(time.Millisecond).Add(time.Millisecond)
+	if err := s.userRepo.WithTx(tx).SetTokensRevokedAt(userID, cutoff); err != nil {
+		return cutoff, err
+	}
+	if err := s.refreshRepo.WithTx(tx).RevokeByUserID(userID); err != nil {
+		return cutoff, err
+	}
+
+	sessionRepo := s.sessionRepo.WithTx(tx)
+	ids, err := sessionRepo.GetActiveIDsByUserID(userID)
+	if err != nil {
+		return cutoff, err
+	}
+	if err := sessionRepo.Revoke(ids); err != nil {
+		return cutoff, err
+	}
+	return cutoff, nil
+}
+
+// ApplyUserCutoff 使用户在失效时间及之前签发的访问令牌立即失效
+func (s *RevocationService) ApplyUserCutoff(userID uint, cutoff time.Time) {
+	s.mu.Lock()
+	s.cutoffs[userID] = cutoff
+	s.mu.Unlock()
+
+	// 等到失效时间之后，此后签发的令牌（如修改密码后返回的新令牌）不会被误判为已吊销
+	if d := time.Until(cutoff.Add(time.Millisecond)); d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// StartCleanup 启动后台任务，定期清理已过期令牌的吊销记录
+func (s *RevocationService) StartCleanup(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			now := time.Now()
+			if _, err := s.revokedRepo.DeleteExpired(now); err != nil {
+				log.Printf("Warning: Failed to clean up revoked tokens: %v", err)
+				continue
+			}
+
+			s.mu.Lock()
+			for jti, exp := range s.jtis {
+				if exp.Before(now) {
+					delete(s.jtis, jti)
+				}
+			}
+			// 失效时间早于访问令牌有效期的用户和会话已不存在有效的旧令牌
+			for id, cutoff := range s.cutoffs {
+				if now.Sub(cutoff) > auth.AccessTokenTTL() {
+					delete(s.cutoffs, id)
+				}
+			}
+			for id, revokedAt := range s.sessions {
+				if now.Sub(revokedAt) > auth.AccessTokenTTL() {
+					delete(s.sessions, id)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}()
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"tidalcore-backend/internal/auth"
+)
+
+func TestRevokeAllForUserRejectsTokensFromSameSecond(t *testing.T) {
+	s := GetRevocationService()
+	user := createUser(t, false)
+
+	before, err := auth.GenerateToken(user.ID, user.Username)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	cutoff, err := s.RevokeAllForUser(user.ID)
+	if err != nil {
+		t.Fatalf("RevokeAllForUser: %v", err)
+	}
+	if _, err := auth.ParseToken(before); !errors.Is(err, auth.ErrRevokedToken) {
+		t.Fatalf("token issued before revocation: err = %v, want ErrRevokedToken", err)
+	}
+
+	tests := []struct {
+		name     string
+		issuedAt time.Time
+		revoked  bool
+	}{
+		{"just before cutoff", cutoff.Add(-time.Millisecond), true},
+		{"same millisecond", cutoff, true},
+		{"after cutoff", cutoff.Add(time.Millisecond), false},
+	}
+	for _, tt := range tests {
+		claims := &auth.Claims{UserID: user.ID}
+		claims.IssuedAt = jwt.NewNumericDate(tt.issuedAt)
+		if got := s.IsRevoked(claims); got != tt.revoked {
+			t.Errorf("%s: IsRevoked = %v, want %v", tt.name, got, tt.revoked)
+		}
+	}
+
+	// RevokeAllForUser 返回后签发的令牌不受影响
+	after, err := auth.GenerateToken(user.ID, user.Username)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	if _, err := auth.ParseToken(after); err != nil {
+		t.Fatalf("token issued after revocation: %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"tidalcore-backend/internal/auth"
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/internal/repository"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+// SessionService 用户登录会话管理
+type SessionService struct {
+	sessionRepo *repository.SessionRepository
+}
+
+func NewSessionService() *SessionService {
+	return &SessionService{
+		sessionRepo: repository.NewSessionRepository(),
+	}
+}
+
+// SessionInfo 会话信息，标记是否为当前会话
+type SessionInfo struct {
+	model.Session
+	Current bool `json:"current"`
+}
+
+// ListSessions 获取用户当前有效的登录会话
+func (s *SessionService) ListSessions(userID, currentSessionID uint) ([]SessionInfo, error) {
+	since := time.Now().Add(-auth.RefreshTokenTTL())
+	sessions, err := s.sessionRepo.GetActiveByUserID(userID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, SessionInfo{
+			Session: session,
+			Current: session.ID == currentSessionID,
+		})
+	}
+	return result, nil
+}
+
+// RevokeSession 注销指定会话
+func (s *SessionService) RevokeSession(userID, sessionID uint) error {
+	session, err := s.sessionRepo.GetByID(sessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+	if session.UserID != userID || session.RevokedAt != nil {
+		return ErrSessionNotFound
+	}
+	return GetRevocationService().RevokeSessions([]uint{sessionID})
+}
+
+// RevokeOtherSessions 注销除当前会话外的所有会话，返回注销数量
+func (s *SessionService) RevokeOtherSessions(userID, currentSessionID uint) (int, error) {
+	ids, err := s.sessionRepo.GetActiveIDsByUserID(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	others := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if id != currentSessionID {
+			others = append(others, id)
+		}
+	}
+	if err := GetRevocationService().RevokeSessions(others); err != nil {
+		return 0, err
+	}
+	return len(others), nil
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"tidalcore-backend/internal/auth"
+	"tidalcore-backend/internal/model"
+)
+
+// issueSessions 为用户登录 n 个会话，返回令牌和对应的会话 ID
+func issueSessions(t *testing.T, user *model.User, n int) ([]*TokenPair, []uint) {
+	t.Helper()
+	tokens := NewTokenService()
+	pairs := make([]*TokenPair, 0, n)
+	ids := make([]uint, 0, n)
+	for i := 0; i < n; i++ {
+		pair, err := tokens.IssueTokens(user, ClientInfo{IP: "10.0.0.1", UserAgent: "test"})
+		if err != nil {
+			t.Fatalf("IssueTokens: %v", err)
+		}
+		id, err := tokens.SessionIDByRefreshToken(user.ID, pair.RefreshToken)
+		if err != nil {
+			t.Fatalf("SessionIDByRefreshToken: %v", err)
+		}
+		pairs = append(pairs, pair)
+		ids = append(ids, id)
+	}
+	return pairs, ids
+}
+
+func TestListSessions(t *testing.T) {
+	s := NewSessionService()
+	user := createUser(t, false)
+	other := createUser(t, false)
+	_, ids := issueSessions(t, user, 3)
+	issueSessions(t, other, 1)
+
+	if err := GetRevocationService().RevokeSessions(ids[2:]); err != nil {
+		t.Fatalf("RevokeSessions: %v", err)
+	}
+
+	sessions, err := s.ListSessions(user.ID, ids[1])
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	// 只列出本人未注销的会话，并标记当前会话
+	if len(sessions) != 2 {
+		t.Fatalf("ListSessions returned %d sessions, want 2", len(sessions))
+	}
+	for _, session := range sessions {
+		if session.UserID != user.ID || (session.ID != ids[0] && session.ID != ids[1]) {
+			t.Fatalf("unexpected session %+v", session.Session)
+		}
+		if session.Current != (session.ID == ids[1]) {
+			t.Fatalf("session %d current = %v", session.ID, session.Current)
+		}
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	s := NewSessionService()
+	user := createUser(t, false)
+	other := createUser(t, false)
+	pairs, ids := issueSessions(t, user, 2)
+	_, otherIDs := issueSessions(t, other, 1)
+
+	tests := []struct {
+		name      string
+		sessionID uint
+		wantErr   error
+	}{
+		{"own session", ids[0], nil},
+		{"already revoked", ids[0], ErrSessionNotFound},
+		{"another user's session", otherIDs[0], ErrSessionNotFound},
+		{"unknown session", otherIDs[0] + 1000, ErrSessionNotFound},
+	}
+	for _, tt := range tests {
+		if err := s.RevokeSession(user.ID, tt.sessionID); !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	// 被注销会话的访问令牌立即失效，其他会话不受影响
+	if _, err := auth.ParseToken(pairs[0].AccessToken); !errors.Is(err, auth.ErrRevokedToken) {
+		t.Fatalf("revoked session token: err = %v, want ErrRevokedToken", err)
+	}
+	if _, err := auth.ParseToken(pairs[1].AccessToken); err != nil {
+		t.Fatalf("remaining session token: %v", err)
+	}
+	sessions, err := s.ListSessions(other.ID, 0)
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("other user's sessions = %d, want 1", len(sessions))
+	}
+}
+
+func TestRevokeOtherSessions(t *testing.T) {
+	s := NewSessionService()
+	user := createUser(t, false)
+	other := createUser(t, false)
+	pairs, ids := issueSessions(t, user, 3)
+	otherPairs, _ := issueSessions(t, other, 1)
+
+	count, err := s.RevokeOtherSessions(user.ID, ids[1])
+	if err != nil {
+		t.Fatalf("RevokeOtherSessions: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("revoked %d sessions, want 2", count)
+	}
+
+	for i, pair := range pairs {
+		_, err := auth.ParseToken(pair.AccessToken)
+		if i == 1 {
+			if err != nil {
+				t.Fatalf("current session token: %v", err)
+			}
+		} else if !errors.Is(err, auth.ErrRevokedToken) {
+			t.Fatalf("session %d token: err = %v, want ErrRevokedToken", ids[i], err)
+		}
+	}
+	if _, err := auth.ParseToken(otherPairs[0].AccessToken); err != nil {
+		t.Fatalf("other user's token: %v", err)
+	}
+
+	// 只剩当前会话时不再注销任何会话
+	count, err = s.RevokeOtherSessions(user.ID, ids[1])
+	if err != nil || count != 0 {
+		t.Fatalf("RevokeOtherSessions again = %d, %v; want 0", count, err)
+	}
+}
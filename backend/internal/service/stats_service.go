@@ -0,0 +1,284 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"tidalcore-backend/config"
+	"tidalcore-backend/internal/repository"
+)
+
+var ErrInvalidStatsRange = errors.New("invalid stats date range")
+
+const (
+	defaultStatsDays = 30
+	maxStatsDays     = 366
+)
+
+// StatsService 管理后台统计：用户增长、活跃、打卡、留存和访问
+type StatsService struct {
+	statsRepo *repository.StatsRepository
+	location  *time.Location
+}
+
+func NewStatsService() *StatsService {
+	loc := time.Local
+	cfg := config.Get()
+	if cfg != nil && cfg.Server.Timezone != "" {
+		if l, err := time.LoadLocation(cfg.Server.Timezone); err == nil {
+			loc = l
+		}
+	}
+
+	return &StatsService{
+		statsRepo: repository.NewStatsRepository(),
+		location:  loc,
+	}
+}
+
+// DailyValue 按日统计的数值，范围内没有数据的日期为 0
+type DailyValue struct {
+	Date  string `json:"date"`
+	Count int64  `json:"count"`
+}
+
+// UserGrowth 用户增长，TotalBefore 与 DailyNew 逐日累加即为用户总数曲线
+type UserGrowth struct {
+	Total       int64        `json:"total"`        // 当前用户总数（不含已删除）
+	New         int64        `json:"new"`          // 范围内新注册
+	TotalBefore int64        `json:"total_before"` // 范围开始前注册的用户数
+	Suspended   int64        `json:"suspended"`    // 当前处于封禁中
+	DailyNew    []DailyValue `json:"daily_new"`
+}
+
+// ActiveUsers 活跃用户，以打卡为准；DAU、WAU、MAU 为截至范围最后一天的 1、7、30 天
+type ActiveUsers struct {
+	DAU   int64        `json:"dau"`
+	WAU   int64        `json:"wau"`
+	MAU   int64        `json:"mau"`
+	Range int64        `json:"range"` // 范围内有打卡的人数
+	Daily []DailyValue `json:"daily"`
+}
+
+// CheckinStats 打卡统计，除 Total 外均为范围内的数据
+type CheckinStats struct {
+	Total         int64        `json:"total"` // 全部打卡次数
+	Count         int64        `json:"count"`
+	AvgPerDay     float64      `json:"avg_per_day"`
+	AvgPerUser    float64      `json:"avg_per_user"` // 每位活跃用户的平均打卡次数
+	TotalDuration int64        `json:"total_duration"`
+	AvgDuration   float64      `json:"avg_duration"` // 每次打卡的平均训练时长（秒）
+	AvgCycles     float64      `json:"avg_cycles"`
+	Daily         []DailyValue `json:"daily"`
+}
+
+// RetentionRate 注册后第 N 天的留存，Eligible 为第 N 天已经到来的注册用户数
+type RetentionRate struct {
+	Eligible int64   `json:"eligible"`
+	Retained int64   `json:"retained"`
+	Rate     float64 `json:"rate"` // 0~1
+}
+
+// RetentionStats 范围内注册用户的留存，第 N 天当天有打卡视为留存
+type RetentionStats struct {
+	Cohort int64         `json:"cohort"`
+	Day1   RetentionRate `json:"day1"`
+	Day7   RetentionRate `json:"day7"`
+	Day30  RetentionRate `json:"day30"`
+}
+
+// VisitStats 访问统计，每位访客每天计一次
+type VisitStats struct {
+	Total    int64        `json:"total"`
+	Count    int64        `json:"count"`
+	Visitors int64        `json:"visitors"` // 范围内独立访客数
+	Daily    []DailyValue `json:"daily"`
+}
+
+// AdminStats 管理后台统计，日期范围 [From, To] 包含首尾两天，按服务器时区划分
+type AdminStats struct {
+	From      string         `json:"from"`
+	To        string         `json:"to"`
+	Days      int            `json:"days"`
+	Users     UserGrowth     `json:"users"`
+	Active    ActiveUsers    `json:"active"`
+	Checkins  CheckinStats   `json:"checkins"`
+	Retention RetentionStats `json:"retention"`
+	Visits    VisitStats     `json:"visits"`
+}
+
+// GetStats 获取统计数据（管理员功能），from、to 为 YYYY-MM-DD，为空时默认最近 30 天
+func (s *StatsService) GetStats(from, to string) (*AdminStats, error) {
+	start, end, err := s.parseRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().In(s.location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.location)
+	days := int(end.Sub(start).Hours()/24 + 0.5)
+
+	stats := &AdminStats{
+		From: start.Format(dateLayout),
+		To:   end.AddDate(0, 0, -1).Format(dateLayout),
+		Days: days,
+	}
+
+	// 用户增长
+	users, err := s.statsRepo.CountUsers(start, now)
+	if err != nil {
+		return nil, err
+	}
+	dailyNew, err := s.statsRepo.DailyNewUsers(start, end)
+	if err != nil {
+		return nil, err
+	}
+	stats.Users = UserGrowth{
+		Total:       users.Total,
+		TotalBefore: users.RegisteredBefore,
+		Suspended:   users.Suspended,
+		DailyNew:    fillDaily(start, end, dailyNew),
+	}
+	for _, d := range stats.Users.DailyNew {
+		stats.Users.New += d.Count
+	}
+
+	// 活跃用户
+	for _, w := range []struct {
+		days int
+		dst  *int64
+	}{{1, &stats.Active.DAU}, {7, &stats.Active.WAU}, {30, &stats.Active.MAU}} {
+		count, err := s.statsRepo.CountActiveUsers(end.AddDate(0, 0, -w.days), end)
+		if err != nil {
+			return nil, err
+		}
+		*w.dst = count
+	}
+
+	// 打卡
+	total, err := s.statsRepo.SummarizeCheckins(time.Time{}, now)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := s.statsRepo.SummarizeCheckins(start, end)
+	if err != nil {
+		return nil, err
+	}
+	dailyCheckins, err := s.statsRepo.DailyCheckins(start, end)
+	if err != nil {
+		return nil, err
+	}
+	checkinCounts := make([]repository.DailyCount, 0, len(dailyCheckins))
+	activeCounts := make([]repository.DailyCount, 0, len(dailyCheckins))
+	for _, d := range dailyCheckins {
+		checkinCounts = append(checkinCounts, repository.DailyCount{Date: d.Date, Count: d.Checkins})
+		activeCounts = append(activeCounts, repository.DailyCount{Date: d.Date, Count: d.Users})
+	}
+	stats.Active.Range = summary.Users
+	stats.Active.Daily = fillDaily(start, end, activeCounts)
+	stats.Checkins = CheckinStats{
+		Total:         total.Count,
+		Count:         summary.Count,
+		AvgPerDay:     ratio(summary.Count, int64(days), 2),
+		AvgPerUser:    ratio(summary.Count, summary.Users, 2),
+		TotalDuration: summary.TotalDuration,
+		AvgDuration:   ratio(summary.TotalDuration, summary.Count, 1),
+		AvgCycles:     ratio(summary.TotalCycles, summary.Count, 2),
+		Daily:         fillDaily(start, end, checkinCounts),
+	}
+
+	// 留存
+	retention, err := s.statsRepo.Retention(start, end, today)
+	if err != nil {
+		return nil, err
+	}
+	stats.Retention = RetentionStats{
+		Cohort: retention.Cohort,
+		Day1:   retentionRate(retention.Eligible1, retention.Day1),
+		Day7:   retentionRate(retention.Eligible7, retention.Day7),
+		Day30:  retentionRate(retention.Eligible30, retention.Day30),
+	}
+
+	// 访问
+	visits, err := s.statsRepo.SummarizeVisits(stats.From, stats.To)
+	if err != nil {
+		return nil, err
+	}
+	dailyVisits, err := s.statsRepo.DailyVisits(stats.From, stats.To)
+	if err != nil {
+		return nil, err
+	}
+	stats.Visits = VisitStats{
+		Total:    visits.Total,
+		Count:    visits.Count,
+		Visitors: visits.Visitors,
+		Daily:    fillDaily(start, end, dailyVisits),
+	}
+
+	return stats, nil
+}
+
+// parseRange 解析日期范围，返回 [start, end) 的时间边界
+func (s *StatsService) parseRange(from, to string) (time.Time, time.Time, error) {
+	var last time.Time
+	if to == "" {
+		now := time.Now().In(s.location)
+		last = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.location)
+	} else {
+		t, err := time.ParseInLocation(dateLayout, to, s.location)
+		if err != nil {
+			return time.Time{}, time.Time{}, ErrInvalidStatsRange
+		}
+		last = t
+	}
+
+	first := last.AddDate(0, 0, -(defaultStatsDays - 1))
+	if from != "" {
+		t, err := time.ParseInLocation(dateLayout, from, s.location)
+		if err != nil {
+			return time.Time{}, time.Time{}, ErrInvalidStatsRange
+		}
+		first = t
+	}
+
+	end := last.AddDate(0, 0, 1)
+	if first.After(last) || end.After(first.AddDate(0, 0, maxStatsDays)) {
+		return time.Time{}, time.Time{}, ErrInvalidStatsRange
+	}
+	return first, end, nil
+}
+
+// fillDaily 将按日统计结果补全为范围内的每一天
+func fillDaily(start, end time.Time, counts []repository.DailyCount) []DailyValue {
+	byDate := make(map[string]int64, len(counts))
+	for _, c := range counts {
+		// 数据库返回的日期可能带有时间部分
+		if len(c.Date) >= len(dateLayout) {
+			byDate[c.Date[:len(dateLayout)]] += c.Count
+		}
+	}
+
+	var result []DailyValue
+	for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+		date := d.Format(dateLayout)
+		result = append(result, DailyValue{Date: date, Count: byDate[date]})
+	}
+	return result
+}
+
+func retentionRate(eligible, retained int64) RetentionRate {
+	return RetentionRate{
+		Eligible: eligible,
+		Retained: retained,
+		Rate:     ratio(retained, eligible, 4),
+	}
+}
+
+// ratio 计算 n/d 并保留指定位数的小数，d 为 0 时返回 0
+func ratio(n, d int64, places int) float64 {
+	if d == 0 {
+		return 0
+	}
+	p := math.Pow(10, float64(places))
+	return math.Round(float64(n)/float64(d)*p) / p
+}
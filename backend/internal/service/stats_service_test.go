@@ -0,0 +1,129 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/pkg/database"
+)
+
+func TestGetStats(t *testing.T) {
+	s := NewStatsService()
+	// 与服务进程时区不同，日期必须按配置的时区划分
+	loc := time.FixedZone("UTC+8", 8*60*60)
+	s.location = loc
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2020, time.March, day, hour, min, 0, 0, loc)
+	}
+	db := database.Get()
+
+	// 统计覆盖全站数据，测试结束后清除写入的记录，重复运行时结果不变
+	var userIDs []uint
+	var visitorIDs []string
+	t.Cleanup(func() {
+		db.Where("user_id IN ?", userIDs).Delete(&model.Checkin{})
+		db.Unscoped().Where("id IN ?", userIDs).Delete(&model.User{})
+		db.Where("visitor_id IN ?", visitorIDs).Delete(&model.Visit{})
+	})
+
+	seedUser := func(createdAt time.Time, checkins ...time.Time) *model.User {
+		user := &model.User{Username: uniqueName("stats"), DisplayName: "统计", CreatedAt: createdAt}
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+		userIDs = append(userIDs, user.ID)
+		for _, c := range checkins {
+			if err := db.Create(&model.Checkin{UserID: user.ID, Duration: 600, Cycles: 2, CheckedAt: c}).Error; err != nil {
+				t.Fatalf("create checkin: %v", err)
+			}
+		}
+		return user
+	}
+	// 注册后第 1、7、30 天都有打卡
+	seedUser(at(1, 10, 0), at(2, 9, 0), at(8, 21, 0), at(10, 20, 0), at(31, 8, 0))
+	// 23:30 注册、第三天 00:30 打卡：按 UTC 划分会被算作第 1 天
+	seedUser(at(1, 23, 30), at(3, 0, 30))
+	// 已删除用户不计入任何统计
+	deleted := seedUser(at(5, 12, 0), at(10, 12, 0))
+	if err := db.Delete(deleted).Error; err != nil {
+		t.Fatalf("delete user: %v", err)
+	}
+	for _, v := range []model.Visit{
+		{VisitorID: uniqueName("visitor"), VisitedDate: "2020-03-05"},
+		{VisitorID: uniqueName("visitor"), VisitedDate: "2020-03-05"},
+		{VisitorID: uniqueName("visitor"), VisitedDate: "2020-03-09"},
+	} {
+		if err := db.Create(&v).Error; err != nil {
+			t.Fatalf("create visit: %v", err)
+		}
+		visitorIDs = append(visitorIDs, v.VisitorID)
+	}
+
+	stats, err := s.GetStats("2020-03-01", "2020-03-10")
+	if err != nil {
+		t.Fatalf("GetStats: %v", err)
+	}
+	if stats.Days != 10 || len(stats.Users.DailyNew) != 10 || len(stats.Checkins.Daily) != 10 || len(stats.Visits.Daily) != 10 {
+		t.Fatalf("days = %d, daily lengths %d/%d/%d, want 10",
+			stats.Days, len(stats.Users.DailyNew), len(stats.Checkins.Daily), len(stats.Visits.Daily))
+	}
+
+	daily := func(values []DailyValue) map[string]int64 {
+		m := make(map[string]int64)
+		for _, v := range values {
+			if v.Count != 0 {
+				m[v.Date] = v.Count
+			}
+		}
+		return m
+	}
+	tests := []struct {
+		name string
+		got  map[string]int64
+		want map[string]int64
+	}{
+		{"daily new users", daily(stats.Users.DailyNew), map[string]int64{"2020-03-01": 2}},
+		{"daily checkins", daily(stats.Checkins.Daily), map[string]int64{"2020-03-02": 1, "2020-03-03": 1, "2020-03-08": 1, "2020-03-10": 1}},
+		{"daily active users", daily(stats.Active.Daily), map[string]int64{"2020-03-02": 1, "2020-03-03": 1, "2020-03-08": 1, "2020-03-10": 1}},
+		{"daily visits", daily(stats.Visits.Daily), map[string]int64{"2020-03-05": 2, "2020-03-09": 1}},
+	}
+	for _, tt := range tests {
+		if len(tt.got) != len(tt.want) {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+			continue
+		}
+		for date, count := range tt.want {
+			if tt.got[date] != count {
+				t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+				break
+			}
+		}
+	}
+
+	if stats.Users.New != 2 {
+		t.Errorf("new users = %d, want 2", stats.Users.New)
+	}
+	if a := stats.Active; a.DAU != 1 || a.WAU != 1 || a.MAU != 2 || a.Range != 2 {
+		t.Errorf("active = dau %d, wau %d, mau %d, range %d; want 1, 1, 2, 2", a.DAU, a.WAU, a.MAU, a.Range)
+	}
+	if c := stats.Checkins; c.Count != 4 || c.TotalDuration != 2400 || c.AvgPerUser != 2 {
+		t.Errorf("checkins = count %d, duration %d, per user %v; want 4, 2400, 2", c.Count, c.TotalDuration, c.AvgPerUser)
+	}
+	if v := stats.Visits; v.Count != 3 || v.Visitors != 3 {
+		t.Errorf("visits = count %d, visitors %d; want 3, 3", v.Count, v.Visitors)
+	}
+
+	r := stats.Retention
+	if r.Cohort != 2 {
+		t.Fatalf("retention cohort = %d, want 2", r.Cohort)
+	}
+	for _, rate := range []struct {
+		name string
+		got  RetentionRate
+	}{{"day1", r.Day1}, {"day7", r.Day7}, {"day30", r.Day30}} {
+		if rate.got.Eligible != 2 || rate.got.Retained != 1 || rate.got.Rate != 0.5 {
+			t.Errorf("retention %s = %+v, want 1 of 2", rate.name, rate.got)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"tidalcore-backend/internal/auth"
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/internal/repository"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenExpired = errors.New("refresh token has expired")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+)
+
+const refreshTokenBytes = 32
+
+// TokenService 签发访问令牌并管理可轮换的刷新令牌
+// 每次登录创建一个会话，会话内的刷新令牌共享同一个 FamilyID
+type TokenService struct {
+	refreshRepo *repository.RefreshTokenRepository
+	sessionRepo *repository.SessionRepository
+	userRepo    *repository.UserRepository
+}
+
+func NewTokenService() *TokenService {
+	return &TokenService{
+		refreshRepo: repository.NewRefreshTokenRepository(),
+		sessionRepo: repository.NewSessionRepository(),
+		userRepo:    repository.NewUserRepository(),
+	}
+}
+
+// ClientInfo 发起请求的客户端信息
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// TokenPair 访问令牌与刷新令牌
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // 访问令牌有效期（秒）
+}
+
+// IssueTokens 创建新的登录会话并签发令牌
+func (s *TokenService) IssueTokens(user *model.User, client ClientInfo) (*TokenPair, error) {
+	familyID, err := auth.GenerateRandomID(16)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &model.Session{
+		UserID:     user.ID,
+		FamilyID:   familyID,
+		UserAgent:  truncate(client.UserAgent, 512),
+		IP:         truncate(client.IP, 64),
+		LastSeenAt: time.Now(),
+	}
+	if err := s.sessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+
+	return s.issue(user, session)
+}
+
+func (s *TokenService) issue(user *model.User, session *model.Session) (*TokenPair, error) {
+	accessToken, err := auth.GenerateSessionToken(user.ID, user.Username, user.IsAdmin, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := auth.GenerateRandomToken(refreshTokenBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &model.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: auth.HashToken(refreshToken),
+		FamilyID:  session.FamilyID,
+		ExpiresAt: time.Now().Add(auth.RefreshTokenTTL()),
+	}
+	if err := s.refreshRepo.Create(record); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(auth.AccessTokenTTL().Seconds()),
+	}, nil
+}
+
+// Refresh 使用刷新令牌换取新的令牌对
+// 每个刷新令牌只能使用一次，重复使用视为令牌泄露，将吊销整个会话的所有令牌
+func (s *TokenService) Refresh(req *RefreshRequest, client ClientInfo) (*TokenPair, *model.User, error) {
+	record, err := s.refreshRepo.GetByHash(auth.HashToken(req.RefreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidRefreshToken
+		}
+		return nil, nil, err
+	}
+
+	if record.UsedAt != nil || record.RevokedAt != nil {
+		return nil, nil, s.revokeReused(record)
+	}
+	if time.Now().After(record.ExpiresAt) {
+		return nil, nil, ErrRefreshTokenExpired
+	}
+
+	user, err := s.userRepo.GetByID(record.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidRefreshToken
+		}
+		return nil, nil, err
+	}
+	// 封禁期间拒绝续期但保留刷新令牌，解除封禁后会话仍可继续使用
+	if err := checkSuspended(user); err != nil {
+		return nil, nil, err
+	}
+
+	ok, err := s.refreshRepo.MarkUsed(record.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		// 并发请求已抢先使用该令牌
+		return nil, nil, s.revokeReused(record)
+	}
+
+	session, err := s.sessionRepo.GetByFamilyID(record.FamilyID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidRefreshToken
+		}
+		return nil, nil, err
+	}
+	if session.RevokedAt != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	if err := s.sessionRepo.Touch(session.ID, truncate(client.IP, 64), truncate(client.UserAgent, 512)); err != nil {
+		return nil, nil, err
+	}
+
+	pair, err := s.issue(user, session)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pair, user, nil
+}
+
+// SessionIDByRefreshToken 查找刷新令牌所属的会话，令牌不存在或不属于该用户时返回 0
+func (s *TokenService) SessionIDByRefreshToken(userID uint, refreshToken string) (uint, error) {
+	record, err := s.refreshRepo.GetByHash(auth.HashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if record.UserID != userID {
+		return 0, nil
+	}
+
+	session, err := s.sessionRepo.GetByFamilyID(record.FamilyID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return session.ID, nil
+}
+
+func (s *TokenService) revokeReused(record *model.RefreshToken) error {
+	log.Printf("Warning: Refresh token reuse detected for user %d, revoking family %s", record.UserID, record.FamilyID)
+	session, err := s.sessionRepo.GetByFamilyID(record.FamilyID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err := s.refreshRepo.RevokeFamily(record.FamilyID); err != nil {
+			return err
+		}
+		return ErrRefreshTokenReused
+	}
+	if err := GetRevocationService().RevokeSessions([]uint{session.ID}); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// StartCleanup 启动后台任务，定期清理过期的刷新令牌和不再活跃的会话
+func (s *TokenService) StartCleanup(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			now := time.Now()
+			if _, err := s.refreshRepo.DeleteExpired(now); err != nil {
+				log.Printf("Warning: Failed to clean up expired refresh tokens: %v", err)
+			}
+			if _, err := s.sessionRepo.DeleteInactive(now.Add(-auth.RefreshTokenTTL())); err != nil {
+				log.Printf("Warning: Failed to clean up inactive sessions: %v", err)
+			}
+		}
+	}()
+}
+
+// truncate 按字节截断字符串，避免超出字段长度
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return strings.ToValidUTF8(s[:max], "")
+}
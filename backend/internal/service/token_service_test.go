@@ -0,0 +1,96 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"tidalcore-backend/internal/auth"
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/pkg/database"
+)
+
+func TestRefreshRotatesToken(t *testing.T) {
+	s := NewTokenService()
+	user := createUser(t, false)
+
+	first, err := s.IssueTokens(user, ClientInfo{IP: "10.0.0.1"})
+	if err != nil {
+		t.Fatalf("IssueTokens: %v", err)
+	}
+	second, refreshed, err := s.Refresh(&RefreshRequest{RefreshToken: first.RefreshToken}, ClientInfo{IP: "10.0.0.2"})
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if refreshed.ID != user.ID {
+		t.Fatalf("refreshed user %d, want %d", refreshed.ID, user.ID)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("refresh token was not rotated")
+	}
+
+	// 新令牌属于同一会话，会话记录最近一次续期的 IP
+	firstSession, err := s.SessionIDByRefreshToken(user.ID, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("SessionIDByRefreshToken: %v", err)
+	}
+	secondSession, err := s.SessionIDByRefreshToken(user.ID, second.RefreshToken)
+	if err != nil {
+		t.Fatalf("SessionIDByRefreshToken: %v", err)
+	}
+	if firstSession == 0 || firstSession != secondSession {
+		t.Fatalf("sessions = %d and %d, want the same session", firstSession, secondSession)
+	}
+	var session model.Session
+	if err := database.Get().First(&session, secondSession).Error; err != nil {
+		t.Fatalf("load session: %v", err)
+	}
+	if session.IP != "10.0.0.2" {
+		t.Fatalf("session ip = %q, want 10.0.0.2", session.IP)
+	}
+
+	if _, err := auth.ParseToken(second.AccessToken); err != nil {
+		t.Fatalf("ParseToken: %v", err)
+	}
+	if _, _, err := s.Refresh(&RefreshRequest{RefreshToken: "unknown"}, ClientInfo{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("unknown token: err = %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestRefreshReuseRevokesSession(t *testing.T) {
+	s := NewTokenService()
+	user := createUser(t, false)
+
+	first, err := s.IssueTokens(user, ClientInfo{})
+	if err != nil {
+		t.Fatalf("IssueTokens: %v", err)
+	}
+	second, _, err := s.Refresh(&RefreshRequest{RefreshToken: first.RefreshToken}, ClientInfo{})
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	// 另一个会话不受影响
+	other, err := s.IssueTokens(user, ClientInfo{})
+	if err != nil {
+		t.Fatalf("IssueTokens: %v", err)
+	}
+
+	// 已使用过的刷新令牌再次出现，视为泄露
+	if _, _, err := s.Refresh(&RefreshRequest{RefreshToken: first.RefreshToken}, ClientInfo{}); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("reused token: err = %v, want ErrRefreshTokenReused", err)
+	}
+
+	// 整个会话随即失效：轮换出的新刷新令牌和访问令牌都不能再用
+	if _, _, err := s.Refresh(&RefreshRequest{RefreshToken: second.RefreshToken}, ClientInfo{}); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("rotated token after reuse: err = %v, want ErrRefreshTokenReused", err)
+	}
+	if _, err := auth.ParseToken(second.AccessToken); !errors.Is(err, auth.ErrRevokedToken) {
+		t.Fatalf("access token after reuse: err = %v, want ErrRevokedToken", err)
+	}
+
+	if _, _, err := s.Refresh(&RefreshRequest{RefreshToken: other.RefreshToken}, ClientInfo{}); err != nil {
+		t.Fatalf("other session: %v", err)
+	}
+	if _, err := auth.ParseToken(other.AccessToken); err != nil {
+		t.Fatalf("other session access token: %v", err)
+	}
+}
@@ -0,0 +1,362 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"tidalcore-backend/config"
+	"tidalcore-backend/internal/auth"
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/internal/repository"
+)
+
+var (
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotSetup       = errors.New("two-factor authentication has not been set up")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorRequired       = errors.New("two-factor authentication is required for admins")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrInvalidTwoFactorToken   = errors.New("invalid or expired two-factor token")
+)
+
+const (
+	totpIssuer = "TidalCore"
+
+	recoveryCodeCount = 10
+	// 早期两步验证恢复码的长度（十六进制）
+	legacyRecoveryCodeLength = 10
+
+	// 两步登录的第二步需在有效期内完成，且验证码错误次数有限
+	pendingLoginTTL         = 5 * time.Minute
+	pendingLoginMaxAttempts = 5
+)
+
+type pendingLogin struct {
+	userID    uint
+	expiresAt time.Time
+	attempts  int
+}
+
+// TwoFactorService 基于 TOTP（RFC 6238）的两步验证
+type TwoFactorService struct {
+	userRepo     *repository.UserRepository
+	recoveryRepo *repository.RecoveryCodeRepository
+
+	mu      sync.Mutex
+	pending map[string]*pendingLogin // 已通过密码校验、等待验证码的登录，键为令牌摘要
+}
+
+var (
+	twoFactorService     *TwoFactorService
+	twoFactorServiceOnce sync.Once
+)
+
+// GetTwoFactorService 获取全局两步验证服务，待完成的登录需在所有请求间共享
+func GetTwoFactorService() *TwoFactorService {
+	twoFactorServiceOnce.Do(func() {
+		twoFactorService = &TwoFactorService{
+			userRepo:     repository.NewUserRepository(),
+			recoveryRepo: repository.NewRecoveryCodeRepository(),
+			pending:      make(map[string]*pendingLogin),
+		}
+	})
+	return twoFactorService
+}
+
+// TwoFactorRequired 配置是否要求该用户启用两步验证
+func TwoFactorRequired(user *model.User) bool {
+	return user.IsAdmin && config.Get().Admin.Require2FA
+}
+
+// TwoFactorStatus 两步验证状态
+type TwoFactorStatus struct {
+	Enabled                bool  `json:"enabled"`
+	Required               bool  `json:"required"`
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorSetup 启用两步验证所需的密钥信息
+type TwoFactorSetup struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type TwoFactorSetupRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type TwoFactorDisableRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type TwoFactorLoginRequest struct {
+	TwoFactorToken string `json:"two_factor_token" binding:"required"`
+	Code           string `json:"code" binding:"required"` // 身份验证器中的验证码或恢复码
+}
+
+func (s *TwoFactorService) GetStatus(userID uint) (*TwoFactorStatus, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining, err := s.recoveryRepo.CountUnused(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorStatus{
+		Enabled:                user.TOTPEnabled,
+		Required:               TwoFactorRequired(user),
+		RecoveryCodesRemaining: remaining,
+	}, nil
+}
+
+// Setup 生成新的密钥，需再调用 Enable 提交验证码后才会生效
+func (s *TwoFactorService) Setup(userID uint, req *TwoFactorSetupRequest) (*TwoFactorSetup, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if !auth.CheckPassword(req.Password, user.PasswordHash) {
+		return nil, ErrOldPasswordWrong
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.UpdateTOTP(userID, secret, false); err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorSetup{
+		Secret:     secret,
+		OTPAuthURI: auth.TOTPURI(totpIssuer, user.Username, secret),
+	}, nil
+}
+
+// Enable 校验身份验证器生成的验证码并启用两步验证，返回一组新的恢复码
+func (s *TwoFactorService) Enable(userID uint, req *TwoFactorCodeRequest) ([]string, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTwoFactorNotSetup
+	}
+
+	step, ok := auth.ValidateTOTP(user.TOTPSecret, req.Code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	if err := s.userRepo.UpdateTOTP(userID, user.TOTPSecret, true); err != nil {
+		return nil, err
+	}
+	if _, err := s.userRepo.AdvanceTOTPStep(userID, step); err != nil {
+		return nil, err
+	}
+	GetUserStateCache().Invalidate(userID)
+
+	return s.replaceRecoveryCodes(userID)
+}
+
+// Disable 关闭两步验证，需同时提供密码和验证码（或恢复码）
+func (s *TwoFactorService) Disable(userID uint, req *TwoFactorDisableRequest) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+	if TwoFactorRequired(user) {
+		return ErrTwoFactorRequired
+	}
+	if !auth.CheckPassword(req.Password, user.PasswordHash) {
+		return ErrOldPasswordWrong
+	}
+
+	ok, err := s.verifyCode(user, req.Code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+
+	if err := s.userRepo.UpdateTOTP(userID, "", false); err != nil {
+		return err
+	}
+	if err := s.recoveryRepo.DeleteByUserID(userID); err != nil {
+		return err
+	}
+	GetUserStateCache().Invalidate(userID)
+	return nil
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧的恢复码全部失效
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID uint, req *TwoFactorCodeRequest) ([]string, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+
+	// 只接受身份验证器的验证码，避免用最后一个恢复码无限续期
+	step, ok := auth.ValidateTOTP(user.TOTPSecret, req.Code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+	fresh, err := s.userRepo.AdvanceTOTPStep(userID, step)
+	if err != nil {
+		return nil, err
+	}
+	if !fresh {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	return s.replaceRecoveryCodes(userID)
+}
+
+// BeginLogin 密码校验通过后创建待完成的登录，返回第二步使用的临时令牌
+func (s *TwoFactorService) BeginLogin(userID uint) (string, error) {
+	token, err := auth.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	for key, p := range s.pending {
+		if now.After(p.expiresAt) {
+			delete(s.pending, key)
+		}
+	}
+	s.pending[auth.HashToken(token)] = &pendingLogin{
+		userID:    userID,
+		expiresAt: now.Add(pendingLoginTTL),
+	}
+	s.mu.Unlock()
+
+	return token, nil
+}
+
+// CompleteLogin 校验第二步的验证码或恢复码，成功后返回登录用户
+// 每个临时令牌最多校验 pendingLoginMaxAttempts 次，次数在校验前预留，并发请求无法绕过上限；
+// 验证码错误同样计入登录失败次数，第二步通过后才清除失败记录
+func (s *TwoFactorService) CompleteLogin(req *TwoFactorLoginRequest, ip string) (*model.User, error) {
+	key := auth.HashToken(req.TwoFactorToken)
+
+	s.mu.Lock()
+	p, ok := s.pending[key]
+	if ok && time.Now().After(p.expiresAt) {
+		delete(s.pending, key)
+		ok = false
+	}
+	if ok {
+		p.attempts++
+		if p.attempts >= pendingLoginMaxAttempts {
+			delete(s.pending, key)
+		}
+	}
+	s.mu.Unlock()
+	if !ok {
+		return nil, ErrInvalidTwoFactorToken
+	}
+
+	user, err := s.userRepo.GetByID(p.userID)
+	if err != nil {
+		return nil, err
+	}
+
+	guard := GetLoginGuard()
+	if err := guard.checkLocked(user.Username); err != nil {
+		return nil, err
+	}
+
+	valid, err := s.verifyCode(user, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		if err := guard.RecordFailure(user.Username, ip); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	s.mu.Lock()
+	delete(s.pending, key)
+	s.mu.Unlock()
+
+	if err := guard.RecordSuccess(user.Username); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// verifyCode 校验验证码或恢复码，同一验证码和恢复码都只能使用一次
+func (s *TwoFactorService) verifyCode(user *model.User, code string) (bool, error) {
+	if !user.TOTPEnabled {
+		return false, nil
+	}
+
+	if step, ok := auth.ValidateTOTP(user.TOTPSecret, code, time.Now()); ok {
+		return s.userRepo.AdvanceTOTPStep(user.ID, step)
+	}
+
+	normalized := normalizeRecoveryCode(code)
+	if normalized == "" {
+		return false, nil
+	}
+	return s.recoveryRepo.Consume(user.ID, auth.HashToken(normalized))
+}
+
+// replaceRecoveryCodes 与找回密码的恢复码相同长度，即使哈希泄露也无法离线穷举
+func (s *TwoFactorService) replaceRecoveryCodes(userID uint) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw, err := generateCode(recoveryCodeLength)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, formatResetCode(raw))
+		hashes = append(hashes, auth.HashToken(raw))
+	}
+
+	if err := s.recoveryRepo.Replace(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// normalizeRecoveryCode 忽略大小写、空格和分隔符
+// 早期签发的 10 位十六进制恢复码在重新生成之前仍可使用
+func normalizeRecoveryCode(code string) string {
+	code = normalizeResetCode(code)
+	switch len(code) {
+	case recoveryCodeLength:
+		return code
+	case legacyRecoveryCodeLength:
+		return strings.ToLower(code)
+	}
+	return ""
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"tidalcore-backend/internal/auth"
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/internal/repository"
+	"tidalcore-backend/pkg/database"
+)
+
+const testPassword = "Tidal-core-2fa!"
+
+// createTwoFactorUser 创建已启用两步验证的用户
+func createTwoFactorUser(t *testing.T) *model.User {
+	t.Helper()
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret: %v", err)
+	}
+	hash, err := auth.HashPassword(testPassword)
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	user := &model.User{
+		Username:     uniqueName("totp"),
+		DisplayName:  "两步验证",
+		PasswordHash: hash,
+		TOTPSecret:   secret,
+		TOTPEnabled:  true,
+	}
+	if err := database.Get().Create(user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user
+}
+
+func failedCount(t *testing.T, username string) int {
+	t.Helper()
+	failure, err := repository.NewLoginFailureRepository().GetByUsername(username)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0
+	}
+	if err != nil {
+		t.Fatalf("GetByUsername: %v", err)
+	}
+	return failure.FailedCount
+}
+
+func TestCompleteLoginAttemptLimitConcurrent(t *testing.T) {
+	user := createTwoFactorUser(t)
+	s := GetTwoFactorService()
+
+	token, err := s.BeginLogin(user.ID)
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+
+	const n = 12
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.CompleteLogin(&TwoFactorLoginRequest{TwoFactorToken: token, Code: "wrong"}, "127.0.0.1")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	tokenErrs := 0
+	for err := range errs {
+		switch {
+		case errors.Is(err, ErrInvalidTwoFactorToken):
+			tokenErrs++
+		case errors.Is(err, ErrInvalidTwoFactorCode):
+		default:
+			var locked *AccountLockedError
+			if !errors.As(err, &locked) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+	if want := n - pendingLoginMaxAttempts; tokenErrs != want {
+		t.Fatalf("%d requests rejected before verification, want %d", tokenErrs, want)
+	}
+
+	// 次数用尽后，正确的验证码也不能再使用该令牌
+	code, err := auth.TOTPCode(user.TOTPSecret, auth.TOTPStep(time.Now()))
+	if err != nil {
+		t.Fatalf("TOTPCode: %v", err)
+	}
+	_, err = s.CompleteLogin(&TwoFactorLoginRequest{TwoFactorToken: token, Code: code}, "127.0.0.1")
+	if !errors.Is(err, ErrInvalidTwoFactorToken) {
+		t.Fatalf("CompleteLogin after limit = %v, want ErrInvalidTwoFactorToken", err)
+	}
+}
+
+func TestLoginClearsFailuresOnlyAfterSecondFactor(t *testing.T) {
+	user := createTwoFactorUser(t)
+	s := NewUserService()
+	client := ClientInfo{IP: "127.0.0.1"}
+
+	for i := 0; i < 2; i++ {
+		_, err := s.Login(&LoginRequest{Username: user.Username, Password: "wrong-password"}, client)
+		if !errors.Is(err, ErrInvalidPassword) {
+			t.Fatalf("Login with wrong password = %v", err)
+		}
+	}
+
+	resp, err := s.Login(&LoginRequest{Username: user.Username, Password: testPassword}, client)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if !resp.TwoFactorRequired {
+		t.Fatalf("Login did not require the second factor")
+	}
+	if got := failedCount(t, user.Username); got != 2 {
+		t.Fatalf("failed count after correct password = %d, want 2", got)
+	}
+
+	_, err = s.LoginTwoFactor(&TwoFactorLoginRequest{TwoFactorToken: resp.TwoFactorToken, Code: "wrong"}, client)
+	if !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("LoginTwoFactor with wrong code = %v", err)
+	}
+	if got := failedCount(t, user.Username); got != 3 {
+		t.Fatalf("failed count after wrong code = %d, want 3", got)
+	}
+
+	code, err := auth.TOTPCode(user.TOTPSecret, auth.TOTPStep(time.Now()))
+	if err != nil {
+		t.Fatalf("TOTPCode: %v", err)
+	}
+	auth2, err := s.LoginTwoFactor(&TwoFactorLoginRequest{TwoFactorToken: resp.TwoFactorToken, Code: code}, client)
+	if err != nil {
+		t.Fatalf("LoginTwoFactor: %v", err)
+	}
+	if auth2.AccessToken == "" {
+		t.Fatalf("LoginTwoFactor returned no access token")
+	}
+	if got := failedCount(t, user.Username); got != 0 {
+		t.Fatalf("failed count after second factor = %d, want 0", got)
+	}
+}
+
+func TestTwoFactorRecoveryCodes(t *testing.T) {
+	user := createTwoFactorUser(t)
+	s := GetTwoFactorService()
+
+	codes, err := s.replaceRecoveryCodes(user.ID)
+	if err != nil {
+		t.Fatalf("replaceRecoveryCodes: %v", err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("recovery codes = %d, want %d", len(codes), recoveryCodeCount)
+	}
+	if n := len(normalizeResetCode(codes[0])); n != recoveryCodeLength {
+		t.Fatalf("recovery code %q has %d characters, want %d", codes[0], n, recoveryCodeLength)
+	}
+
+	// 忽略大小写和分隔符，每个恢复码只能使用一次
+	input := strings.ToLower(strings.ReplaceAll(codes[0], "-", " "))
+	if ok, err := s.verifyCode(user, input); err != nil || !ok {
+		t.Fatalf("verifyCode = %v, %v; want true", ok, err)
+	}
+	if ok, err := s.verifyCode(user, codes[0]); err != nil || ok {
+		t.Fatalf("reused recovery code: verifyCode = %v, %v; want false", ok, err)
+	}
+
+	// 早期签发的 10 位恢复码仍可使用
+	legacy := "0a1b2c3d4e"
+	if err := s.recoveryRepo.Replace(user.ID, []string{auth.HashToken(legacy)}); err != nil {
+		t.Fatalf("Replace: %v", err)
+	}
+	if ok, err := s.verifyCode(user, "0A1B2-C3D4E"); err != nil || !ok {
+		t.Fatalf("legacy recovery code: verifyCode = %v, %v; want true", ok, err)
+	}
+}
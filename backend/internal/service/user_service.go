@@ -2,13 +2,17 @@ package service
 
 import (
 	"errors"
+	"log"
 	"regexp"
 	"strings"
+	"time"
 
 	"gorm.io/gorm"
 
+	"tidalcore-backend/config"
 	"tidalcore-backend/internal/auth"
 	"tidalcore-backend/internal/model"
+	"tidalcore-backend/internal/moderation"
 	"tidalcore-backend/internal/repository"
 )
 
@@ -18,47 +22,124 @@ var (
 	ErrInvalidPassword  = errors.New("invalid password")
 	ErrInvalidUsername  = errors.New("invalid username format")
 	ErrOldPasswordWrong = errors.New("old password is incorrect")
+	ErrPasswordNotSet   = errors.New("account has no local password")
+	ErrPasswordSet      = errors.New("account already has a local password")
+	ErrCannotSuspend    = errors.New("admin accounts cannot be suspended")
+	ErrUserNotDeleted   = errors.New("user must be deleted before purging")
 )
 
 var usernameRegex = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
 
+// PasswordPolicyError 密码不符合密码策略，Reasons 列出所有不符合的原因
+type PasswordPolicyError struct {
+	Reasons []auth.PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	codes := make([]string, 0, len(e.Reasons))
+	for _, r := range e.Reasons {
+		codes = append(codes, r.Code)
+	}
+	return "password does not meet policy: " + strings.Join(codes, ",")
+}
+
+// SuspendedError 账号已被管理员封禁，Until 为空表示永久封禁
+type SuspendedError struct {
+	Until  *time.Time
+	Reason string
+}
+
+func (e *SuspendedError) Error() string {
+	return "account is suspended"
+}
+
+// userWriteError 将用户名唯一索引冲突转换为 ErrUserExists
+// 并发注册或改名时，检查通过后仍可能在写入时冲突
+func userWriteError(err error) error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrUserExists
+	}
+	return err
+}
+
+// checkSuspended 账号处于封禁中时返回 *SuspendedError
+func checkSuspended(user *model.User) error {
+	if user.IsSuspended(time.Now()) {
+		return &SuspendedError{Until: user.SuspendedUntil, Reason: user.SuspendReason}
+	}
+	return nil
+}
+
+// checkPasswordPolicy 校验密码策略，不符合时返回 *PasswordPolicyError
+func checkPasswordPolicy(password, username string) error {
+	if reasons := auth.CheckPasswordPolicy(password, username); len(reasons) > 0 {
+		return &PasswordPolicyError{Reasons: reasons}
+	}
+	return nil
+}
+
 type UserService struct {
-	userRepo *repository.UserRepository
+	userRepo          *repository.UserRepository
+	cheerRepo         *repository.CheerRepository
+	tokenService      *TokenService
+	nameReviewService *NameReviewService
+	passwordService   *PasswordResetService
+	auditService      *AuditService
 }
 
 func NewUserService() *UserService {
 	return &UserService{
-		userRepo: repository.NewUserRepository(),
+		userRepo:          repository.NewUserRepository(),
+		cheerRepo:         repository.NewCheerRepository(),
+		tokenService:      NewTokenService(),
+		nameReviewService: NewNameReviewService(),
+		passwordService:   NewPasswordResetService(),
+		auditService:      NewAuditService(),
 	}
 }
 
 type RegisterRequest struct {
 	Username    string `json:"username" binding:"required,min=3,max=50"`
 	DisplayName string `json:"display_name" binding:"required,min=1,max=50"`
-	Password    string `json:"password" binding:"required,min=6,max=50"`
+	Password    string `json:"password" binding:"required"` // 长度等要求由密码策略校验
 }
 
 type LoginRequest struct {
-	Username string `json:"username" binding:"required"`
+	Username string `json:"username" binding:"required,max=50"`
 	Password string `json:"password" binding:"required"`
 }
 
+// AuthResponse 登录结果
+// 启用两步验证的账号在密码校验通过后只返回 TwoFactorToken，需提交验证码完成登录
 type AuthResponse struct {
-	Token string      `json:"token"`
-	User  *model.User `json:"user"`
+	*TokenPair
+	User                   *model.User `json:"user,omitempty"`
+	TwoFactorRequired      bool        `json:"two_factor_required,omitempty"`
+	TwoFactorToken         string      `json:"two_factor_token,omitempty"`
+	TwoFactorSetupRequired bool        `json:"two_factor_setup_required,omitempty"` // 管理员需启用两步验证后才能使用管理功能
+	RecoveryCodes          []string    `json:"recovery_codes,omitempty"`            // 注册时生成的密码恢复码，仅返回这一次
+	DisplayNamePending     bool        `json:"display_name_pending,omitempty"`      // 注册时填写的显示名称需审核，通过前显示用户名
 }
 
-func (s *UserService) Register(req *RegisterRequest) (*AuthResponse, error) {
+func (s *UserService) Register(req *RegisterRequest, client ClientInfo) (*AuthResponse, error) {
 	username := strings.TrimSpace(req.Username)
 	if !usernameRegex.MatchString(username) {
 		return nil, ErrInvalidUsername
 	}
 
+	if err := checkUsername(username); err != nil {
+		return nil, err
+	}
+
 	displayName := strings.TrimSpace(req.DisplayName)
 	if displayName == "" {
 		displayName = username // 如果未提供显示名称，默认使用用户名
 	}
 
+	if err := checkPasswordPolicy(req.Password, username); err != nil {
+		return nil, err
+	}
+
 	exists, err := s.userRepo.ExistsByUsername(username)
 	if err != nil {
 		return nil, err
@@ -72,55 +153,232 @@ func (s *UserService) Register(req *RegisterRequest) (*AuthResponse, error) {
 		return nil, err
 	}
 
+	// 显示名称需审核时暂用用户名，审核通过后再替换
+	// 显示名称与用户名相同时只审核用户名，避免同一名称重复提交
+	var matched []string
+	if displayName != username {
+		matched = moderation.CheckDisplayName(displayName)
+	}
 	user := &model.User{
 		Username:     username,
 		DisplayName:  displayName,
 		PasswordHash: hashedPassword,
 	}
+	if len(matched) > 0 {
+		user.DisplayName = username
+	}
 
 	if err := s.userRepo.Create(user); err != nil {
-		return nil, err
+		return nil, userWriteError(err)
 	}
 
-	token, err := auth.GenerateTokenWithAdmin(user.ID, user.Username, user.IsAdmin)
+	if len(matched) > 0 {
+		if err := s.nameReviewService.Submit(user.ID, model.NameFieldDisplayName, displayName, matched); err != nil {
+			log.Printf("Warning: Failed to submit display name review for user %d: %v", user.ID, err)
+		}
+	}
+	if matched := moderation.CheckUsername(username); len(matched) > 0 {
+		if err := s.nameReviewService.Submit(user.ID, model.NameFieldUsername, username, matched); err != nil {
+			log.Printf("Warning: Failed to submit username review for user %d: %v", user.ID, err)
+		}
+	}
+
+	resp, err := s.issueAuth(user, client)
 	if err != nil {
 		return nil, err
 	}
 
-	return &AuthResponse{
-		Token: token,
-		User:  user,
-	}, nil
+	// 账号已创建，恢复码生成失败时不影响注册，用户可稍后在个人资料中重新生成
+	codes, err := NewPasswordResetService().GenerateRecoveryCodes(user.ID)
+	if err != nil {
+		log.Printf("Warning: Failed to generate recovery codes for user %d: %v", user.ID, err)
+	}
+	resp.RecoveryCodes = codes
+	resp.DisplayNamePending = len(matched) > 0
+	return resp, nil
 }
 
-func (s *UserService) Login(req *LoginRequest) (*AuthResponse, error) {
+func (s *UserService) Login(req *LoginRequest, client ClientInfo) (*AuthResponse, error) {
 	username := strings.TrimSpace(req.Username)
 
+	// 不存在的用户名同样计入失败次数，避免通过锁定行为探测账号是否存在
+	guard := GetLoginGuard()
+	if err := guard.Check(username); err != nil {
+		return nil, err
+	}
+
 	user, err := s.userRepo.GetByUsername(username)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := guard.RecordFailure(username, client.IP); err != nil {
+				return nil, err
+			}
 			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
 
 	if !auth.CheckPassword(req.Password, user.PasswordHash) {
+		if err := guard.RecordFailure(username, client.IP); err != nil {
+			return nil, err
+		}
 		return nil, ErrInvalidPassword
 	}
 
-	token, err := auth.GenerateTokenWithAdmin(user.ID, user.Username, user.IsAdmin)
+	// 启用两步验证时，第二步通过后才清除失败记录
+	if !user.TOTPEnabled {
+		if err := guard.RecordSuccess(username); err != nil {
+			return nil, err
+		}
+	}
+
+	s.upgradePasswordHash(user, req.Password)
+
+	// 密码正确后才提示封禁，避免泄露账号状态
+	if err := checkSuspended(user); err != nil {
+		return nil, err
+	}
+
+	return s.completeLogin(user, client)
+}
+
+// upgradePasswordHash 登录成功时若哈希使用的是旧算法或旧参数，用明文密码重新计算
+// 升级失败不影响本次登录，下次登录会再次尝试
+func (s *UserService) upgradePasswordHash(user *model.User, password string) {
+	if !auth.PasswordNeedsRehash(user.PasswordHash) {
+		return
+	}
+
+	hashedPassword, err := auth.HashPassword(password)
+	if err != nil {
+		log.Printf("Warning: Failed to rehash password for user %d: %v", user.ID, err)
+		return
+	}
+	if err := s.userRepo.UpgradePasswordHash(user.ID, user.PasswordHash, hashedPassword); err != nil {
+		log.Printf("Warning: Failed to upgrade password hash for user %d: %v", user.ID, err)
+		return
+	}
+	user.PasswordHash = hashedPassword
+}
+
+// LoginOIDC 使用单点登录回调生成的一次性登录码完成登录
+func (s *UserService) LoginOIDC(req *OIDCExchangeRequest, client ClientInfo) (*AuthResponse, error) {
+	user, err := GetOIDCService().ConsumeLoginCode(req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSuspended(user); err != nil {
+		return nil, err
+	}
+	return s.completeLogin(user, client)
+}
+
+// LoginPasskey 使用通行密钥完成无密码登录
+// 通行密钥要求用户验证（指纹、面容或设备 PIN），本身即满足多因素要求，不再要求两步验证
+func (s *UserService) LoginPasskey(req *PasskeyLoginRequest, client ClientInfo) (*AuthResponse, error) {
+	user, err := GetPasskeyService().FinishLogin(req, client.IP)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSuspended(user); err != nil {
+		return nil, err
+	}
+	return s.issueAuth(user, client)
+}
+
+// completeLogin 身份校验通过后签发令牌，启用两步验证的账号需先完成第二步
+func (s *UserService) completeLogin(user *model.User, client ClientInfo) (*AuthResponse, error) {
+	if user.TOTPEnabled {
+		token, err := GetTwoFactorService().BeginLogin(user.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &AuthResponse{
+			TwoFactorRequired: true,
+			TwoFactorToken:    token,
+		}, nil
+	}
+
+	return s.issueAuth(user, client)
+}
+
+// LoginTwoFactor 两步登录的第二步：校验验证码或恢复码后签发令牌
+func (s *UserService) LoginTwoFactor(req *TwoFactorLoginRequest, client ClientInfo) (*AuthResponse, error) {
+	user, err := GetTwoFactorService().CompleteLogin(req, client.IP)
+	if err != nil {
+		return nil, err
+	}
+	// 两步之间账号可能被封禁，签发令牌前再次确认
+	if err := checkSuspended(user); err != nil {
+		return nil, err
+	}
+	return s.issueAuth(user, client)
+}
+
+func (s *UserService) issueAuth(user *model.User, client ClientInfo) (*AuthResponse, error) {
+	tokens, err := s.tokenService.IssueTokens(user, client)
 	if err != nil {
 		return nil, err
 	}
 
 	return &AuthResponse{
-		Token: token,
-		User:  user,
+		TokenPair:              tokens,
+		User:                   user,
+		TwoFactorSetupRequired: TwoFactorRequired(user) && !user.TOTPEnabled,
 	}, nil
 }
 
-func (s *UserService) GetProfile(userID uint) (*model.User, error) {
-	return s.userRepo.GetByID(userID)
+// ProfileResponse 用户资料，附带收到的鼓励统计
+type ProfileResponse struct {
+	*model.User
+	TOTPEnabled        bool             `json:"totp_enabled"` // 是否已启用两步验证
+	HasPassword        bool             `json:"has_password"` // 是否已设置本地密码，单点登录创建的账号初始没有
+	CheersReceived     int64            `json:"cheers_received"`
+	CheersByKind       map[string]int64 `json:"cheers_by_kind"`
+	PendingDisplayName string           `json:"pending_display_name,omitempty"` // 等待审核的显示名称
+}
+
+// RefreshToken 轮换刷新令牌并签发新的访问令牌
+func (s *UserService) RefreshToken(req *RefreshRequest, client ClientInfo) (*AuthResponse, error) {
+	tokens, user, err := s.tokenService.Refresh(req, client)
+	if err != nil {
+		return nil, err
+	}
+	return &AuthResponse{
+		TokenPair: tokens,
+		User:      user,
+	}, nil
+}
+
+func (s *UserService) GetProfile(userID uint) (*ProfileResponse, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byKind, err := s.cheerRepo.CountReceivedByKind(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for _, count := range byKind {
+		total += count
+	}
+
+	pending, err := s.nameReviewService.PendingDisplayName(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProfileResponse{
+		User:               user,
+		TOTPEnabled:        user.TOTPEnabled,
+		HasPassword:        user.PasswordHash != "",
+		CheersReceived:     total,
+		CheersByKind:       byKind,
+		PendingDisplayName: pending,
+	}, nil
 }
 
 func (s *UserService) GetLeaderboard(limit int) ([]model.User, error) {
@@ -143,26 +401,43 @@ type UpdateUsernameRequest struct {
 // UpdatePasswordRequest 更新密码请求
 type UpdatePasswordRequest struct {
 	OldPassword string `json:"old_password" binding:"required"`
-	NewPassword string `json:"new_password" binding:"required,min=6,max=50"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// UpdateProfileResponse 更新资料结果，显示名称需审核时附带待审核标记
+type UpdateProfileResponse struct {
+	*model.User
+	DisplayNamePending bool `json:"display_name_pending,omitempty"`
 }
 
 // UpdateProfile 更新用户显示名称
-func (s *UserService) UpdateProfile(userID uint, req *UpdateProfileRequest) (*model.User, error) {
+// 新的显示名称命中屏蔽词或保留名称时提交审核，审核通过前显示名称保持不变
+// 管理员修改自己的显示名称无需审核
+func (s *UserService) UpdateProfile(userID uint, req *UpdateProfileRequest) (*UpdateProfileResponse, error) {
 	user, err := s.userRepo.GetByID(userID)
 	if err != nil {
 		return nil, ErrUserNotFound
 	}
 
+	pending := false
 	displayName := strings.TrimSpace(req.DisplayName)
 	if displayName != "" {
-		user.DisplayName = displayName
+		if !user.IsAdmin {
+			// 改回当前名称时同样会取消未处理的申请
+			if pending, err = s.nameReviewService.Screen(userID, displayName); err != nil {
+				return nil, err
+			}
+		}
+		if !pending {
+			user.DisplayName = displayName
+		}
 	}
 
-	if err := s.userRepo.Update(user); err != nil {
+	if err := s.userRepo.UpdateFields(user, "display_name"); err != nil {
 		return nil, err
 	}
 
-	return user, nil
+	return &UpdateProfileResponse{User: user, DisplayNamePending: pending}, nil
 }
 
 // UpdateUsername 更新用户名
@@ -177,8 +452,11 @@ func (s *UserService) UpdateUsername(userID uint, req *UpdateUsernameRequest) (*
 		return nil, ErrUserNotFound
 	}
 
-	// 检查新用户名是否已存在（排除自己）
+	// 检查新用户名是否已被占用（排除自己），改名后旧用户名立即释放
 	if username != user.Username {
+		if err := checkUsername(username); err != nil {
+			return nil, err
+		}
 		exists, err := s.userRepo.ExistsByUsername(username)
 		if err != nil {
 			return nil, err
@@ -188,34 +466,122 @@ func (s *UserService) UpdateUsername(userID uint, req *UpdateUsernameRequest) (*
 		}
 	}
 
+	changed := username != user.Username
 	user.Username = username
-	if err := s.userRepo.Update(user); err != nil {
-		return nil, err
+	if err := s.userRepo.UpdateFields(user, "username"); err != nil {
+		return nil, userWriteError(err)
 	}
 
+	// 包含屏蔽词的用户名照常生效，提交管理员审核；管理员改名无需审核
+	if changed && !user.IsAdmin {
+		if err := s.nameReviewService.ScreenUsername(user.ID, username); err != nil {
+			log.Printf("Warning: Failed to screen username for user %d: %v", user.ID, err)
+		}
+	}
 	return user, nil
 }
 
 // UpdatePassword 更新密码
-func (s *UserService) UpdatePassword(userID uint, req *UpdatePasswordRequest) error {
+// 修改成功后吊销该用户所有已签发的令牌，并为当前设备签发新令牌
+func (s *UserService) UpdatePassword(userID uint, req *UpdatePasswordRequest, client ClientInfo) (*AuthResponse, error) {
 	user, err := s.userRepo.GetByID(userID)
 	if err != nil {
-		return ErrUserNotFound
+		return nil, ErrUserNotFound
+	}
+
+	if user.PasswordHash == "" {
+		return nil, ErrPasswordNotSet
 	}
 
 	// 验证旧密码
 	if !auth.CheckPassword(req.OldPassword, user.PasswordHash) {
-		return ErrOldPasswordWrong
+		return nil, ErrOldPasswordWrong
+	}
+
+	if err := checkPasswordPolicy(req.NewPassword, user.Username); err != nil {
+		return nil, err
 	}
 
 	// 生成新密码哈希
 	hashedPassword, err := auth.HashPassword(req.NewPassword)
 	if err != nil {
+		return nil, err
+	}
+
+	// 新密码与令牌吊销在同一事务中写入，之后签发的令牌不受影响
+	var cutoff time.Time
+	err = s.userRepo.Transaction(func(tx *gorm.DB) error {
+		cutoff, err = s.passwordService.setPasswordTx(tx, user, hashedPassword)
 		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.passwordService.afterPasswordChange(user, cutoff); err != nil {
+		return nil, err
 	}
 
-	user.PasswordHash = hashedPassword
-	return s.userRepo.Update(user)
+	return s.issueAuth(user, client)
+}
+
+// SetPasswordRequest 设置初始密码请求
+type SetPasswordRequest struct {
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// SetPassword 为没有本地密码的账号（单点登录创建）设置初始密码，已有密码时需通过修改密码更换
+func (s *UserService) SetPassword(userID uint, req *SetPasswordRequest) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if user.PasswordHash != "" {
+		return ErrPasswordSet
+	}
+
+	if err := checkPasswordPolicy(req.NewPassword, user.Username); err != nil {
+		return err
+	}
+	hashedPassword, err := auth.HashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	// 仅在仍未设置密码时写入，并发请求只有一个生效
+	set, err := s.userRepo.SetInitialPassword(userID, hashedPassword)
+	if err != nil {
+		return err
+	}
+	if !set {
+		return ErrPasswordSet
+	}
+	return nil
+}
+
+// LogoutRequest 退出登录请求
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Logout 退出登录，吊销当前访问令牌及其所属会话
+// 旧版令牌不携带会话 ID 时，通过请求中的刷新令牌定位会话
+func (s *UserService) Logout(userID uint, jti string, sessionID uint, expiresAt time.Time, req *LogoutRequest) error {
+	revocation := GetRevocationService()
+	if err := revocation.RevokeToken(jti, userID, expiresAt); err != nil {
+		return err
+	}
+
+	if sessionID == 0 && req.RefreshToken != "" {
+		id, err := s.tokenService.SessionIDByRefreshToken(userID, req.RefreshToken)
+		if err != nil {
+			return err
+		}
+		sessionID = id
+	}
+	if sessionID == 0 {
+		return nil
+	}
+	return revocation.RevokeSessions([]uint{sessionID})
 }
 
 // InitAdmin 初始化管理员账号
@@ -225,6 +591,11 @@ func (s *UserService) InitAdmin(username, password string) error {
 		return nil // 未配置管理员，跳过
 	}
 
+	// 配置的管理员密码同样需要符合密码策略，不符合时不创建也不更新账号
+	if err := checkPasswordPolicy(password, username); err != nil {
+		return err
+	}
+
 	hashedPassword, err := auth.HashPassword(password)
 	if err != nil {
 		return err
@@ -241,35 +612,362 @@ func (s *UserService) InitAdmin(username, password string) error {
 				PasswordHash: hashedPassword,
 				IsAdmin:      true,
 			}
-			return s.userRepo.Create(admin)
+			// 用户名被已删除的账号占用时返回 ErrUserExists，需先恢复或永久清除该账号
+			return userWriteError(s.userRepo.Create(admin))
 		}
 		return err
 	}
 
+	// 配置中的密码变更时吊销该账号已签发的令牌
+	if !auth.CheckPassword(password, user.PasswordHash) {
+		cutoff, err := GetRevocationService().RevokeAllForUser(user.ID)
+		if err != nil {
+			return err
+		}
+		user.TokensRevokedAt = &cutoff
+	}
+
 	// 更新现有管理员账号
 	user.PasswordHash = hashedPassword
 	user.IsAdmin = true
-	return s.userRepo.Update(user)
+	if err := s.userRepo.UpdateFields(user, "password_hash", "is_admin"); err != nil {
+		return err
+	}
+	GetUserStateCache().Invalidate(user.ID)
+	return nil
+}
+
+// AdminUser 管理后台中的用户信息，附带不在公开接口中返回的封禁状态
+type AdminUser struct {
+	model.User
+	SuspendedAt    *time.Time `json:"suspended_at"`
+	SuspendedUntil *time.Time `json:"suspended_until"`
+	SuspendReason  string     `json:"suspend_reason"`
+	SuspendedBy    *uint      `json:"suspended_by"`
+}
+
+func newAdminUser(user *model.User) *AdminUser {
+	return &AdminUser{
+		User:           *user,
+		SuspendedAt:    user.SuspendedAt,
+		SuspendedUntil: user.SuspendedUntil,
+		SuspendReason:  user.SuspendReason,
+		SuspendedBy:    user.SuspendedBy,
+	}
+}
+
+// getAdminUser 重新读取用户，用于管理操作完成后返回最新状态
+func (s *UserService) getAdminUser(userID uint) (*AdminUser, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return newAdminUser(user), nil
 }
 
 // GetAllUsers 获取所有用户列表（管理员功能）
-func (s *UserService) GetAllUsers(page, pageSize int) ([]model.User, int64, error) {
-	return s.userRepo.GetAllUsers(page, pageSize)
+func (s *UserService) GetAllUsers(page, pageSize int) ([]AdminUser, int64, error) {
+	users, total, err := s.userRepo.GetAllUsers(page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	result := make([]AdminUser, 0, len(users))
+	for i := range users {
+		result = append(result, *newAdminUser(&users[i]))
+	}
+	return result, total, nil
+}
+
+// DeleteUser 删除用户（管理员功能），同时吊销该用户所有令牌
+func (s *UserService) DeleteUser(actor AuditActor, userID uint) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	// 吊销与删除在同一事务中，删除失败时用户的登录会话不受影响
+	revocation := GetRevocationService()
+	var cutoff time.Time
+	err = s.auditService.Record(actor, &AuditEntry{
+		Action:     "user.delete",
+		TargetType: model.AuditTargetUser,
+		TargetID:   auditID(userID),
+		Before:     newAdminUser(user),
+	}, func(tx *gorm.DB) error {
+		var err error
+		if cutoff, err = revocation.RevokeAllForUserTx(tx, userID); err != nil {
+			return err
+		}
+		return s.userRepo.WithTx(tx).Delete(userID)
+	})
+	if err != nil {
+		return err
+	}
+	revocation.ApplyUserCutoff(userID, cutoff)
+	GetUserStateCache().Invalidate(userID)
+	return nil
 }
 
-// DeleteUser 删除用户（管理员功能）
-func (s *UserService) DeleteUser(userID uint) error {
-	return s.userRepo.Delete(userID)
+// DeletedUser 已删除的用户，附带删除时间和自动清除时间
+type DeletedUser struct {
+	AdminUser
+	DeletedAt time.Time  `json:"deleted_at"`
+	PurgeAt   *time.Time `json:"purge_at"` // 到期后永久清除，未开启自动清除时为空
+}
+
+// GetDeletedUsers 获取已删除、尚未永久清除的用户列表（管理员功能）
+func (s *UserService) GetDeletedUsers(page, pageSize int) ([]DeletedUser, int64, error) {
+	users, total, err := s.userRepo.GetDeletedUsers(page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	retentionDays := config.Get().Account.DeletedRetentionDays
+	result := make([]DeletedUser, 0, len(users))
+	for i := range users {
+		u := &users[i]
+		item := DeletedUser{AdminUser: *newAdminUser(u), DeletedAt: u.DeletedAt.Time}
+		if retentionDays > 0 {
+			purgeAt := u.DeletedAt.Time.AddDate(0, 0, retentionDays)
+			item.PurgeAt = &purgeAt
+		}
+		result = append(result, item)
+	}
+	return result, total, nil
+}
+
+// RestoreUser 恢复已删除的用户（管理员功能），打卡记录等数据原样保留
+// 删除时吊销的令牌不会恢复，用户需要重新登录
+func (s *UserService) RestoreUser(actor AuditActor, userID uint) (*AdminUser, error) {
+	user, err := s.userRepo.GetDeletedByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	err = s.auditService.Record(actor, &AuditEntry{
+		Action:     "user.restore",
+		TargetType: model.AuditTargetUser,
+		TargetID:   auditID(userID),
+		Before:     map[string]interface{}{"deleted_at": user.DeletedAt.Time},
+	}, func(tx *gorm.DB) error {
+		return s.userRepo.WithTx(tx).Restore(userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	GetUserStateCache().Invalidate(userID)
+
+	return s.getAdminUser(userID)
+}
+
+// PurgeUser 永久删除用户及其打卡、小组、会话等全部关联数据（管理员功能）
+// 仅能清除已删除的用户，避免误操作
+func (s *UserService) PurgeUser(actor AuditActor, userID uint) error {
+	user, err := s.userRepo.GetDeletedByID(userID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if _, err := s.userRepo.GetByID(userID); err == nil {
+			return ErrUserNotDeleted
+		}
+		return ErrUserNotFound
+	}
+
+	if err := s.purge(actor, user); err != nil {
+		return err
+	}
+	GetUserStateCache().Invalidate(userID)
+	return nil
+}
+
+func (s *UserService) purge(actor AuditActor, user *model.User) error {
+	return s.auditService.Record(actor, &AuditEntry{
+		Action:     "user.purge",
+		TargetType: model.AuditTargetUser,
+		TargetID:   auditID(user.ID),
+		Before:     newAdminUser(user),
+	}, func(tx *gorm.DB) error {
+		return s.userRepo.WithTx(tx).Purge(user)
+	})
+}
+
+// PurgeExpiredUsers 永久清除删除时间早于保留期的用户，返回清除数量
+func (s *UserService) PurgeExpiredUsers(retentionDays int) (int, error) {
+	users, err := s.userRepo.GetDeletedBefore(time.Now().AddDate(0, 0, -retentionDays))
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for i := range users {
+		if err := s.purge(SystemActor, &users[i]); err != nil {
+			log.Printf("Warning: Failed to purge deleted user %d: %v", users[i].ID, err)
+			continue
+		}
+		GetUserStateCache().Invalidate(users[i].ID)
+		purged++
+	}
+	return purged, nil
+}
+
+// StartPurge 启动后台任务，定期永久清除超过保留期的已删除用户和过期的登录失败记录
+// retentionDays 不大于 0 时不自动清除已删除用户
+func (s *UserService) StartPurge(retentionDays int, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			s.runPurge(retentionDays)
+		}
+	}()
+}
+
+func (s *UserService) runPurge(retentionDays int) {
+	if retentionDays > 0 {
+		purged, err := s.PurgeExpiredUsers(retentionDays)
+		if err != nil {
+			log.Printf("Warning: Failed to purge deleted users: %v", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d deleted users past %d-day retention", purged, retentionDays)
+		}
+	}
+
+	if _, err := GetLoginGuard().PurgeStale(); err != nil {
+		log.Printf("Warning: Failed to purge stale login failures: %v", err)
+	}
 }
 
 // SetUserAdmin 设置用户管理员状态（管理员功能）
-func (s *UserService) SetUserAdmin(userID uint, isAdmin bool) error {
+func (s *UserService) SetUserAdmin(actor AuditActor, userID uint, isAdmin bool) error {
 	user, err := s.userRepo.GetByID(userID)
 	if err != nil {
 		return ErrUserNotFound
 	}
+	entry := &AuditEntry{
+		Action:     "user.set_admin",
+		TargetType: model.AuditTargetUser,
+		TargetID:   auditID(userID),
+		Before:     map[string]bool{"is_admin": user.IsAdmin},
+		After:      map[string]bool{"is_admin": isAdmin},
+	}
 	user.IsAdmin = isAdmin
-	return s.userRepo.Update(user)
+	err = s.auditService.Record(actor, entry, func(tx *gorm.DB) error {
+		return s.userRepo.WithTx(tx).UpdateFields(user, "is_admin")
+	})
+	if err != nil {
+		return err
+	}
+	GetUserStateCache().Invalidate(userID)
+	return nil
+}
+
+// SuspendUserRequest 封禁用户请求，DurationHours 为 0 表示永久封禁
+type SuspendUserRequest struct {
+	Reason        string `json:"reason" binding:"max=255"`
+	DurationHours int    `json:"duration_hours" binding:"min=0,max=87600"`
+}
+
+// SuspendUser 封禁用户（管理员功能），被封禁的用户无法登录和访问接口，并从排行榜和热力图中隐藏
+// 封禁不删除任何数据，也不吊销登录会话，解除封禁后账号完全恢复
+func (s *UserService) SuspendUser(actor AuditActor, userID uint, req *SuspendUserRequest) (*AdminUser, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	if user.IsAdmin {
+		return nil, ErrCannotSuspend
+	}
+
+	var until *time.Time
+	if req.DurationHours > 0 {
+		t := time.Now().Add(time.Duration(req.DurationHours) * time.Hour)
+		until = &t
+	}
+	reason := strings.TrimSpace(req.Reason)
+	action := "user.ban"
+	if until != nil {
+		action = "user.suspend"
+	}
+	err = s.auditService.Record(actor, &AuditEntry{
+		Action:     action,
+		TargetType: model.AuditTargetUser,
+		TargetID:   auditID(userID),
+		Before:     suspensionOf(user),
+		After:      map[string]interface{}{"suspended_until": until, "suspend_reason": reason},
+	}, func(tx *gorm.DB) error {
+		return s.userRepo.WithTx(tx).Suspend(userID, until, reason, actor.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	GetUserStateCache().Invalidate(userID)
+
+	return s.getAdminUser(userID)
+}
+
+// UnsuspendUser 解除封禁（管理员功能）
+func (s *UserService) UnsuspendUser(actor AuditActor, userID uint) (*AdminUser, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	err = s.auditService.Record(actor, &AuditEntry{
+		Action:     "user.unsuspend",
+		TargetType: model.AuditTargetUser,
+		TargetID:   auditID(userID),
+		Before:     suspensionOf(user),
+	}, func(tx *gorm.DB) error {
+		return s.userRepo.WithTx(tx).Unsuspend(userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	GetUserStateCache().Invalidate(userID)
+
+	return s.getAdminUser(userID)
+}
+
+// suspension 审计记录中的封禁状态
+type suspension struct {
+	SuspendedAt    *time.Time `json:"suspended_at"`
+	SuspendedUntil *time.Time `json:"suspended_until"`
+	SuspendReason  string     `json:"suspend_reason"`
+}
+
+func suspensionOf(user *model.User) suspension {
+	return suspension{
+		SuspendedAt:    user.SuspendedAt,
+		SuspendedUntil: user.SuspendedUntil,
+		SuspendReason:  user.SuspendReason,
+	}
+}
+
+// userStats 审计记录中的统计数据和称号
+type userStats struct {
+	Streak       int    `json:"streak"`
+	MaxStreak    int    `json:"max_streak"`
+	TotalCheckin int    `json:"total_checkin"`
+	Title        string `json:"title"`
+}
+
+func statsOf(user *model.User) userStats {
+	return userStats{
+		Streak:       user.Streak,
+		MaxStreak:    user.MaxStreak,
+		TotalCheckin: user.TotalCheckin,
+		Title:        user.Title,
+	}
 }
 
 // UpdateUserStatsRequest 更新用户统计数据请求
@@ -281,11 +979,12 @@ type UpdateUserStatsRequest struct {
 }
 
 // UpdateUserStats 更新用户统计数据（管理员功能）
-func (s *UserService) UpdateUserStats(userID uint, req *UpdateUserStatsRequest) (*model.User, error) {
+func (s *UserService) UpdateUserStats(actor AuditActor, userID uint, req *UpdateUserStatsRequest) (*AdminUser, error) {
 	user, err := s.userRepo.GetByID(userID)
 	if err != nil {
 		return nil, ErrUserNotFound
 	}
+	before := statsOf(user)
 
 	if req.Streak != nil {
 		user.Streak = *req.Streak
@@ -300,9 +999,18 @@ func (s *UserService) UpdateUserStats(userID uint, req *UpdateUserStatsRequest)
 		user.Title = *req.Title
 	}
 
-	if err := s.userRepo.Update(user); err != nil {
+	err = s.auditService.Record(actor, &AuditEntry{
+		Action:     "user.update_stats",
+		TargetType: model.AuditTargetUser,
+		TargetID:   auditID(userID),
+		Before:     before,
+		After:      statsOf(user),
+	}, func(tx *gorm.DB) error {
+		return s.userRepo.WithTx(tx).UpdateFields(user, "streak", "max_streak", "total_checkin", "title")
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	return user, nil
+	return newAdminUser(user), nil
 }
@@ -0,0 +1,416 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"tidalcore-backend/internal/auth"
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/pkg/database"
+)
+
+func TestSetPasswordOnlyWithoutLocalPassword(t *testing.T) {
+	s := NewUserService()
+	user := createUser(t, false) // 与单点登录建号相同，没有本地密码
+
+	profile, err := s.GetProfile(user.ID)
+	if err != nil {
+		t.Fatalf("GetProfile: %v", err)
+	}
+	if profile.HasPassword {
+		t.Fatal("has_password = true for account without password")
+	}
+
+	// 没有密码时只能设置初始密码，不能修改或解绑
+	_, err = s.UpdatePassword(user.ID, &UpdatePasswordRequest{OldPassword: "x", NewPassword: testPassword}, ClientInfo{})
+	if !errors.Is(err, ErrPasswordNotSet) {
+		t.Fatalf("UpdatePassword: err = %v, want ErrPasswordNotSet", err)
+	}
+	err = GetOIDCService().Unlink(user.ID, 1, &UnlinkIdentityRequest{Password: "x"})
+	if !errors.Is(err, ErrPasswordNotSet) {
+		t.Fatalf("Unlink: err = %v, want ErrPasswordNotSet", err)
+	}
+
+	var policyErr *PasswordPolicyError
+	if err := s.SetPassword(user.ID, &SetPasswordRequest{NewPassword: "short"}); !errors.As(err, &policyErr) {
+		t.Fatalf("SetPassword weak: err = %v, want PasswordPolicyError", err)
+	}
+	if err := s.SetPassword(user.ID, &SetPasswordRequest{NewPassword: testPassword}); err != nil {
+		t.Fatalf("SetPassword: %v", err)
+	}
+	if err := s.SetPassword(user.ID, &SetPasswordRequest{NewPassword: testPassword + "2"}); !errors.Is(err, ErrPasswordSet) {
+		t.Fatalf("SetPassword again: err = %v, want ErrPasswordSet", err)
+	}
+
+	var stored model.User
+	if err := database.Get().First(&stored, user.ID).Error; err != nil {
+		t.Fatalf("load user: %v", err)
+	}
+	if !auth.CheckPassword(testPassword, stored.PasswordHash) {
+		t.Fatal("initial password was not stored")
+	}
+}
+
+func TestUpdatePasswordRevokesAPITokens(t *testing.T) {
+	s := NewUserService()
+	hash, err := auth.HashPassword(testPassword)
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	user := &model.User{Username: uniqueName("changepw"), DisplayName: "修改密码", PasswordHash: hash}
+	if err := database.Get().Create(user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	tokens := NewAPITokenService()
+	created, err := tokens.Create(user.ID, &CreateAPITokenRequest{Name: "watch", Scopes: []string{ScopeCheckinWrite}})
+	if err != nil {
+		t.Fatalf("Create api token: %v", err)
+	}
+
+	newPassword := testPassword + "2"
+	resp, err := s.UpdatePassword(user.ID, &UpdatePasswordRequest{OldPassword: testPassword, NewPassword: newPassword}, ClientInfo{})
+	if err != nil {
+		t.Fatalf("UpdatePassword: %v", err)
+	}
+	if _, err := auth.ParseToken(resp.AccessToken); err != nil {
+		t.Fatalf("access token issued after the change: %v", err)
+	}
+	if _, err := tokens.Authenticate(created.Token, ""); !errors.Is(err, ErrInvalidAPIToken) {
+		t.Fatalf("api token after password change: err = %v, want ErrInvalidAPIToken", err)
+	}
+
+	var stored model.User
+	if err := database.Get().First(&stored, user.ID).Error; err != nil {
+		t.Fatalf("load user: %v", err)
+	}
+	if !auth.CheckPassword(newPassword, stored.PasswordHash) || stored.TokensRevokedAt == nil {
+		t.Fatalf("password not changed or tokens not revoked: revoked_at=%v", stored.TokensRevokedAt)
+	}
+}
+
+func TestDeleteUserRevokesTokensWithDelete(t *testing.T) {
+	s := NewUserService()
+	admin := createUser(t, false)
+	user := createUser(t, false)
+
+	// 审计记录写入失败时删除回滚，吊销也不应生效
+	failAuditWrites(t, func() {
+		if err := s.DeleteUser(AuditActor{ID: admin.ID}, user.ID); err == nil {
+			t.Fatal("DeleteUser succeeded although the audit log could not be written")
+		}
+	})
+	var stored model.User
+	if err := database.Get().First(&stored, user.ID).Error; err != nil {
+		t.Fatalf("load user: %v", err)
+	}
+	if stored.TokensRevokedAt != nil {
+		t.Fatalf("tokens_revoked_at = %v after failed delete, want nil", stored.TokensRevokedAt)
+	}
+
+	if err := s.DeleteUser(AuditActor{ID: admin.ID}, user.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if err := database.Get().Unscoped().First(&stored, user.ID).Error; err != nil {
+		t.Fatalf("load deleted user: %v", err)
+	}
+	if stored.TokensRevokedAt == nil {
+		t.Fatal("tokens_revoked_at not set after delete")
+	}
+	if n := countAudit(t, "user.delete", auditID(user.ID)); n != 1 {
+		t.Fatalf("user.delete audit logs = %d, want 1", n)
+	}
+}
+
+// failAuditWrites 在 fn 执行期间让审计记录写入失败
+func failAuditWrites(t *testing.T, fn func()) {
+	t.Helper()
+	callbacks := database.Get().Callback().Create()
+	err := callbacks.Before("gorm:create").Register("test:fail_audit", func(db *gorm.DB) {
+		if db.Statement.Table == (model.AuditLog{}).TableName() {
+			db.AddError(errors.New("audit log unavailable"))
+		}
+	})
+	if err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+	defer func() {
+		if err := callbacks.Remove("test:fail_audit"); err != nil {
+			t.Fatalf("remove callback: %v", err)
+		}
+	}()
+	fn()
+}
+
+func TestInitAdminRejectsWeakPassword(t *testing.T) {
+	username := uniqueName("admin")
+	var policyErr *PasswordPolicyError
+	if err := NewUserService().InitAdmin(username, "password"); !errors.As(err, &policyErr) {
+		t.Fatalf("InitAdmin: err = %v, want PasswordPolicyError", err)
+	}
+	var count int64
+	if err := database.Get().Model(&model.User{}).Where("username = ?", username).Count(&count).Error; err != nil {
+		t.Fatalf("count users: %v", err)
+	}
+	if count != 0 {
+		t.Fatal("admin account created with a weak password")
+	}
+}
+
+func TestUpdateFieldsKeepsConcurrentChanges(t *testing.T) {
+	s := NewUserService()
+	user := createUser(t, false)
+
+	stale, err := s.userRepo.GetByID(user.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	// 读取之后用户被封禁，随后用读取时的数据修改显示名称
+	if err := s.userRepo.Suspend(user.ID, nil, "spam", 1); err != nil {
+		t.Fatalf("Suspend: %v", err)
+	}
+	stale.DisplayName = "新名称"
+	if err := s.userRepo.UpdateFields(stale, "display_name"); err != nil {
+		t.Fatalf("UpdateFields: %v", err)
+	}
+
+	var stored model.User
+	if err := database.Get().First(&stored, user.ID).Error; err != nil {
+		t.Fatalf("load user: %v", err)
+	}
+	if stored.DisplayName != "新名称" || stored.SuspendedAt == nil {
+		t.Fatalf("display name = %q, suspended_at = %v; want the new name and the suspension kept", stored.DisplayName, stored.SuspendedAt)
+	}
+}
+
+func TestLoginUpgradesLegacyPasswordHash(t *testing.T) {
+	s := NewUserService()
+	legacy, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("bcrypt: %v", err)
+	}
+	user := &model.User{Username: uniqueName("rehash"), DisplayName: "旧哈希", PasswordHash: string(legacy)}
+	if err := database.Get().Create(user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	client := ClientInfo{IP: "127.0.0.1"}
+
+	// 密码错误时不升级
+	if _, err := s.Login(&LoginRequest{Username: user.Username, Password: "wrong-password"}, client); !errors.Is(err, ErrInvalidPassword) {
+		t.Fatalf("Login with wrong password: err = %v", err)
+	}
+	var stored model.User
+	if err := database.Get().First(&stored, user.ID).Error; err != nil {
+		t.Fatalf("load user: %v", err)
+	}
+	if stored.PasswordHash != string(legacy) {
+		t.Fatal("hash changed after a failed login")
+	}
+
+	if _, err := s.Login(&LoginRequest{Username: user.Username, Password: testPassword}, client); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if err := database.Get().First(&stored, user.ID).Error; err != nil {
+		t.Fatalf("load user: %v", err)
+	}
+	if !strings.HasPrefix(stored.PasswordHash, "$argon2id$") || auth.PasswordNeedsRehash(stored.PasswordHash) {
+		t.Fatalf("hash after login = %s, want current argon2id", stored.PasswordHash)
+	}
+
+	// 升级后的哈希可以继续登录，且不再重复计算
+	upgraded := stored.PasswordHash
+	if _, err := s.Login(&LoginRequest{Username: user.Username, Password: testPassword}, client); err != nil {
+		t.Fatalf("Login after upgrade: %v", err)
+	}
+	if err := database.Get().First(&stored, user.ID).Error; err != nil {
+		t.Fatalf("load user: %v", err)
+	}
+	if stored.PasswordHash != upgraded {
+		t.Fatal("current hash was rehashed again")
+	}
+}
+
+// countUserRows 统计属于用户的记录数，包括已软删除的记录
+func countUserRows(t *testing.T, m interface{}, query string, args ...interface{}) int64 {
+	t.Helper()
+	var count int64
+	if err := database.Get().Unscoped().Model(m).Where(query, args...).Count(&count).Error; err != nil {
+		t.Fatalf("count %T: %v", m, err)
+	}
+	return count
+}
+
+func TestRestoreDeletedUser(t *testing.T) {
+	s := NewUserService()
+	admin := AuditActor{ID: createUser(t, false).ID}
+	user := createUser(t, false)
+	checkin := &model.Checkin{UserID: user.ID, Duration: 600, Cycles: 1, CheckedAt: time.Now()}
+	if err := database.Get().Create(checkin).Error; err != nil {
+		t.Fatalf("create checkin: %v", err)
+	}
+
+	if _, err := s.RestoreUser(admin, user.ID); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("restore active user: err = %v, want ErrUserNotFound", err)
+	}
+	if err := s.DeleteUser(admin, user.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+
+	deleted, _, err := s.GetDeletedUsers(1, 100)
+	if err != nil {
+		t.Fatalf("GetDeletedUsers: %v", err)
+	}
+	found := false
+	for _, d := range deleted {
+		found = found || d.ID == user.ID
+	}
+	if !found {
+		t.Fatalf("deleted user %d not listed", user.ID)
+	}
+
+	restored, err := s.RestoreUser(admin, user.ID)
+	if err != nil {
+		t.Fatalf("RestoreUser: %v", err)
+	}
+	if restored.ID != user.ID {
+		t.Fatalf("restored user = %d, want %d", restored.ID, user.ID)
+	}
+	// 打卡记录原样保留
+	if n := countUserRows(t, &model.Checkin{}, "user_id = ?", user.ID); n != 1 {
+		t.Fatalf("checkins after restore = %d, want 1", n)
+	}
+}
+
+func TestPurgeUser(t *testing.T) {
+	s := NewUserService()
+	admin := AuditActor{ID: createUser(t, false).ID}
+	user := createUser(t, false)
+	friend := createUser(t, false)
+
+	rows := []interface{}{
+		&model.Checkin{UserID: user.ID, Duration: 600, Cycles: 1, CheckedAt: time.Now()},
+		&model.Group{Name: "purge", OwnerID: user.ID, InviteCode: uniqueName("inv")},
+		&model.Cheer{SenderID: friend.ID, ReceiverID: user.ID, Kind: "clap", SentDate: time.Now().Format(dateLayout)},
+	}
+	for _, row := range rows {
+		if err := database.Get().Create(row).Error; err != nil {
+			t.Fatalf("create %T: %v", row, err)
+		}
+	}
+	group := rows[1].(*model.Group)
+	member := &model.GroupMember{GroupID: group.ID, UserID: friend.ID}
+	if err := database.Get().Create(member).Error; err != nil {
+		t.Fatalf("create member: %v", err)
+	}
+	if _, err := NewAPITokenService().Create(user.ID, &CreateAPITokenRequest{Name: "watch", Scopes: []string{ScopeCheckinWrite}}); err != nil {
+		t.Fatalf("Create api token: %v", err)
+	}
+
+	// 只能清除已删除的用户
+	if err := s.PurgeUser(admin, user.ID); !errors.Is(err, ErrUserNotDeleted) {
+		t.Fatalf("purge active user: err = %v, want ErrUserNotDeleted", err)
+	}
+	if err := s.PurgeUser(admin, user.ID+100000); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("purge unknown user: err = %v, want ErrUserNotFound", err)
+	}
+
+	if err := s.DeleteUser(admin, user.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if err := s.PurgeUser(admin, user.ID); err != nil {
+		t.Fatalf("PurgeUser: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		model interface{}
+		query string
+		args  []interface{}
+	}{
+		{"user", &model.User{}, "id = ?", []interface{}{user.ID}},
+		{"checkins", &model.Checkin{}, "user_id = ?", []interface{}{user.ID}},
+		{"owned groups", &model.Group{}, "owner_id = ?", []interface{}{user.ID}},
+		{"members of owned groups", &model.GroupMember{}, "group_id = ?", []interface{}{group.ID}},
+		{"cheers", &model.Cheer{}, "sender_id = ? OR receiver_id = ?", []interface{}{user.ID, user.ID}},
+		{"api tokens", &model.APIToken{}, "user_id = ?", []interface{}{user.ID}},
+		{"sessions", &model.Session{}, "user_id = ?", []interface{}{user.ID}},
+	}
+	for _, tt := range tests {
+		if n := countUserRows(t, tt.model, tt.query, tt.args...); n != 0 {
+			t.Errorf("%s left after purge: %d", tt.name, n)
+		}
+	}
+	if countAudit(t, "user.purge", auditID(user.ID)) != 1 {
+		t.Fatal("purge was not audited")
+	}
+}
+
+func TestPurgeExpiredUsers(t *testing.T) {
+	s := NewUserService()
+	admin := AuditActor{ID: createUser(t, false).ID}
+	expired := createUser(t, false)
+	recent := createUser(t, false)
+	for _, u := range []*model.User{expired, recent} {
+		if err := s.DeleteUser(admin, u.ID); err != nil {
+			t.Fatalf("DeleteUser: %v", err)
+		}
+	}
+	if err := database.Get().Unscoped().Model(&model.User{}).Where("id = ?", expired.ID).
+		Update("deleted_at", time.Now().AddDate(0, 0, -31)).Error; err != nil {
+		t.Fatalf("backdate deletion: %v", err)
+	}
+
+	// 保留期为 0 时不自动清除
+	s.runPurge(0)
+	if n := countUserRows(t, &model.User{}, "id = ?", expired.ID); n != 1 {
+		t.Fatal("user purged with retention disabled")
+	}
+
+	s.runPurge(30)
+	if n := countUserRows(t, &model.User{}, "id = ?", expired.ID); n != 0 {
+		t.Fatal("user past retention was not purged")
+	}
+	if n := countUserRows(t, &model.User{}, "id = ?", recent.ID); n != 1 {
+		t.Fatal("user within retention was purged")
+	}
+	if countAudit(t, "user.purge", auditID(expired.ID)) != 1 {
+		t.Fatal("scheduled purge was not audited")
+	}
+}
+
+func TestDeletedUsernameIsReserved(t *testing.T) {
+	s := NewUserService()
+	admin := AuditActor{ID: createUser(t, false).ID}
+	deleted := createUser(t, false)
+	other := createUser(t, false)
+	if err := s.DeleteUser(admin, deleted.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+
+	// 已删除账号的用户名保留到永久清除为止
+	_, err := s.Register(&RegisterRequest{Username: deleted.Username, Password: testPassword}, ClientInfo{})
+	if !errors.Is(err, ErrUserExists) {
+		t.Fatalf("Register: err = %v, want ErrUserExists", err)
+	}
+	if _, err := s.UpdateUsername(other.ID, &UpdateUsernameRequest{Username: deleted.Username}); !errors.Is(err, ErrUserExists) {
+		t.Fatalf("UpdateUsername: err = %v, want ErrUserExists", err)
+	}
+
+	// 跳过存在性检查直接写入时，唯一索引冲突同样转换为 ErrUserExists
+	dup := &model.User{Username: deleted.Username, DisplayName: "重复"}
+	if err := userWriteError(s.userRepo.Create(dup)); !errors.Is(err, ErrUserExists) {
+		t.Fatalf("duplicate insert: err = %v, want ErrUserExists", err)
+	}
+
+	if err := s.PurgeUser(admin, deleted.ID); err != nil {
+		t.Fatalf("PurgeUser: %v", err)
+	}
+	if _, err := s.UpdateUsername(other.ID, &UpdateUsernameRequest{Username: deleted.Username}); err != nil {
+		t.Fatalf("UpdateUsername after purge: %v", err)
+	}
+}
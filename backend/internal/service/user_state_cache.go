@@ -0,0 +1,98 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"tidalcore-backend/internal/repository"
+)
+
+// userStateTTL 用户状态缓存有效期
+const userStateTTL = 30 * time.Second
+
+// UserState 鉴权所需的用户实时状态
+type UserState struct {
+	Exists      bool // 用户存在且未被删除
+	IsAdmin     bool
+	TOTPEnabled bool // 已启用两步验证
+	Suspended   bool // 账号处于封禁中
+}
+
+type userStateEntry struct {
+	state     UserState
+	expiresAt time.Time
+}
+
+// UserStateCache 缓存用户的管理员、两步验证、封禁和删除状态，供鉴权中间件使用
+// 本进程内的状态变更会立即失效对应缓存
+type UserStateCache struct {
+	userRepo *repository.UserRepository
+
+	mu      sync.RWMutex
+	entries map[uint]userStateEntry
+}
+
+var (
+	userStateCache     *UserStateCache
+	userStateCacheOnce sync.Once
+)
+
+// GetUserStateCache 获取全局用户状态缓存
+func GetUserStateCache() *UserStateCache {
+	userStateCacheOnce.Do(func() {
+		userStateCache = &UserStateCache{
+			userRepo: repository.NewUserRepository(),
+			entries:  make(map[uint]userStateEntry),
+		}
+	})
+	return userStateCache
+}
+
+// Get 获取用户当前状态，缓存过期时从数据库读取
+func (c *UserStateCache) Get(userID uint) (UserState, error) {
+	now := time.Now()
+
+	c.mu.RLock()
+	entry, ok := c.entries[userID]
+	c.mu.RUnlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.state, nil
+	}
+
+	var state UserState
+	user, err := c.userRepo.GetByID(userID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return state, err
+		}
+	} else {
+		state = UserState{
+			Exists:      true,
+			IsAdmin:     user.IsAdmin,
+			TOTPEnabled: user.TOTPEnabled,
+			Suspended:   user.IsSuspended(now),
+		}
+	}
+
+	c.mu.Lock()
+	c.entries[userID] = userStateEntry{state: state, expiresAt: now.Add(userStateTTL)}
+	c.mu.Unlock()
+	return state, nil
+}
+
+// Invalidate 清除用户的缓存状态，下次鉴权时重新读取
+func (c *UserStateCache) Invalidate(userID uint) {
+	c.mu.Lock()
+	delete(c.entries, userID)
+	c.mu.Unlock()
+}
+
+// Clear 清空所有缓存，用于批量变更用户数据之后（如从备份恢复）
+func (c *UserStateCache) Clear() {
+	c.mu.Lock()
+	c.entries = make(map[uint]userStateEntry)
+	c.mu.Unlock()
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/pkg/database"
+)
+
+// expireUserState 让缓存中的用户状态立即过期，模拟超过 userStateTTL
+func expireUserState(userID uint) {
+	c := GetUserStateCache()
+	c.mu.Lock()
+	if entry, ok := c.entries[userID]; ok {
+		entry.expiresAt = time.Now().Add(-time.Second)
+		c.entries[userID] = entry
+	}
+	c.mu.Unlock()
+}
+
+func getUserState(t *testing.T, userID uint) UserState {
+	t.Helper()
+	state, err := GetUserStateCache().Get(userID)
+	if err != nil {
+		t.Fatalf("Get user state: %v", err)
+	}
+	return state
+}
+
+func TestUserStateCacheTTL(t *testing.T) {
+	user := createUser(t, false)
+	if state := getUserState(t, user.ID); !state.Exists || state.IsAdmin {
+		t.Fatalf("state = %+v, want existing non-admin", state)
+	}
+
+	// 绕过服务直接修改数据库时，有效期内仍返回缓存的状态
+	if err := database.Get().Model(&model.User{}).Where("id = ?", user.ID).Update("is_admin", true).Error; err != nil {
+		t.Fatalf("update is_admin: %v", err)
+	}
+	if state := getUserState(t, user.ID); state.IsAdmin {
+		t.Fatal("cached state changed before the TTL expired")
+	}
+
+	expireUserState(user.ID)
+	if state := getUserState(t, user.ID); !state.IsAdmin {
+		t.Fatal("state not reloaded after the TTL expired")
+	}
+
+	// 不存在的用户同样缓存，避免每次请求都查询数据库
+	if state := getUserState(t, user.ID+100000); state.Exists {
+		t.Fatalf("unknown user state = %+v", state)
+	}
+}
+
+func TestUserStateCacheInvalidatedByAdminActions(t *testing.T) {
+	s := NewUserService()
+	admin := AuditActor{ID: createUser(t, false).ID}
+	user := createUser(t, false)
+
+	getUserState(t, user.ID)
+	if err := s.SetUserAdmin(admin, user.ID, true); err != nil {
+		t.Fatalf("SetUserAdmin: %v", err)
+	}
+	if state := getUserState(t, user.ID); !state.IsAdmin {
+		t.Fatal("promotion not visible immediately")
+	}
+	if err := s.SetUserAdmin(admin, user.ID, false); err != nil {
+		t.Fatalf("SetUserAdmin: %v", err)
+	}
+	if state := getUserState(t, user.ID); state.IsAdmin {
+		t.Fatal("demotion not visible immediately")
+	}
+
+	if _, err := s.SuspendUser(admin, user.ID, &SuspendUserRequest{Reason: "spam"}); err != nil {
+		t.Fatalf("SuspendUser: %v", err)
+	}
+	if state := getUserState(t, user.ID); !state.Suspended {
+		t.Fatal("suspension not visible immediately")
+	}
+	if _, err := s.UnsuspendUser(admin, user.ID); err != nil {
+		t.Fatalf("UnsuspendUser: %v", err)
+	}
+	if state := getUserState(t, user.ID); state.Suspended {
+		t.Fatal("unsuspension not visible immediately")
+	}
+
+	if err := s.DeleteUser(admin, user.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if state := getUserState(t, user.ID); state.Exists {
+		t.Fatal("deletion not visible immediately")
+	}
+	if _, err := s.RestoreUser(admin, user.ID); err != nil {
+		t.Fatalf("RestoreUser: %v", err)
+	}
+	if state := getUserState(t, user.ID); !state.Exists {
+		t.Fatal("restore not visible immediately")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"tidalcore-backend/internal/model"
+	"tidalcore-backend/internal/realtime"
 	"tidalcore-backend/internal/repository"
 	"tidalcore-backend/pkg/database"
 )
@@ -52,7 +53,17 @@ func (s *VisitService) RecordVisit(ip, userAgent string) error {
 		VisitedDate: time.Now().In(s.loc).Format("2006-01-02"),
 	}
 
-	return s.repo.Create(visit)
+	if err := s.repo.Create(visit); err != nil {
+		return err
+	}
+
+	// 推送今日访问人数
+	if count, err := s.repo.GetTodayVisitCount(s.loc); err == nil {
+		realtime.Default().Publish(realtime.EventVisitStats, map[string]interface{}{
+			"today_visits": count,
+		})
+	}
+	return nil
 }
 
 // GetTodayCount 获取今日访问人数
@@ -1,15 +1,22 @@
 package middleware
 
 import (
+	"errors"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 
+	"tidalcore-backend/config"
 	"tidalcore-backend/internal/auth"
+	"tidalcore-backend/internal/service"
 	"tidalcore-backend/pkg/response"
 )
 
-func JWTAuth() gin.HandlerFunc {
+// JWTAuth 登录认证中间件
+// 指定 scopes 时，同时接受拥有全部授权范围的个人访问令牌；未指定时仅接受 JWT
+func JWTAuth(scopes ...string) gin.HandlerFunc {
+	apiTokens := service.NewAPITokenService()
+
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -25,6 +32,11 @@ func JWTAuth() gin.HandlerFunc {
 			return
 		}
 
+		if service.IsAPIToken(parts[1]) {
+			apiTokenAuth(c, apiTokens, parts[1], scopes)
+			return
+		}
+
 		claims, err := auth.ParseToken(parts[1])
 		if err != nil {
 			response.Unauthorized(c, err.Error())
@@ -32,14 +44,18 @@ func JWTAuth() gin.HandlerFunc {
 			return
 		}
 
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
+		if _, ok := loadUserState(c, claims.UserID); !ok {
+			return
+		}
+
+		setClaims(c, claims)
 		c.Next()
 	}
 }
 
 // AdminAuth 管理员权限验证中间件
-// 需要在 JWTAuth 之后使用，会检查用户是否为管理员
+// 需要在 JWTAuthWithAdmin 之后使用，会检查用户是否为管理员
+// 配置要求管理员启用两步验证时，未启用的管理员无法访问管理接口
 func AdminAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		isAdmin, exists := c.Get("is_admin")
@@ -48,11 +64,17 @@ func AdminAuth() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+		if config.Get().Admin.Require2FA && !c.GetBool("totp_enabled") {
+			response.Forbidden(c, "管理员账号需先启用两步验证")
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
 
 // JWTAuthWithAdmin JWT认证并获取管理员状态
+// 管理员状态以数据库中的用户当前状态为准，不信任令牌中的 is_admin 声明
 func JWTAuthWithAdmin() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -76,9 +98,130 @@ func JWTAuthWithAdmin() gin.HandlerFunc {
 			return
 		}
 
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
-		c.Set("is_admin", claims.IsAdmin)
+		state, ok := loadUserState(c, claims.UserID)
+		if !ok {
+			return
+		}
+
+		setClaims(c, claims)
+		c.Set("is_admin", state.IsAdmin)
+		c.Set("totp_enabled", state.TOTPEnabled)
+		c.Next()
+	}
+}
+
+// OptionalJWTAuth 可选的 JWT 认证
+// 用于 EventSource 等无法设置请求头的场景，支持通过 ticket 查询参数传递一次性事件流票据
+// 未携带令牌时以匿名身份继续，携带无效令牌或票据时拒绝请求
+func OptionalJWTAuth() gin.HandlerFunc {
+	tickets := service.GetEventTicketService()
+
+	return func(c *gin.Context) {
+		var claims *auth.Claims
+		if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+			parts := strings.SplitN(authHeader, " ", 2)
+			if len(parts) != 2 || parts[0] != "Bearer" {
+				response.Unauthorized(c, "invalid authorization format")
+				c.Abort()
+				return
+			}
+			parsed, err := auth.ParseToken(parts[1])
+			if err != nil {
+				response.Unauthorized(c, err.Error())
+				c.Abort()
+				return
+			}
+			claims = parsed
+		} else if ticket := c.Query("ticket"); ticket != "" {
+			redeemed, err := tickets.Redeem(ticket)
+			if err != nil {
+				response.Unauthorized(c, err.Error())
+				c.Abort()
+				return
+			}
+			claims = redeemed
+		}
+
+		if claims == nil {
+			c.Next()
+			return
+		}
+
+		if _, ok := loadUserState(c, claims.UserID); !ok {
+			return
+		}
+
+		setClaims(c, claims)
 		c.Next()
 	}
 }
+
+// apiTokenAuth 使用个人访问令牌认证，令牌须拥有路由要求的全部授权范围
+func apiTokenAuth(c *gin.Context, apiTokens *service.APITokenService, raw string, scopes []string) {
+	if len(scopes) == 0 {
+		response.Forbidden(c, "该接口不支持个人访问令牌")
+		c.Abort()
+		return
+	}
+
+	token, err := apiTokens.Authenticate(raw, c.ClientIP())
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidAPIToken), errors.Is(err, service.ErrAPITokenExpired):
+			response.Unauthorized(c, err.Error())
+		default:
+			response.ServerError(c, "failed to verify api token")
+		}
+		c.Abort()
+		return
+	}
+
+	for _, scope := range scopes {
+		if !token.HasScope(scope) {
+			response.Forbidden(c, "个人访问令牌缺少授权范围: "+scope)
+			c.Abort()
+			return
+		}
+	}
+
+	if _, ok := loadUserState(c, token.UserID); !ok {
+		return
+	}
+
+	c.Set("user_id", token.UserID)
+	c.Set("api_token_id", token.ID)
+	c.Next()
+}
+
+// loadUserState 读取令牌所属用户的当前状态，用户已被删除或封禁时拒绝请求
+func loadUserState(c *gin.Context, userID uint) (service.UserState, bool) {
+	state, err := service.GetUserStateCache().Get(userID)
+	if err != nil {
+		response.ServerError(c, "failed to load user state")
+		c.Abort()
+		return state, false
+	}
+	if !state.Exists {
+		response.Unauthorized(c, "user no longer exists")
+		c.Abort()
+		return state, false
+	}
+	if state.Suspended {
+		response.Forbidden(c, "账号已被封禁")
+		c.Abort()
+		return state, false
+	}
+	return state, true
+}
+
+// setClaims 将令牌信息写入上下文
+func setClaims(c *gin.Context, claims *auth.Claims) {
+	c.Set("user_id", claims.UserID)
+	c.Set("username", claims.Username)
+	c.Set("jti", claims.ID)
+	c.Set("session_id", claims.SessionID)
+	c.Set("claims", claims)
+	if claims.ExpiresAt != nil {
+		c.Set("token_expires_at", claims.ExpiresAt.Time)
+	}
+}
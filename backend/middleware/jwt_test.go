@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"tidalcore-backend/config"
+	"tidalcore-backend/internal/auth"
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/internal/service"
+	"tidalcore-backend/pkg/database"
+)
+
+// serve 以指定凭证请求经过 handlers 的路由，返回状态码和写入上下文的 user_id
+func serve(t *testing.T, credential string, handlers ...gin.HandlerFunc) (int, uint) {
+	t.Helper()
+	var userID uint
+	router := gin.New()
+	router.GET("/", append(handlers, func(c *gin.Context) {
+		userID = c.GetUint("user_id")
+		c.Status(http.StatusOK)
+	})...)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if credential != "" {
+		req.Header.Set("Authorization", "Bearer "+credential)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code, userID
+}
+
+func TestAPITokenScopes(t *testing.T) {
+	owner := &model.User{Username: uniqueName("owner"), DisplayName: "令牌"}
+	if err := database.Get().Create(owner).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	tokens := service.NewAPITokenService()
+	created, err := tokens.Create(owner.ID, &service.CreateAPITokenRequest{Name: "watch", Scopes: []string{service.ScopeCheckinWrite}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	expired, err := tokens.Create(owner.ID, &service.CreateAPITokenRequest{Name: "old", Scopes: []string{service.ScopeCheckinWrite}, ExpiresInDays: 1})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := database.Get().Model(&model.APIToken{}).Where("id = ?", expired.ID).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatalf("expire token: %v", err)
+	}
+	jwtToken, err := auth.GenerateToken(owner.ID, owner.Username)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		credential string
+		scopes     []string
+		want       int
+	}{
+		{"granted scope", created.Token, []string{service.ScopeCheckinWrite}, http.StatusOK},
+		{"missing scope", created.Token, []string{service.ScopeStatsRead}, http.StatusForbidden},
+		{"one of several scopes", created.Token, []string{service.ScopeCheckinWrite, service.ScopeStatsRead}, http.StatusForbidden},
+		{"route without scopes", created.Token, nil, http.StatusForbidden},
+		{"unknown token", service.APITokenPrefix + "unknown", []string{service.ScopeCheckinWrite}, http.StatusUnauthorized},
+		{"expired token", expired.Token, []string{service.ScopeCheckinWrite}, http.StatusUnauthorized},
+		{"jwt on scoped route", jwtToken, []string{service.ScopeStatsRead}, http.StatusOK},
+		{"missing credential", "", []string{service.ScopeCheckinWrite}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		code, userID := serve(t, tt.credential, JWTAuth(tt.scopes...))
+		if code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, code, tt.want)
+			continue
+		}
+		if code == http.StatusOK && userID != owner.ID {
+			t.Errorf("%s: user_id = %d, want %d", tt.name, userID, owner.ID)
+		}
+	}
+
+	// 令牌所属用户被封禁后令牌随之失效
+	now := time.Now()
+	if err := database.Get().Model(owner).Update("suspended_at", &now).Error; err != nil {
+		t.Fatalf("suspend: %v", err)
+	}
+	service.GetUserStateCache().Invalidate(owner.ID)
+	if code, _ := serve(t, created.Token, JWTAuth(service.ScopeCheckinWrite)); code != http.StatusForbidden {
+		t.Fatalf("suspended owner: status = %d, want 403", code)
+	}
+}
+
+func TestAdminAuthUsesCurrentUserState(t *testing.T) {
+	config.Get().Admin.Require2FA = false
+	admin := &model.User{Username: uniqueName("admin"), DisplayName: "管理员", IsAdmin: true}
+	if err := database.Get().Create(admin).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	// 令牌签发后不再依赖其中的 is_admin 声明
+	token, err := auth.GenerateSessionToken(admin.ID, admin.Username, true, 0)
+	if err != nil {
+		t.Fatalf("GenerateSessionToken: %v", err)
+	}
+	users := service.NewUserService()
+	actor := service.AuditActor{ID: admin.ID}
+
+	if code, _ := serve(t, token, JWTAuthWithAdmin(), AdminAuth()); code != http.StatusOK {
+		t.Fatalf("admin: status = %d, want 200", code)
+	}
+
+	tests := []struct {
+		name   string
+		change func() error
+		want   int
+	}{
+		{"demoted", func() error { return users.SetUserAdmin(actor, admin.ID, false) }, http.StatusForbidden},
+		{"promoted again", func() error { return users.SetUserAdmin(actor, admin.ID, true) }, http.StatusOK},
+		{"deleted", func() error { return users.DeleteUser(actor, admin.ID) }, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		if err := tt.change(); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if code, _ := serve(t, token, JWTAuthWithAdmin(), AdminAuth()); code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"tidalcore-backend/config"
+	"tidalcore-backend/internal/model"
+	"tidalcore-backend/pkg/database"
+)
+
+// 中间件测试共用一个 SQLite 数据库，鉴权时读取的用户状态和个人访问令牌都来自这里
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "tidalcore-middleware-test")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	code := func() int {
+		defer os.RemoveAll(dir)
+
+		if err := config.Load(""); err != nil {
+			fmt.Println(err)
+			return 1
+		}
+		config.Get().JWT.Secret = "tidalcore-test-secret"
+		gin.SetMode(gin.TestMode)
+
+		db, err := gorm.Open(sqlite.Open(filepath.Join(dir, "test.db")), &gorm.Config{
+			Logger:         logger.Default.LogMode(logger.Silent),
+			TranslateError: true,
+		})
+		if err != nil {
+			fmt.Println(err)
+			return 1
+		}
+		// 管理员变更和删除用户会写入审计日志并吊销会话
+		err = db.AutoMigrate(
+			&model.User{},
+			&model.APIToken{},
+			&model.AuditLog{},
+			&model.Session{},
+			&model.RefreshToken{},
+			&model.RevokedToken{},
+		)
+		if err != nil {
+			fmt.Println(err)
+			return 1
+		}
+		database.DB = db
+		return m.Run()
+	}()
+	os.Exit(code)
+}
+
+var nameSeq atomic.Int64
+
+// uniqueName 生成测试内唯一的名称
+func uniqueName(prefix string) string {
+	return fmt.Sprintf("%s%d", prefix, nameSeq.Add(1))
+}
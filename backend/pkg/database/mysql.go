@@ -24,6 +24,8 @@ func Init(cfg *config.DatabaseConfig) error {
 	var err error
 	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
+		// 将唯一索引冲突等数据库错误转换为 gorm.ErrDuplicatedKey，便于映射为业务错误
+		TranslateError: true,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to connect database: %w", err)
@@ -49,6 +49,15 @@ func BadRequest(c *gin.Context, msg string) {
 	Error(c, http.StatusBadRequest, CodeBadRequest, msg)
 }
 
+// BadRequestWithData 参数错误时附带结构化的错误详情
+func BadRequestWithData(c *gin.Context, msg string, data interface{}) {
+	c.JSON(http.StatusBadRequest, Response{
+		Code: CodeBadRequest,
+		Msg:  msg,
+		Data: data,
+	})
+}
+
 func Unauthorized(c *gin.Context, msg string) {
 	Error(c, http.StatusUnauthorized, CodeUnauthorized, msg)
 }
@@ -57,6 +66,15 @@ func Forbidden(c *gin.Context, msg string) {
 	Error(c, http.StatusForbidden, CodeForbidden, msg)
 }
 
+// ForbiddenWithData 拒绝访问时附带结构化的详情
+func ForbiddenWithData(c *gin.Context, msg string, data interface{}) {
+	c.JSON(http.StatusForbidden, Response{
+		Code: CodeForbidden,
+		Msg:  msg,
+		Data: data,
+	})
+}
+
 func NotFound(c *gin.Context, msg string) {
 	Error(c, http.StatusNotFound, CodeNotFound, msg)
 }
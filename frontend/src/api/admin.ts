@@ -1,8 +1,16 @@
 import request from './request'
 import type { UserInfo } from './auth'
 
+// Suspension fields are only returned by admin endpoints
+export interface AdminUserInfo extends UserInfo {
+  suspended_at: string | null
+  suspended_until: string | null
+  suspend_reason: string
+  suspended_by: number | null
+}
+
 export interface UsersResponse {
-  users: UserInfo[]
+  users: AdminUserInfo[]
   total: number
   page: number
   page_size: number
@@ -31,6 +39,213 @@ export function setUserAdmin(userId: number, isAdmin: boolean): Promise<void> {
   return request.put(`/admin/users/${userId}/admin`, { is_admin: isAdmin })
 }
 
-export function updateUserStats(userId: number, data: UpdateUserStatsRequest): Promise<UserInfo> {
+export interface DeletedUserInfo extends AdminUserInfo {
+  deleted_at: string
+  purge_at: string | null // 自动永久清除时间，未开启时为空
+}
+
+export interface DeletedUsersResponse {
+  users: DeletedUserInfo[]
+  total: number
+  page: number
+  page_size: number
+}
+
+export function getDeletedUsers(page = 1, pageSize = 20): Promise<DeletedUsersResponse> {
+  return request.get('/admin/users/deleted', { params: { page, page_size: pageSize } })
+}
+
+export function restoreUser(userId: number): Promise<AdminUserInfo> {
+  return request.post(`/admin/users/${userId}/restore`)
+}
+
+export function purgeUser(userId: number): Promise<void> {
+  return request.delete(`/admin/users/${userId}/purge`)
+}
+
+export interface SuspendUserRequest {
+  reason: string
+  duration_hours: number // 0 表示永久封禁
+}
+
+export function suspendUser(userId: number, data: SuspendUserRequest): Promise<AdminUserInfo> {
+  return request.put(`/admin/users/${userId}/suspend`, data)
+}
+
+export function unsuspendUser(userId: number): Promise<AdminUserInfo> {
+  return request.delete(`/admin/users/${userId}/suspend`)
+}
+
+export interface ResetCodeResponse {
+  username: string
+  code: string
+  expires_at: string
+}
+
+export function issueResetCode(userId: number): Promise<ResetCodeResponse> {
+  return request.post(`/admin/users/${userId}/reset-code`)
+}
+
+export function updateUserStats(userId: number, data: UpdateUserStatsRequest): Promise<AdminUserInfo> {
   return request.put(`/admin/users/${userId}/stats`, data)
 }
+
+export type NameReviewStatus = 'pending' | 'approved' | 'rejected' | 'cancelled'
+
+// 审核的名称类型：用户名在提交时已生效，通过审核不会修改用户
+export type NameReviewField = 'display_name' | 'username'
+
+export interface NameReview {
+  id: number
+  user_id: number
+  field: NameReviewField
+  display_name: string // 申请的名称，field 为 username 时是用户名
+  matched_terms: string
+  status: NameReviewStatus
+  reviewed_by: number | null
+  reviewed_at: string | null
+  reject_reason: string
+  created_at: string
+  username: string
+  current_display_name: string
+}
+
+export interface NameReviewsResponse {
+  reviews: NameReview[]
+  total: number
+  pending_count: number
+  page: number
+  page_size: number
+}
+
+export function getNameReviews(status: NameReviewStatus = 'pending', page = 1, pageSize = 20): Promise<NameReviewsResponse> {
+  return request.get('/admin/name-reviews', { params: { status, page, page_size: pageSize } })
+}
+
+export function approveNameReview(id: number): Promise<void> {
+  return request.post(`/admin/name-reviews/${id}/approve`)
+}
+
+export function rejectNameReview(id: number, reason: string): Promise<void> {
+  return request.post(`/admin/name-reviews/${id}/reject`, { reason })
+}
+
+export interface AuditLog {
+  id: number
+  actor_id: number // 0 表示系统后台任务
+  actor_name: string
+  action: string
+  target_type: string
+  target_id: string
+  before: unknown
+  after: unknown
+  ip: string
+  created_at: string
+}
+
+export interface AuditLogsResponse {
+  logs: AuditLog[]
+  total: number
+  page: number
+  page_size: number
+}
+
+export interface AuditLogFilter {
+  actor_id?: number
+  action?: string
+  target_type?: string
+  target_id?: string
+  from?: string // YYYY-MM-DD
+  to?: string
+}
+
+export function getAuditLogs(filter: AuditLogFilter, page = 1, pageSize = 20): Promise<AuditLogsResponse> {
+  return request.get('/admin/audit', { params: { ...filter, page, page_size: pageSize } })
+}
+
+// 导出审计日志，返回是否因超出上限而只包含最新的部分记录
+export async function exportAuditLogs(filter: AuditLogFilter, format: 'csv' | 'json' = 'csv'): Promise<boolean> {
+  const token = localStorage.getItem('token')
+  const params = new URLSearchParams({ format })
+  Object.entries(filter).forEach(([key, value]) => {
+    if (value !== undefined && value !== '') params.set(key, String(value))
+  })
+
+  const response = await fetch(`/api/v1/admin/audit/export?${params}`, {
+    headers: { Authorization: `Bearer ${token}` }
+  })
+  if (!response.ok) {
+    const data = await response.json().catch(() => null)
+    throw new Error(data?.msg || '导出失败')
+  }
+
+  const blob = await response.blob()
+  const blobUrl = window.URL.createObjectURL(blob)
+  const link = document.createElement('a')
+  link.href = blobUrl
+  link.download = `audit_${new Date().toISOString().split('T')[0]}.${format}`
+  document.body.appendChild(link)
+  link.click()
+  document.body.removeChild(link)
+  window.URL.revokeObjectURL(blobUrl)
+
+  return response.headers.get('X-Audit-Truncated') === 'true'
+}
+
+export interface DailyValue {
+  date: string // YYYY-MM-DD
+  count: number
+}
+
+export interface RetentionRate {
+  eligible: number // 注册后第 N 天已到来的用户数
+  retained: number
+  rate: number // 0~1
+}
+
+export interface AdminStats {
+  from: string
+  to: string
+  days: number
+  users: {
+    total: number
+    new: number
+    total_before: number
+    suspended: number
+    daily_new: DailyValue[]
+  }
+  active: {
+    dau: number
+    wau: number
+    mau: number
+    range: number
+    daily: DailyValue[]
+  }
+  checkins: {
+    total: number
+    count: number
+    avg_per_day: number
+    avg_per_user: number
+    total_duration: number // 秒
+    avg_duration: number // 秒
+    avg_cycles: number
+    daily: DailyValue[]
+  }
+  retention: {
+    cohort: number
+    day1: RetentionRate
+    day7: RetentionRate
+    day30: RetentionRate
+  }
+  visits: {
+    total: number
+    count: number
+    visitors: number
+    daily: DailyValue[]
+  }
+}
+
+// 获取统计数据，日期为 YYYY-MM-DD，不传时默认最近 30 天
+export function getAdminStats(from?: string, to?: string): Promise<AdminStats> {
+  return request.get('/admin/stats', { params: { from, to } })
+}
@@ -20,6 +20,8 @@ export interface UserInfo {
   max_streak: number
   total_checkin: number
   is_admin: boolean
+  pending_display_name?: string // Only in /user/profile; the name awaiting admin review
+  has_password?: boolean // Only in /user/profile; false for single sign-on accounts without a local password
   created_at: string
 }
 
@@ -38,22 +40,86 @@ export interface UpdatePasswordRequest {
 
 export interface AuthResponse {
   token: string
+  refresh_token: string
+  expires_in: number
   user: UserInfo
+  two_factor_setup_required?: boolean
+  // Only present right after registration; shown once so the user can store them offline
+  recovery_codes?: string[]
+  // The chosen display name matched the blocklist and is waiting for admin review
+  display_name_pending?: boolean
 }
 
-export function login(data: LoginRequest): Promise<AuthResponse> {
+// Accounts with 2FA enabled get a short-lived two_factor_token instead of tokens after the password check
+export interface LoginResponse extends Partial<AuthResponse> {
+  two_factor_required?: boolean
+  two_factor_token?: string
+}
+
+export interface TwoFactorLoginRequest {
+  two_factor_token: string
+  code: string
+}
+
+export function login(data: LoginRequest): Promise<LoginResponse> {
   return request.post('/auth/login', data)
 }
 
+export function loginTwoFactor(data: TwoFactorLoginRequest): Promise<AuthResponse> {
+  return request.post('/auth/login/2fa', data)
+}
+
 export function register(data: RegisterRequest): Promise<AuthResponse> {
   return request.post('/auth/register', data)
 }
 
+export interface OIDCInfo {
+  enabled: boolean
+  provider_name?: string
+}
+
+export function getOIDCInfo(): Promise<OIDCInfo> {
+  return request.get('/auth/oidc')
+}
+
+// Single sign-on starts with a full-page redirect; the callback returns to /login with a one-time oidc_code
+export function oidcLoginURL(redirect?: string): string {
+  const base = `${request.defaults.baseURL || ''}/auth/oidc/login`
+  return redirect ? `${base}?redirect=${encodeURIComponent(redirect)}` : base
+}
+
+export function exchangeOIDCCode(code: string): Promise<LoginResponse> {
+  return request.post('/auth/oidc/exchange', { code })
+}
+
+export interface ResetPasswordRequest {
+  username: string
+  code: string
+  new_password: string
+}
+
+export function resetPassword(data: ResetPasswordRequest): Promise<void> {
+  return request.post('/auth/reset', data)
+}
+
+// Same payload as resetPassword, but the code is one of the user's own recovery codes
+export function recoverPassword(data: ResetPasswordRequest): Promise<void> {
+  return request.post('/auth/recover', data)
+}
+
+export function refreshToken(refresh_token: string): Promise<AuthResponse> {
+  return request.post('/auth/refresh', { refresh_token })
+}
+
 export function getProfile(): Promise<UserInfo> {
   return request.get('/user/profile')
 }
 
-export function updateProfile(data: UpdateProfileRequest): Promise<UserInfo> {
+export interface UpdateProfileResponse extends UserInfo {
+  display_name_pending?: boolean
+}
+
+export function updateProfile(data: UpdateProfileRequest): Promise<UpdateProfileResponse> {
   return request.put('/user/profile', data)
 }
 
@@ -61,6 +127,17 @@ export function updateUsername(data: UpdateUsernameRequest): Promise<UserInfo> {
   return request.put('/user/username', data)
 }
 
-export function updatePassword(data: UpdatePasswordRequest): Promise<void> {
+// Changing the password revokes every existing session; the response carries fresh tokens for this device
+export function updatePassword(data: UpdatePasswordRequest): Promise<AuthResponse> {
   return request.put('/user/password', data)
 }
+
+// Sets the first local password of a single sign-on account; fails once a password exists
+export function setPassword(new_password: string): Promise<void> {
+  return request.post('/user/password', { new_password })
+}
+
+// The access token is passed explicitly because the store clears it before the request is sent
+export function logout(token: string, refresh_token?: string): Promise<void> {
+  return request.post('/auth/logout', { refresh_token }, { headers: { Authorization: `Bearer ${token}` } })
+}
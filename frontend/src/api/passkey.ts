@@ -0,0 +1,134 @@
+import request from './request'
+import type { AuthResponse } from './auth'
+
+export interface Passkey {
+  id: number
+  name: string
+  backup_eligible: boolean
+  last_used_at: string | null
+  created_at: string
+}
+
+// The server sends WebAuthn options as JSON with binary fields base64url-encoded
+interface CredentialDescriptorJSON {
+  type: PublicKeyCredentialType
+  id: string
+  transports?: AuthenticatorTransport[]
+}
+
+interface CreationOptionsJSON {
+  publicKey: Omit<PublicKeyCredentialCreationOptions, 'challenge' | 'user' | 'excludeCredentials'> & {
+    challenge: string
+    user: { id: string; name: string; displayName: string }
+    excludeCredentials?: CredentialDescriptorJSON[]
+  }
+}
+
+interface RequestOptionsJSON {
+  publicKey: Omit<PublicKeyCredentialRequestOptions, 'challenge' | 'allowCredentials'> & {
+    challenge: string
+    allowCredentials?: CredentialDescriptorJSON[]
+  }
+}
+
+interface PasskeyLoginBegin {
+  session_id: string
+  options: RequestOptionsJSON
+}
+
+function toBase64url(buffer: ArrayBuffer): string {
+  const bytes = new Uint8Array(buffer)
+  let binary = ''
+  bytes.forEach((b) => { binary += String.fromCharCode(b) })
+  return btoa(binary).replace(/\+/g, '-').replace(/\//g, '_').replace(/=+$/, '')
+}
+
+function fromBase64url(value: string): ArrayBuffer {
+  const base64 = value.replace(/-/g, '+').replace(/_/g, '/').padEnd(Math.ceil(value.length / 4) * 4, '=')
+  const binary = atob(base64)
+  const bytes = new Uint8Array(binary.length)
+  for (let i = 0; i < binary.length; i++) bytes[i] = binary.charCodeAt(i)
+  return bytes.buffer
+}
+
+function toDescriptors(list?: CredentialDescriptorJSON[]): PublicKeyCredentialDescriptor[] | undefined {
+  return list?.map((c) => ({ ...c, id: fromBase64url(c.id) }))
+}
+
+export function passkeysSupported(): boolean {
+  return typeof window !== 'undefined' && !!window.PublicKeyCredential
+}
+
+export function listPasskeys(): Promise<Passkey[]> {
+  return request.get('/user/passkeys')
+}
+
+export function renamePasskey(id: number, name: string): Promise<void> {
+  return request.put(`/user/passkeys/${id}`, { name })
+}
+
+export function deletePasskey(id: number): Promise<void> {
+  return request.delete(`/user/passkeys/${id}`)
+}
+
+// Runs the full registration ceremony: server options -> platform prompt -> server verification
+export async function registerPasskey(name: string): Promise<Passkey> {
+  const options: CreationOptionsJSON = await request.post('/user/passkeys/register/begin')
+  const { publicKey } = options
+
+  const credential = await navigator.credentials.create({
+    publicKey: {
+      ...publicKey,
+      challenge: fromBase64url(publicKey.challenge),
+      user: { ...publicKey.user, id: fromBase64url(publicKey.user.id) },
+      excludeCredentials: toDescriptors(publicKey.excludeCredentials)
+    }
+  }) as PublicKeyCredential | null
+  if (!credential) throw new Error('已取消')
+
+  const response = credential.response as AuthenticatorAttestationResponse
+  return request.post('/user/passkeys/register/finish', {
+    name,
+    credential: {
+      id: credential.id,
+      rawId: toBase64url(credential.rawId),
+      type: credential.type,
+      response: {
+        clientDataJSON: toBase64url(response.clientDataJSON),
+        attestationObject: toBase64url(response.attestationObject),
+        transports: response.getTransports?.() ?? []
+      }
+    }
+  })
+}
+
+// Discoverable login: the authenticator picks the account, so no username is needed
+export async function loginWithPasskey(): Promise<AuthResponse> {
+  const { session_id, options }: PasskeyLoginBegin = await request.post('/auth/passkey/login/begin')
+  const { publicKey } = options
+
+  const credential = await navigator.credentials.get({
+    publicKey: {
+      ...publicKey,
+      challenge: fromBase64url(publicKey.challenge),
+      allowCredentials: toDescriptors(publicKey.allowCredentials)
+    }
+  }) as PublicKeyCredential | null
+  if (!credential) throw new Error('已取消')
+
+  const response = credential.response as AuthenticatorAssertionResponse
+  return request.post('/auth/passkey/login/finish', {
+    session_id,
+    credential: {
+      id: credential.id,
+      rawId: toBase64url(credential.rawId),
+      type: credential.type,
+      response: {
+        clientDataJSON: toBase64url(response.clientDataJSON),
+        authenticatorData: toBase64url(response.authenticatorData),
+        signature: toBase64url(response.signature),
+        userHandle: response.userHandle ? toBase64url(response.userHandle) : undefined
+      }
+    }
+  })
+}
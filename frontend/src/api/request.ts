@@ -2,6 +2,10 @@ import axios, { type AxiosResponse, type InternalAxiosRequestConfig } from 'axio
 import { useUserStore } from '@/store/user'
 import router from '@/router'
 
+interface RetryableRequestConfig extends InternalAxiosRequestConfig {
+  _retried?: boolean
+}
+
 const request = axios.create({
   baseURL: '/api/v1',
   timeout: 15000
@@ -10,7 +14,7 @@ const request = axios.create({
 request.interceptors.request.use(
   (config: InternalAxiosRequestConfig) => {
     const userStore = useUserStore()
-    if (userStore.token) {
+    if (userStore.token && !config.headers.Authorization) {
       config.headers.Authorization = `Bearer ${userStore.token}`
     }
     return config
@@ -28,12 +32,28 @@ request.interceptors.response.use(
 
     return Promise.reject(new Error(msg || '请求失败'))
   },
-  (error) => {
+  async (error) => {
     if (error.response) {
       const { status, data } = error.response
 
+      // An expired token on logout is not an error worth redirecting for
+      if (status === 401 && error.config?.url === '/auth/logout') {
+        return Promise.reject(new Error(data?.msg || '登录已过期'))
+      }
+
       if (status === 401) {
         const userStore = useUserStore()
+        const config = error.config as RetryableRequestConfig | undefined
+
+        // Access tokens are short-lived: try a refresh once, then replay the request
+        if (config && !config._retried && !config.url?.startsWith('/auth/')) {
+          config._retried = true
+          if (await userStore.refresh()) {
+            config.headers.Authorization = `Bearer ${userStore.token}`
+            return request(config)
+          }
+        }
+
         userStore.logout()
         router.push({
           name: 'login',
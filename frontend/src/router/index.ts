@@ -73,12 +73,24 @@ const router = createRouter({
           component: () => import('@/views/admin/Users.vue'),
           meta: { title: '用户管理 - 管理后台 - TidalCore' }
         },
+        {
+          path: 'name-reviews',
+          name: 'admin-name-reviews',
+          component: () => import('@/views/admin/NameReviews.vue'),
+          meta: { title: '名称审核 - 管理后台 - TidalCore' }
+        },
         {
           path: 'checkins',
           name: 'admin-checkins',
           component: () => import('@/views/admin/Checkins.vue'),
           meta: { title: '打卡数据 - 管理后台 - TidalCore' }
         },
+        {
+          path: 'audit',
+          name: 'admin-audit',
+          component: () => import('@/views/admin/AuditLogs.vue'),
+          meta: { title: '审计日志 - 管理后台 - TidalCore' }
+        },
         {
           path: 'backup',
           name: 'admin-backup',
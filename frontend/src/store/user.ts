@@ -1,10 +1,20 @@
 import { defineStore } from 'pinia'
 import { ref, computed, watch } from 'vue'
-import { login as apiLogin, register as apiRegister, getProfile } from '@/api/auth'
-import type { LoginRequest, RegisterRequest, UserInfo } from '@/api/auth'
+import {
+  login as apiLogin,
+  loginTwoFactor as apiLoginTwoFactor,
+  exchangeOIDCCode,
+  register as apiRegister,
+  logout as apiLogout,
+  getProfile,
+  refreshToken as apiRefreshToken
+} from '@/api/auth'
+import { loginWithPasskey } from '@/api/passkey'
+import type { AuthResponse, LoginRequest, RegisterRequest, TwoFactorLoginRequest, UserInfo } from '@/api/auth'
 
 export const useUserStore = defineStore('user', () => {
   const token = ref(localStorage.getItem('token') || '')
+  const refreshToken = ref(localStorage.getItem('refresh_token') || '')
   const user = ref<UserInfo | null>(null)
   const isLoading = ref(false)
   const lastFetchTime = ref(0)
@@ -20,13 +30,82 @@ export const useUserStore = defineStore('user', () => {
     }
   })
 
+  watch(refreshToken, (newToken) => {
+    if (newToken) {
+      localStorage.setItem('refresh_token', newToken)
+    } else {
+      localStorage.removeItem('refresh_token')
+    }
+  })
+
+  function setAuth(res: AuthResponse) {
+    token.value = res.token
+    refreshToken.value = res.refresh_token
+    user.value = res.user
+    lastFetchTime.value = Date.now()
+  }
+
+  // Exchange the refresh token for a new token pair.
+  // Concurrent callers share a single in-flight request, since each refresh token can only be used once.
+  let refreshing: Promise<boolean> | null = null
+  function refresh(): Promise<boolean> {
+    if (!refreshToken.value) return Promise.resolve(false)
+    if (!refreshing) {
+      refreshing = apiRefreshToken(refreshToken.value)
+        .then((res) => {
+          setAuth(res)
+          return true
+        })
+        .catch(() => false)
+        .finally(() => {
+          refreshing = null
+        })
+    }
+    return refreshing
+  }
+
   async function login(data: LoginRequest) {
     isLoading.value = true
     try {
       const res = await apiLogin(data)
-      token.value = res.token
-      user.value = res.user
-      lastFetchTime.value = Date.now()
+      if (!res.two_factor_required) {
+        setAuth(res as AuthResponse)
+      }
+      return res
+    } finally {
+      isLoading.value = false
+    }
+  }
+
+  async function loginOIDC(code: string) {
+    isLoading.value = true
+    try {
+      const res = await exchangeOIDCCode(code)
+      if (!res.two_factor_required) {
+        setAuth(res as AuthResponse)
+      }
+      return res
+    } finally {
+      isLoading.value = false
+    }
+  }
+
+  async function loginTwoFactor(data: TwoFactorLoginRequest) {
+    isLoading.value = true
+    try {
+      const res = await apiLoginTwoFactor(data)
+      setAuth(res)
+      return res
+    } finally {
+      isLoading.value = false
+    }
+  }
+
+  async function loginPasskey() {
+    isLoading.value = true
+    try {
+      const res = await loginWithPasskey()
+      setAuth(res)
       return res
     } finally {
       isLoading.value = false
@@ -37,9 +116,7 @@ export const useUserStore = defineStore('user', () => {
     isLoading.value = true
     try {
       const res = await apiRegister(data)
-      token.value = res.token
-      user.value = res.user
-      lastFetchTime.value = Date.now()
+      setAuth(res)
       return res
     } finally {
       isLoading.value = false
@@ -70,7 +147,12 @@ export const useUserStore = defineStore('user', () => {
   }
 
   function logout() {
+    // Revoke the session server-side; local state is cleared regardless of the outcome
+    if (token.value) {
+      apiLogout(token.value, refreshToken.value || undefined).catch(() => {})
+    }
     token.value = ''
+    refreshToken.value = ''
     user.value = null
     lastFetchTime.value = 0
   }
@@ -94,13 +176,19 @@ export const useUserStore = defineStore('user', () => {
 
   return {
     token,
+    refreshToken,
     user,
     isLoggedIn,
     isLoading,
     login,
+    loginTwoFactor,
+    loginOIDC,
+    loginPasskey,
     register,
     fetchProfile,
     logout,
+    refresh,
+    setAuth,
     updateStreak,
     refreshUser
   }